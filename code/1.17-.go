@@ -45,3 +45,28 @@ func isTypeParam(tt types.Type) bool {
 
 func (d *CodeAnalyzer) comfirmDirectSelectorsForInstantiatedType(typeInfo *TypeInfo, currentCounter uint32, fieldMap, methodMap map[string]*TypeInfo) {
 }
+
+func initTypesInfoInstances(info *types.Info) {
+}
+
+func genericTypeComponents(tt types.Type) (components []types.Type, pkg *types.Package, ok bool) {
+	return nil, nil, false
+}
+
+func (d *CodeAnalyzer) findGenericImplementations() {
+}
+
+func (d *CodeAnalyzer) findConstraintSatisfactions() {
+}
+
+func (d *CodeAnalyzer) SatisfiedBys(t *TypeInfo) []*TypeInfo {
+	return nil
+}
+
+func (t *TypeInfo) IsConstraint() bool {
+	return false
+}
+
+func (d *CodeAnalyzer) ObjectInstantiations(obj types.Object) []Instantiation {
+	return nil
+}
@@ -6,8 +6,11 @@ package code
 import (
 	"log"
 	//"fmt"
+	"bytes"
 	"go/ast"
+	"go/token"
 	"go/types"
+	"sort"
 )
 
 var _ = log.Print
@@ -350,3 +353,509 @@ func transformTypeArgs(source TypeExpr, typeArgs []TypeExpr) (*Package, TypeExpr
 
 	return source.Type.TypeName.Pkg, source.Type.TypeName.Source, nextTypeArgs
 }
+
+func initTypesInfoInstances(info *types.Info) {
+	info.Instances = make(map[*ast.Ident]types.Instance)
+}
+
+// genericTypeComponents returns the types directly composing a type
+// parameter, a union or an instantiated type, and the package declaring
+// the type parameter. It returns ok as false for other types.
+func genericTypeComponents(tt types.Type) (components []types.Type, pkg *types.Package, ok bool) {
+	switch tt := tt.(type) {
+	case *types.Named:
+		args := tt.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			components = append(components, args.At(i))
+		}
+		return components, nil, true
+	case *types.TypeParam:
+		return nil, tt.Obj().Pkg(), true
+	case *types.Union:
+		for i := 0; i < tt.Len(); i++ {
+			components = append(components, tt.Term(i).Type())
+		}
+		return components, nil, true
+	}
+	return nil, nil, false
+}
+
+// findGenericImplementations finds the implementation relations missed by
+// findImplementations (https://github.com/golang/go/issues/59224), in which
+// a named type implements an instantiation of a generic interface type.
+// The type arguments of the instantiation are inferred by unifying the
+// method signatures, and they must satisfy the constraints of the generic
+// interface type. The found instantiations are registered as TypeInfos
+// (if they have not been), so that they may be listed as normal types.
+//
+// The instantiation table of generic type names is also rebuilt here.
+//
+// New types might be registered in this function.
+func (d *CodeAnalyzer) findGenericImplementations() {
+	var genericInterfaces, implers []*TypeInfo
+	var ctx = types.NewContext()
+	for _, t := range d.allTypeInfos {
+		if t.TypeName == nil || t.TypeName.Denoting != t {
+			continue
+		}
+		ntt, ok := t.TT.(*types.Named)
+		if !ok {
+			continue
+		}
+		// Constraint-only interfaces neither implement nor are implemented.
+		if isConstraintType(t) {
+			continue
+		}
+		implers = append(implers, t)
+		if itt, ok := ntt.Underlying().(*types.Interface); ok && ntt.TypeParams().Len() > 0 && itt.NumMethods() > 0 {
+			genericInterfaces = append(genericInterfaces, t)
+		}
+	}
+
+	if len(genericInterfaces) > 0 {
+		var methodNames = make(map[string]struct{}, 64)
+		for _, t := range implers {
+			for k := range methodNames {
+				delete(methodNames, k)
+			}
+			for _, sel := range t.AllMethods {
+				methodNames[sel.Name()] = struct{}{}
+			}
+			if len(methodNames) == 0 {
+				continue
+			}
+
+		NextInterface:
+			for _, it := range genericInterfaces {
+				if it == t {
+					continue
+				}
+				itt := it.TT.Underlying().(*types.Interface)
+				for i := itt.NumMethods() - 1; i >= 0; i-- {
+					if _, ok := methodNames[itt.Method(i).Name()]; !ok {
+						continue NextInterface
+					}
+				}
+				d.checkGenericImplementation(ctx, t, it)
+			}
+		}
+	}
+
+	d.typeInstantiations = make(map[*TypeName][]*TypeInfo, 256)
+	for _, t := range d.allTypeInfos {
+		if tn := t.TypeName; tn != nil && tn.Denoting != t && t.Instantiated != nil {
+			d.typeInstantiations[tn] = append(d.typeInstantiations[tn], t)
+		}
+	}
+}
+
+// findConstraintSatisfactions prepares for finding the types satisfying
+// constraint-only interface types (interface types whose type sets are
+// not fully described by their method sets, such as
+// interface{~int | ~string}). Such interface types may be only used as
+// type constraints, so the implementation relations found for them by
+// findImplementations are removed here.
+//
+// Checking all constraints against all types is expensive, so the
+// satisfactions of a constraint are only found when they are requested
+// (see SatisfiedBys).
+func (d *CodeAnalyzer) findConstraintSatisfactions() {
+	d.constraintCandidates = nil
+	for _, t := range d.allTypeInfos {
+		t.satisfiedBys = nil
+		t.attributes &^= satisfiedBysCollected
+
+		if isConstraintType(t) {
+			t.ImplementedBys = nil
+		} else if len(t.Implements) > 0 {
+			impls := t.Implements[:0]
+			for _, impl := range t.Implements {
+				if !isConstraintType(impl.Interface) {
+					impls = append(impls, impl)
+				}
+			}
+			t.Implements = impls
+		}
+
+		if t.TypeName == nil || t.TypeName.Denoting != t || len(t.TypeName.TypeParams) > 0 {
+			continue
+		}
+		// Constraint interfaces and unsafe pseudo types can't be type arguments.
+		if !isConstraintType(t) && t.TypeName.Package().Path != "unsafe" {
+			d.constraintCandidates = append(d.constraintCandidates, t)
+		}
+	}
+}
+
+// SatisfiedBys returns the package-level non-generic named types
+// satisfying the constraint-only interface type t.
+// The result is found on the first call and cached in t.
+//
+// The constraint interfaces declared in the builtin package (comparable)
+// are ignored, for they are satisfied by too many types.
+func (d *CodeAnalyzer) SatisfiedBys(t *TypeInfo) []*TypeInfo {
+	if (t.attributes & satisfiedBysCollected) != 0 {
+		return t.satisfiedBys
+	}
+	t.attributes |= satisfiedBysCollected
+
+	if !isConstraintType(t) {
+		return nil
+	}
+	if t.TypeName == nil || t.TypeName.Denoting != t || len(t.TypeName.TypeParams) > 0 {
+		return nil
+	}
+	if t.TypeName.Package().Path == "builtin" {
+		return nil
+	}
+
+	itt := t.TT.Underlying().(*types.Interface)
+	for _, c := range d.constraintCandidates {
+		if typesSatisfies(c.TT, itt) {
+			t.satisfiedBys = append(t.satisfiedBys, c)
+		}
+	}
+	return t.satisfiedBys
+}
+
+// IsConstraint returns whether or not t is a constraint-only interface type,
+// which can only be used as a type constraint.
+func (t *TypeInfo) IsConstraint() bool {
+	return isConstraintType(t)
+}
+
+func isConstraintType(t *TypeInfo) bool {
+	itt, ok := t.TT.Underlying().(*types.Interface)
+	return ok && !itt.IsMethodSet()
+}
+
+// checkGenericImplementation checks whether or not the origin named type t
+// implements an instantiation of the generic interface type it.
+// If it does, the implementation relation is registered.
+func (d *CodeAnalyzer) checkGenericImplementation(ctx *types.Context, t, it *TypeInfo) {
+	ntt := t.TT.(*types.Named)
+	if tparams := ntt.TypeParams(); tparams.Len() > 0 {
+		// The type parameters of method receivers are different from the
+		// ones of the generic type. Instantiating the generic type with its
+		// own type parameters makes the method signatures use the latter.
+		targs := make([]types.Type, tparams.Len())
+		for i := range targs {
+			targs[i] = tparams.At(i)
+		}
+		inst, err := types.Instantiate(ctx, ntt, targs, false)
+		if err != nil {
+			return
+		}
+		ntt = inst.(*types.Named)
+	}
+	origin := it.TT.(*types.Named)
+	itt := origin.Underlying().(*types.Interface)
+	tparams := origin.TypeParams()
+
+	targs := make([]types.Type, tparams.Len())
+	for i := itt.NumMethods() - 1; i >= 0; i-- {
+		m := itt.Method(i)
+		obj, _, _ := types.LookupFieldOrMethod(ntt, true, m.Pkg(), m.Name())
+		f, ok := obj.(*types.Func)
+		if !ok {
+			return
+		}
+		if !unifyGenericTypes(m.Type(), f.Type(), tparams, targs) {
+			return
+		}
+	}
+
+	typeArgs := make([]TypeExpr, len(targs))
+	for i, targ := range targs {
+		if targ == nil { // used in constraints only
+			return
+		}
+		if !d.buildInferredTypeArg(&typeArgs[i], targ, t.TypeName) {
+			return
+		}
+	}
+
+	// Validate the constraints.
+	inst, err := types.Instantiate(ctx, origin, targs, true)
+	if err != nil {
+		return
+	}
+
+	var impler = t
+	var instItt = inst.Underlying().(*types.Interface)
+	if !types.Implements(ntt, instItt) {
+		if _, ok := ntt.Underlying().(*types.Interface); ok {
+			return
+		}
+		if !types.Implements(types.NewPointer(ntt), instItt) {
+			return
+		}
+		impler = d.RegisterType(types.NewPointer(t.TT))
+	}
+
+	instInfo := d.RegisterType(inst)
+	if instInfo.TypeName == nil {
+		instInfo.TypeName = it.TypeName
+	}
+	if instInfo.Instantiated == nil {
+		instInfo.Instantiated = &InstantiatedInfo{TypeArgs: typeArgs}
+	}
+	d.collectSelectorsForInstantiatedInterface(instInfo, it)
+
+	for _, impl := range t.Implements {
+		if impl.Interface == instInfo {
+			return
+		}
+	}
+	t.Implements = append(t.Implements, Implementation{Impler: impler, Interface: instInfo})
+	instInfo.ImplementedBys = append(instInfo.ImplementedBys, impler)
+
+	if _, ok := ntt.Underlying().(*types.Interface); !ok {
+		for i := itt.NumMethods() - 1; i >= 0; i-- {
+			m := itt.Method(i)
+			var methodPkg string
+			if !m.Exported() {
+				methodPkg = m.Pkg().Path()
+			}
+			d.registerTypeMethodContributingToTypeImplementations(t.TypeName.Package().Path, t.TypeName.Name(), methodPkg, m.Name())
+		}
+	}
+}
+
+// collectSelectorsForInstantiatedInterface collects the method set of
+// an instantiation of the generic interface type origin. Such an
+// instantiation might be registered after all selectors are collected,
+// so its methods are copied from origin, with the real types adjusted.
+func (d *CodeAnalyzer) collectSelectorsForInstantiatedInterface(instInfo, origin *TypeInfo) {
+	if (instInfo.attributes & promotedSelectorsCollected) != 0 {
+		return
+	}
+
+	itt := instInfo.TT.Underlying().(*types.Interface)
+	methodMap := make(map[string]*TypeInfo, itt.NumMethods())
+	for i := itt.NumMethods() - 1; i >= 0; i-- {
+		m := itt.Method(i)
+		methodMap[m.Name()] = d.RegisterType(m.Type())
+	}
+
+	methods := make([]*Selector, len(origin.AllMethods))
+	for i, sel := range origin.AllMethods {
+		insSel := *sel
+		methods[i] = &insSel
+
+		insSel.Instantiated = instInfo.Instantiated
+		insSel.RealType = methodMap[sel.Method.Name]
+		if insSel.RealType == nil {
+			panic("should not")
+		}
+	}
+
+	for _, t := range []*TypeInfo{instInfo, instInfo.Underlying} {
+		if (t.attributes & promotedSelectorsCollected) == 0 {
+			t.DirectSelectors = methods
+			t.AllMethods = methods
+			t.attributes |= directSelectorsCollected | promotedSelectorsCollected
+		}
+	}
+}
+
+// buildInferredTypeArg builds the TypeExpr for an inferred type argument.
+// Only type parameters of the impler type, basic types and non-generic
+// package-level named types are supported now.
+func (d *CodeAnalyzer) buildInferredTypeArg(typeArg *TypeExpr, targ types.Type, impler *TypeName) bool {
+	switch tt := targ.(type) {
+	case *types.TypeParam:
+		if i := tt.Index(); i < len(impler.TypeParams) && impler.TypeParams[i].Type.TT == tt {
+			*typeArg = impler.TypeParams[i]
+			return true
+		}
+	case *types.Basic:
+		*typeArg = TypeExpr{Expr: ast.NewIdent(tt.Name()), Type: d.RegisterType(tt), Pkg: impler.Pkg}
+		return true
+	case *types.Named:
+		if tt.TypeArgs().Len() > 0 || tt.TypeParams().Len() > 0 {
+			return false
+		}
+		obj := tt.Obj()
+		if obj.Pkg() == nil { // error
+			*typeArg = TypeExpr{Expr: ast.NewIdent(obj.Name()), Type: d.RegisterType(tt), Pkg: impler.Pkg}
+			return true
+		}
+		if obj.Parent() != obj.Pkg().Scope() {
+			return false
+		}
+		if pkg := d.PackageByPath(obj.Pkg().Path()); pkg != nil {
+			*typeArg = TypeExpr{Expr: ast.NewIdent(obj.Name()), Type: d.RegisterType(tt), Pkg: pkg}
+			return true
+		}
+	}
+	return false
+}
+
+// unifyGenericTypes reports whether or not x, which might use the type
+// parameters in tparams, can be unified with y. The type arguments
+// inferred in the process are recorded in targs.
+func unifyGenericTypes(x, y types.Type, tparams *types.TypeParamList, targs []types.Type) bool {
+	if tp, ok := x.(*types.TypeParam); ok {
+		if i := tp.Index(); i < tparams.Len() && tparams.At(i) == tp {
+			if targs[i] == nil {
+				targs[i] = y
+				return true
+			}
+			return types.Identical(targs[i], y)
+		}
+	}
+
+	switch x := x.(type) {
+	case *types.Pointer:
+		y, ok := y.(*types.Pointer)
+		return ok && unifyGenericTypes(x.Elem(), y.Elem(), tparams, targs)
+	case *types.Slice:
+		y, ok := y.(*types.Slice)
+		return ok && unifyGenericTypes(x.Elem(), y.Elem(), tparams, targs)
+	case *types.Array:
+		y, ok := y.(*types.Array)
+		return ok && x.Len() == y.Len() && unifyGenericTypes(x.Elem(), y.Elem(), tparams, targs)
+	case *types.Map:
+		y, ok := y.(*types.Map)
+		return ok && unifyGenericTypes(x.Key(), y.Key(), tparams, targs) &&
+			unifyGenericTypes(x.Elem(), y.Elem(), tparams, targs)
+	case *types.Chan:
+		y, ok := y.(*types.Chan)
+		return ok && x.Dir() == y.Dir() && unifyGenericTypes(x.Elem(), y.Elem(), tparams, targs)
+	case *types.Tuple:
+		y, ok := y.(*types.Tuple)
+		if !ok || x.Len() != y.Len() {
+			return false
+		}
+		for i := x.Len() - 1; i >= 0; i-- {
+			if !unifyGenericTypes(x.At(i).Type(), y.At(i).Type(), tparams, targs) {
+				return false
+			}
+		}
+		return true
+	case *types.Signature: // receivers are ignored
+		y, ok := y.(*types.Signature)
+		return ok && x.Variadic() == y.Variadic() &&
+			unifyGenericTypes(x.Params(), y.Params(), tparams, targs) &&
+			unifyGenericTypes(x.Results(), y.Results(), tparams, targs)
+	case *types.Named:
+		y, ok := y.(*types.Named)
+		if !ok || x.Obj() != y.Obj() {
+			return false
+		}
+		xargs, yargs := x.TypeArgs(), y.TypeArgs()
+		if xargs.Len() != yargs.Len() {
+			return false
+		}
+		for i := xargs.Len() - 1; i >= 0; i-- {
+			if !unifyGenericTypes(xargs.At(i), yargs.At(i), tparams, targs) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return types.Identical(x, y)
+}
+
+// ObjectInstantiations returns the instantiation sites of a generic type
+// or function, grouped by type argument lists. The instantiations which
+// use the type parameters of the generic type or function itself, such
+// as the ones in method declarations, are not included.
+func (d *CodeAnalyzer) ObjectInstantiations(obj types.Object) []Instantiation {
+	var tparams *types.TypeParamList
+	switch o := obj.(type) {
+	case *types.TypeName:
+		if ntt, ok := o.Type().(*types.Named); ok {
+			tparams = ntt.TypeParams()
+		}
+	case *types.Func:
+		tparams = o.Type().(*types.Signature).TypeParams()
+	}
+	if tparams.Len() == 0 {
+		return nil
+	}
+
+	var qualifier = func(p *types.Package) string { return p.Path() }
+	var indexes = make(map[string]int, 16)
+	var instantiations []Instantiation
+	var keys []string
+	var b bytes.Buffer
+	for _, id := range d.objectRefs[obj] {
+		inst, ok := id.FileInfo.Pkg.PPkg.TypesInfo.Instances[id.AstIdent]
+		if !ok || inst.TypeArgs.Len() != tparams.Len() {
+			continue
+		}
+
+		b.Reset()
+		selfInstantiated := true
+		for i := 0; i < inst.TypeArgs.Len(); i++ {
+			targ := inst.TypeArgs.At(i)
+			if tp, ok := targ.(*types.TypeParam); !ok || tp.Index() != i || tp != tparams.At(i) && (id.FileInfo.Pkg.PPkg.Types != obj.Pkg() || !isInMethodDeclOf(id.FileInfo.AstFile, id.AstIdent.Pos(), obj.Name())) {
+				selfInstantiated = false
+			}
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			types.WriteType(&b, targ, qualifier)
+		}
+		if selfInstantiated {
+			continue
+		}
+
+		key := b.String()
+		k, ok := indexes[key]
+		if !ok {
+			targs := make([]types.Type, inst.TypeArgs.Len())
+			for i := range targs {
+				targs[i] = inst.TypeArgs.At(i)
+			}
+			k = len(instantiations)
+			indexes[key] = k
+			instantiations = append(instantiations, Instantiation{TypeArgs: targs})
+			keys = append(keys, key)
+		}
+		instantiations[k].Sites = append(instantiations[k].Sites, id)
+	}
+
+	sort.Sort(instantiationsByTypeArgs{instantiations, keys})
+	return instantiations
+}
+
+type instantiationsByTypeArgs struct {
+	instantiations []Instantiation
+	keys           []string
+}
+
+func (s instantiationsByTypeArgs) Len() int           { return len(s.keys) }
+func (s instantiationsByTypeArgs) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s instantiationsByTypeArgs) Swap(i, j int) {
+	s.instantiations[i], s.instantiations[j] = s.instantiations[j], s.instantiations[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// isInMethodDeclOf reports whether or not pos is in the declaration
+// of a method of the generic type named typeName. In such declarations,
+// the receiver type parameters are used instead of the ones of the type.
+func isInMethodDeclOf(file *ast.File, pos token.Pos, typeName string) bool {
+	for _, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Recv == nil || len(fd.Recv.List) == 0 || pos < fd.Pos() || pos >= fd.End() {
+			continue
+		}
+		rt := fd.Recv.List[0].Type
+		if star, ok := rt.(*ast.StarExpr); ok {
+			rt = star.X
+		}
+		switch e := rt.(type) {
+		case *ast.IndexExpr:
+			rt = e.X
+		case *ast.IndexListExpr:
+			rt = e.X
+		}
+		id, ok := rt.(*ast.Ident)
+		return ok && id.Name == typeName
+	}
+	return false
+}
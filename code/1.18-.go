@@ -0,0 +1,42 @@
+//go:build !go1.19
+// +build !go1.19
+
+package code
+
+import (
+	"go/types"
+)
+
+// originFunc returns the generic method of an instantiated method, like
+// the (*types.Func).Origin method (only available since Go 1.19) does.
+func originFunc(f *types.Func) *types.Func {
+	sig, ok := f.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return f
+	}
+	rt := sig.Recv().Type()
+	if ptr, ok := rt.(*types.Pointer); ok {
+		rt = ptr.Elem()
+	}
+	named, ok := rt.(*types.Named)
+	if !ok {
+		return f
+	}
+	origin := originType(named)
+	if origin == named {
+		return f
+	}
+	for i := 0; i < origin.NumMethods(); i++ {
+		if m := origin.Method(i); m.Name() == f.Name() {
+			return m
+		}
+	}
+	return f
+}
+
+// originVar returns v itself. The owner struct type of a field is not
+// known here, so the fields of instantiated types are not mapped to
+// their generic ones before Go 1.19.
+func originVar(v *types.Var) *types.Var {
+	return v
+}
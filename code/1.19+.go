@@ -0,0 +1,16 @@
+//go:build go1.19
+// +build go1.19
+
+package code
+
+import (
+	"go/types"
+)
+
+func originFunc(f *types.Func) *types.Func {
+	return f.Origin()
+}
+
+func originVar(v *types.Var) *types.Var {
+	return v.Origin()
+}
@@ -0,0 +1,15 @@
+//go:build go1.18 && !go1.20
+// +build go1.18,!go1.20
+
+package code
+
+import (
+	"go/types"
+)
+
+// types.Satisfies is only available since Go 1.20.
+// types.Implements also checks the type sets of constraints,
+// it only differs for comparable, which is not checked here.
+func typesSatisfies(v types.Type, t *types.Interface) bool {
+	return types.Implements(v, t)
+}
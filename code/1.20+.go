@@ -0,0 +1,12 @@
+//go:build go1.20
+// +build go1.20
+
+package code
+
+import (
+	"go/types"
+)
+
+func typesSatisfies(v types.Type, t *types.Interface) bool {
+	return types.Satisfies(v, t)
+}
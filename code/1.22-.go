@@ -0,0 +1,13 @@
+//go:build !go1.23
+// +build !go1.23
+
+package code
+
+import (
+	"golang.org/x/tools/go/packages"
+)
+
+// The on-disk cache is only supported when golds is built with Go 1.23+.
+func loadPackagesWithCache(cacheDir string, config *packages.Config, toolchain ToolchainInfo, args ...string) ([]*packages.Package, error) {
+	return packages.Load(config, args...)
+}
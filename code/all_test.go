@@ -1,11 +1,19 @@
 package code
 
 import (
+	"fmt"
+	"go/ast"
 	"go/types"
+	"io/fs"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/types/typeutil"
 )
 
@@ -175,3 +183,573 @@ func TestComparePackagePaths(t *testing.T) {
 		}
 	}
 }
+
+func TestIsDeprecationDoc(t *testing.T) {
+	var vs = []struct {
+		doc        string
+		deprecated bool
+	}{
+		{"", false},
+		{"Deprecated: use Y instead.\n", true},
+		{"F does something.\n\nDeprecated: use G instead.\n", true},
+		{"F does something.\nDeprecated: not a paragraph start.\n", false},
+		{"F is not Deprecated: at all.\n", false},
+		{"Deprecated:no space.\n", false},
+	}
+
+	for _, v := range vs {
+		if IsDeprecationDoc(v.doc) != v.deprecated {
+			t.Errorf("IsDeprecationDoc(%q) != %v", v.doc, v.deprecated)
+		}
+	}
+}
+
+func TestParseAPILine(t *testing.T) {
+	var vs = []struct {
+		line, pkgPath, id string
+	}{
+		{"pkg bytes, func Clone([]uint8) []uint8 #45038", "bytes", "Clone"},
+		{"pkg syscall (linux-386), const AF_ALG = 38", "syscall", "AF_ALG"},
+		{"pkg unicode, var Adlam *RangeTable", "unicode", "Adlam"},
+		{"pkg net/http, method (*Request) Clone(context.Context) *Request", "net/http", "Request.Clone"},
+		{"pkg sync/atomic, method (*Pointer[$0]) Load() *$0 #50860", "sync/atomic", "Pointer.Load"},
+		{"pkg database/sql, type Null[$0 interface{}] struct #60370", "database/sql", "Null"},
+		{"pkg database/sql, type Null[$0 interface{}] struct, V $0 #60370", "database/sql", "Null.V"},
+		{"pkg io, type ByteWriter interface, WriteByte(uint8) error", "io", "ByteWriter.WriteByte"},
+		{"pkg crypto/ecdsa, type PublicKey struct, embedded elliptic.Curve", "crypto/ecdsa", "PublicKey.Curve"},
+		{"pkg go/build/constraint, type Expr interface, unexported methods", "go/build/constraint", ""},
+		{"pkg database/sql/driver, type Stmt interface, Query //deprecated", "database/sql/driver", "Stmt.Query"},
+		{"", "", ""},
+	}
+
+	for _, v := range vs {
+		if pkgPath, id := parseAPILine(v.line); pkgPath != v.pkgPath || id != v.id {
+			t.Errorf("parseAPILine(%q) = (%s, %s), want (%s, %s)", v.line, pkgPath, id, v.pkgPath, v.id)
+		}
+	}
+}
+
+func TestSplitExampleName(t *testing.T) {
+	var vs = []struct {
+		name           string
+		i              int
+		prefix, suffix string
+		ok             bool
+	}{
+		{"Foo", 3, "Foo", "", true},
+		{"Foo_bar", 3, "Foo", "bar", true},
+		{"Foo_Bar", 3, "Foo", "Bar", false},
+		{"T_Method", 1, "T", "Method", false},
+		{"T_Method_second", 8, "T_Method", "second", true},
+		{"_other", 0, "", "other", true},
+		{"Foo_", 3, "", "", false},
+	}
+
+	for _, v := range vs {
+		if prefix, suffix, ok := splitExampleName(v.name, v.i); prefix != v.prefix || suffix != v.suffix || ok != v.ok {
+			t.Errorf("splitExampleName(%q, %d) = (%s, %s, %v), want (%s, %s, %v)", v.name, v.i, prefix, suffix, ok, v.prefix, v.suffix, v.ok)
+		}
+	}
+}
+
+var typesCacheTestConfig = &packages.Config{
+	Mode: packages.NeedName | packages.NeedImports | packages.NeedDeps |
+		packages.NeedTypes | packages.NeedFiles | packages.NeedCompiledGoFiles |
+		packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo,
+}
+
+// describeTypeCheckingResults describes the type-checking results
+// of some packages (and their dependencies) by strings, keyed by positions.
+// Synthetic nodes (such as the implicit 1 in x++) created by go/types
+// are not reachable from syntax trees, so they are not described.
+func describeTypeCheckingResults(ppkgs []*packages.Package) map[string]string {
+	var results = make(map[string]string)
+	packages.Visit(ppkgs, nil, func(ppkg *packages.Package) {
+		var info = ppkg.TypesInfo
+		var nodes = make(map[ast.Node]bool)
+		for _, f := range ppkg.Syntax {
+			ast.Inspect(f, func(n ast.Node) bool {
+				nodes[n] = true
+				return true
+			})
+		}
+		var pos = func(n ast.Node) string {
+			return fmt.Sprintf("%s-%d %T", ppkg.Fset.Position(n.Pos()), ppkg.Fset.Position(n.End()).Offset, n)
+		}
+		for id, obj := range info.Defs {
+			results["def "+pos(id)] = fmt.Sprint(obj)
+		}
+		for id, obj := range info.Uses {
+			results["use "+pos(id)] = fmt.Sprint(obj)
+		}
+		for n, obj := range info.Implicits {
+			results["implicit "+pos(n)] = fmt.Sprint(obj)
+		}
+		for expr, tv := range info.Types {
+			if !nodes[expr] {
+				continue
+			}
+			results["type "+pos(expr)] = fmt.Sprint(tv.Type, tv.Value, tv.IsType(), tv.IsValue(), tv.Addressable(), tv.Assignable(), tv.HasOk())
+		}
+	})
+	return results
+}
+
+// loadPackagesWithTestCache loads packages with the types cache in cacheDir
+// and returns the descriptions of the type-checking results.
+func loadPackagesWithTestCache(t *testing.T, cacheDir string, toolchain ToolchainInfo, args ...string) map[string]string {
+	ppkgs, err := loadPackagesWithCache(cacheDir, typesCacheTestConfig, toolchain, args...)
+	if err != nil {
+		t.Fatalf("load packages error: %s", err)
+	}
+	packages.Visit(ppkgs, nil, func(ppkg *packages.Package) {
+		if len(ppkg.Errors) > 0 {
+			t.Fatalf("package %s has errors: %v", ppkg.PkgPath, ppkg.Errors)
+		}
+	})
+	return describeTypeCheckingResults(ppkgs)
+}
+
+// typesCacheFiles returns the paths of the cache files in cacheDir,
+// relative to cacheDir and separated by slashes.
+func typesCacheFiles(cacheDir string) []string {
+	var files []string
+	filepath.WalkDir(cacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".types") {
+			if rel, err := filepath.Rel(cacheDir, path); err == nil {
+				files = append(files, filepath.ToSlash(rel))
+			}
+		}
+		return nil
+	})
+	return files
+}
+
+func compareTypeCheckingResults(t *testing.T, checked, restored map[string]string) {
+	if len(checked) != len(restored) {
+		t.Errorf("result counts not match: %d vs. %d", len(checked), len(restored))
+	}
+	for k, v := range checked {
+		if restored[k] != v {
+			t.Errorf("%s: results not match: %s vs. %s", k, v, restored[k])
+		}
+	}
+}
+
+func TestCachedPackages(t *testing.T) {
+	var cacheDir = t.TempDir()
+	var checked = loadPackagesWithTestCache(t, cacheDir, ToolchainInfo{}, "go/types", "slices")
+	var restored = loadPackagesWithTestCache(t, cacheDir, ToolchainInfo{}, "go/types", "slices")
+	if len(typesCacheFiles(cacheDir)) == 0 {
+		t.Skip("types cache is not supported")
+	}
+	compareTypeCheckingResults(t, checked, restored)
+}
+
+func TestTypesCacheFiles(t *testing.T) {
+	var cacheDir = t.TempDir()
+
+	var dir = t.TempDir()
+	var files = map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.18\n",
+		"m.go":   "package m\n\nimport \"strings\"\n\nfunc F(s string) string { return strings.ToUpper(s) }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("write file %s error: %s", name, err)
+		}
+	}
+	t.Setenv("GO111MODULE", "on")
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("get working directory error: %s", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("change working directory error: %s", err)
+	}
+	defer os.Chdir(oldDir)
+
+	var toolchain = ToolchainInfo{Version: "go1.99.0"}
+	var checked = loadPackagesWithTestCache(t, cacheDir, toolchain, "./...")
+	var cacheFiles = typesCacheFiles(cacheDir)
+	if len(cacheFiles) == 0 {
+		t.Skip("types cache is not supported")
+	}
+
+	// Only the std packages are cached, in the directory for the toolchain.
+	var hasStrings bool
+	for _, f := range cacheFiles {
+		if !strings.HasPrefix(f, "types/go1.99.0/std/") {
+			t.Errorf("unexpected cache file: %s", f)
+		}
+		if f == "types/go1.99.0/std/strings.types" {
+			hasStrings = true
+		}
+	}
+	if !hasStrings {
+		t.Errorf("package strings is not cached")
+	}
+
+	// Broken cache files are ignored and rewritten.
+	for _, f := range cacheFiles {
+		if err := os.WriteFile(filepath.Join(cacheDir, f), []byte("broken"), 0644); err != nil {
+			t.Fatalf("write cache file %s error: %s", f, err)
+		}
+	}
+	compareTypeCheckingResults(t, checked, loadPackagesWithTestCache(t, cacheDir, toolchain, "./..."))
+	for _, f := range cacheFiles {
+		data, err := os.ReadFile(filepath.Join(cacheDir, f))
+		if err != nil {
+			t.Fatalf("read cache file %s error: %s", f, err)
+		}
+		if string(data) == "broken" {
+			t.Errorf("cache file %s is not rewritten", f)
+		}
+	}
+	compareTypeCheckingResults(t, checked, loadPackagesWithTestCache(t, cacheDir, toolchain, "./..."))
+
+	// Another toolchain version uses another directory.
+	loadPackagesWithTestCache(t, cacheDir, ToolchainInfo{Version: "go1.99.1"}, "./...")
+	if len(typesCacheFiles(cacheDir)) != 2*len(cacheFiles) {
+		t.Errorf("the cache files of toolchain go1.99.1 are not separated")
+	}
+}
+
+// analyzeTestModule analyzes a module made up of the specified files.
+func analyzeTestModule(t *testing.T, files map[string]string) *CodeAnalyzer {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("write file %s error: %s", name, err)
+		}
+	}
+
+	// The tests analyzing the std packages turn the modules feature off.
+	t.Setenv("GO111MODULE", "on")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("get working directory error: %s", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("change working directory error: %s", err)
+	}
+	defer os.Chdir(oldDir)
+
+	var analyzer CodeAnalyzer
+	if err := analyzer.ParsePackages(nil, nil, ToolchainInfo{}, "./..."); err != nil {
+		t.Fatalf("parse packages error: %s", err)
+	}
+	analyzer.AnalyzePackages(nil)
+	return &analyzer
+}
+
+func TestClassifyExamples(t *testing.T) {
+	analyzer := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/ex\n\ngo 1.21\n",
+		"ex.go": `package ex
+
+type T struct{}
+
+func (T) Method() {}
+
+func F() {}
+`,
+		"example_test.go": `package ex_test
+
+func Example() {}
+func Example_other() {}
+func ExampleT() {}
+func ExampleT_Method() {}
+func ExampleT_Method_second() {}
+func ExampleT_method() {}
+func ExampleF_first() {}
+func ExampleT_Unknown() {}
+func ExampleUnknown() {}
+`,
+	})
+
+	pkg := analyzer.PackageByPath("example.com/ex")
+	if pkg == nil {
+		t.Fatal("package example.com/ex is not found")
+	}
+
+	// identifier path -> example names (with suffixes after colons).
+	// The unmatched examples fall back to the package-level ones.
+	var expected = map[string][]string{
+		"":         {":", "T_Unknown:", "Unknown:", "_other:other"},
+		"T":        {"T:", "T_method:method"},
+		"T.Method": {"T_Method:", "T_Method_second:second"},
+		"F":        {"F_first:first"},
+	}
+
+	if len(pkg.ExamplesByIdentifier) != len(expected) {
+		t.Errorf("identifier counts not match: %d vs. %d", len(pkg.ExamplesByIdentifier), len(expected))
+	}
+	for id, want := range expected {
+		var got []string
+		for _, ex := range pkg.ExamplesOf(id) {
+			got = append(got, ex.Name+":"+ex.Suffix)
+		}
+		sort.Strings(got)
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("examples of %q: %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestCollectNotes(t *testing.T) {
+	analyzer := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/notes\n\ngo 1.21\n",
+		"notes.go": `package notes
+
+// BUG(alice): the first bug.
+// It has two lines.
+// TODO(bob): a todo in the same comment group.
+
+// NOTE(carol):
+
+// Not a note: BUG(dave): in the middle.
+
+/* HACK(eve): in a block comment */
+
+// BUG(frank) the second bug.
+func F() {}
+`,
+	})
+
+	pkg := analyzer.PackageByPath("example.com/notes")
+	if pkg == nil {
+		t.Fatal("package example.com/notes is not found")
+	}
+
+	// marker -> "uid: body" list
+	var expected = map[string][]string{
+		"BUG":  {"alice: the first bug.\nIt has two lines.", "frank: the second bug."},
+		"TODO": {"bob: a todo in the same comment group."},
+		"HACK": {"eve: in a block comment"},
+	}
+
+	if len(pkg.Notes) != len(expected) {
+		t.Errorf("marker counts not match: %d vs. %d", len(pkg.Notes), len(expected))
+	}
+	for marker, want := range expected {
+		var got []string
+		for _, note := range pkg.Notes[marker] {
+			got = append(got, note.UID+": "+note.Body)
+		}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("%s notes: %q, want %q", marker, got, want)
+		}
+	}
+
+	if markers := pkg.NoteMarkers(); strings.Join(markers, " ") != "BUG HACK TODO" {
+		t.Errorf("note markers: %v", markers)
+	}
+}
+
+func TestGenericImplementations(t *testing.T) {
+	analyzer := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/gen\n\ngo 1.18\n",
+		"gen.go": `package gen
+
+type Getter[T any] interface {
+	Get() T
+}
+
+// Constraint-only interfaces are satisfied instead of implemented.
+type StructGetter[T any] interface {
+	~struct{}
+	Get() T
+}
+
+type Box[T any] struct{ v T }
+
+func (b Box[T]) Get() T { return b.v }
+
+type IntBox struct{}
+
+func (IntBox) Get() int { return 0 }
+
+type PtrBox struct{}
+
+func (*PtrBox) Get() string { return "" }
+
+type Number interface {
+	~int | ~float64
+}
+
+type Adder[T Number] interface {
+	Add(T) T
+}
+
+type Int int
+
+func (i Int) Add(v Int) Int { return i + v }
+
+type Str string
+
+func (s Str) Add(v Str) Str { return s + v }
+`,
+	})
+
+	pkg := analyzer.PackageByPath("example.com/gen")
+	if pkg == nil {
+		t.Fatal("package example.com/gen is not found")
+	}
+	var qualifier = func(p *types.Package) string { return "" }
+	var typeInfo = func(name string) *TypeInfo {
+		for _, tn := range pkg.AllTypeNames {
+			if tn.Name() == name {
+				return tn.Denoting
+			}
+		}
+		t.Fatalf("type %s is not found", name)
+		return nil
+	}
+	var implements = func(name string) string {
+		var impls []string
+		for _, impl := range typeInfo(name).Implements {
+			impls = append(impls, types.TypeString(impl.Impler.TT, qualifier)+" -> "+types.TypeString(impl.Interface.TT, qualifier))
+		}
+		sort.Strings(impls)
+		return strings.Join(impls, ", ")
+	}
+
+	var expected = map[string]string{
+		"Box":    "Box[T any] -> Getter[T]",
+		"IntBox": "IntBox -> Getter[int]",
+		"PtrBox": "*PtrBox -> Getter[string]",
+		"Int":    "Int -> Adder[Int]",
+		"Str":    "", // Str doesn't satisfy the constraint of Adder
+	}
+	for name, want := range expected {
+		if got := implements(name); got != want {
+			t.Errorf("implementations of %s: %q, want %q", name, got, want)
+		}
+	}
+
+	// The instantiations of the generic interface type list their implementers.
+	var implementedBys []string
+	for _, inst := range analyzer.InstantiatedTypes(typeInfo("Getter").TypeName) {
+		for _, by := range inst.ImplementedBys {
+			implementedBys = append(implementedBys, types.TypeString(inst.TT, qualifier)+" <- "+types.TypeString(by.TT, qualifier))
+		}
+	}
+	sort.Strings(implementedBys)
+	if got, want := strings.Join(implementedBys, ", "), "Getter[T] <- Box[T any], Getter[int] <- IntBox, Getter[string] <- *PtrBox"; got != want {
+		t.Errorf("implemented-bys of Getter instantiations: %q, want %q", got, want)
+	}
+}
+
+func TestObjectInstantiations(t *testing.T) {
+	analyzer := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/inst\n\ngo 1.18\n",
+		"inst.go": `package inst
+
+type Pair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+func (p Pair[K, V]) Swap() Pair[K, V] { return p }
+
+func Map[T, U any](s []T, f func(T) U) []U { return nil }
+
+func Keys[K comparable, V any](ps []Pair[K, V]) []K { return Map(ps, func(p Pair[K, V]) K { return p.Key }) }
+
+var a Pair[string, int]
+var b = Pair[string, int]{}
+var c []Pair[int, bool]
+
+var _ = Map([]int{1}, func(int) string { return "" })
+var _ = Map[int, string]
+var _ = Map([]Pair[int, bool]{}, func(Pair[int, bool]) bool { return true })
+`,
+	})
+
+	pkg := analyzer.PackageByPath("example.com/inst")
+	if pkg == nil {
+		t.Fatal("package example.com/inst is not found")
+	}
+	var qualifier = func(p *types.Package) string { return "" }
+	var instantiations = func(name string) string {
+		var insts []string
+		for _, inst := range analyzer.ObjectInstantiations(pkg.PPkg.Types.Scope().Lookup(name)) {
+			targs := make([]string, len(inst.TypeArgs))
+			for i, targ := range inst.TypeArgs {
+				targs[i] = types.TypeString(targ, qualifier)
+			}
+			lines := make([]string, len(inst.Sites))
+			for i, site := range inst.Sites {
+				lines[i] = fmt.Sprint(site.FileInfo.Pkg.PPkg.Fset.PositionFor(site.AstIdent.Pos(), false).Line)
+			}
+			sort.Strings(lines)
+			insts = append(insts, fmt.Sprintf("[%s] L%s", strings.Join(targs, ", "), strings.Join(lines, ",")))
+		}
+		return strings.Join(insts, "; ")
+	}
+
+	// The self-instantiations in method receivers are excluded,
+	// but the ones with the type parameters of other declarations are not.
+	var expected = map[string]string{
+		"Pair": "[K, V] L12,12; [int, bool] L16,20,20; [string, int] L14,15",
+		"Map":  "[Pair[K, V], K] L12; [Pair[int, bool], bool] L20; [int, string] L18,19",
+		"Keys": "",
+	}
+	for name, want := range expected {
+		if got := instantiations(name); got != want {
+			t.Errorf("instantiations of %s: %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestObjectUseCount(t *testing.T) {
+	analyzer := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/uses\n\ngo 1.18\n",
+		"uses.go": `package uses
+
+type T struct {
+	X int
+}
+
+func (T) M() {}
+
+func Unused() {}
+
+func Used() T {
+	var t T
+	t.M()
+	t.M()
+	return T{X: t.X}
+}
+
+var _ = Used
+`,
+	})
+
+	pkg := analyzer.PackageByPath("example.com/uses")
+	if pkg == nil {
+		t.Fatal("package example.com/uses is not found")
+	}
+	scope := pkg.PPkg.Types.Scope()
+	tt := scope.Lookup("T").Type()
+	m, _, _ := types.LookupFieldOrMethod(tt, false, pkg.PPkg.Types, "M")
+	x, _, _ := types.LookupFieldOrMethod(tt, false, pkg.PPkg.Types, "X")
+
+	var expected = []struct {
+		obj  types.Object
+		uses int
+	}{
+		{scope.Lookup("Unused"), 0},
+		{scope.Lookup("Used"), 1},
+		{scope.Lookup("T"), 4},
+		{m, 2},
+		{x, 2},
+	}
+	for _, e := range expected {
+		if n := analyzer.ObjectUseCount(e.obj); n != e.uses {
+			t.Errorf("use count of %s: %d, want %d", e.obj.Name(), n, e.uses)
+		}
+	}
+}
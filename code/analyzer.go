@@ -9,6 +9,7 @@ import (
 	"go/token"
 	"go/types"
 	"log"
+	"os"
 	"strings"
 	//"runtime/debug"
 
@@ -52,7 +53,8 @@ type CodeAnalyzer struct {
 	modulesByPath       map[string]*Module // including stdModule
 	nonToolchainModules []Module           // not including stdModule and std/cmd module
 	stdModule           *Module
-	wdModule            *Module // working diretory module. It might be the cmd toolchain module, or nil if modules feature is off.
+	wdModule            *Module   // working diretory module. It might be the cmd toolchain module, or nil if modules feature is off.
+	wdModules           []*Module // all working directory modules (more than one for go.work workspaces), including wdModule.
 
 	//stdPackages  map[string]struct{}
 	packageTable map[string]*Package
@@ -79,6 +81,14 @@ type CodeAnalyzer struct {
 	instantiatedTypes        *list.List
 	numSeenInstantiatedTypes uint32
 
+	// Origin generic type names -> their instantiated types.
+	// Rebuilt in findGenericImplementations.
+	typeInstantiations map[*TypeName][]*TypeInfo
+
+	// The types which might satisfy constraint-only interface types.
+	// Rebuilt in findConstraintSatisfactions.
+	constraintCandidates []*TypeInfo
+
 	//>> 1.18, fake underlying for instantiated types
 	// Always nil for 1.17-.
 	//blankInterface *TypeInfo
@@ -106,21 +116,79 @@ type CodeAnalyzer struct {
 	// Identifer references (ToDo: need optimizations)
 	objectRefs map[types.Object][]Identifier
 
+	// Objects documented as deprecated.
+	deprecatedObjects map[types.Object]struct{}
+
+	// The Go versions introducing std packages and identifiers.
+	stdAPIVersions *stdAPIVersions
+
 	// Not concurrent safe.
 	tempTypeLookup map[uint32]struct{}
 
+	// The directory to cache the type-checking results of the packages
+	// in std and dependency modules. Blank means caching is disabled.
+	cacheDir string
+
+	// The extra environment variables used to run go commands,
+	// such as GOWORK=path/to/go.work. Set in ParsePackages.
+	goEnv []string
+
 	//
 	forbidRegisterTypes bool // for debug
 
 	debug bool
 }
 
+// GoCommandEnv returns the extra environment variables which should be
+// used to run go commands for the parsed packages.
+func (d *CodeAnalyzer) GoCommandEnv() []string {
+	return d.goEnv
+}
+
+// packagesConfigEnv returns the Env value for a packages.Config.
+// nil means the environment of the current process.
+func (d *CodeAnalyzer) packagesConfigEnv() []string {
+	if len(d.goEnv) == 0 {
+		return nil
+	}
+	return append(os.Environ(), d.goEnv...)
+}
+
+// EnableCache makes the analyzer cache the type-checking results of
+// the packages in std and versioned dependency modules in the specified
+// directory, so that later ParsePackages calls (including the ones in
+// later processes) don't need to type-check these packages again.
+// A blank dir disables caching.
+func (d *CodeAnalyzer) EnableCache(dir string) {
+	d.cacheDir = dir
+}
+
 // WorkingDirectoryModule returns the module at the working directory.
 // It might be nil.
 func (d *CodeAnalyzer) WorkingDirectoryModule() *Module {
 	return d.wdModule
 }
 
+// WorkingDirectoryModules returns all the working directory modules.
+// There might be multiple ones when a go.work file is used.
+func (d *CodeAnalyzer) WorkingDirectoryModules() []*Module {
+	return d.wdModules
+}
+
+// IsWorkingDirectoryModule returns whether or not the specified module
+// is a working directory module.
+func (d *CodeAnalyzer) IsWorkingDirectoryModule(m *Module) bool {
+	if m == nil {
+		return false
+	}
+	for _, wdm := range d.wdModules {
+		if wdm == m {
+			return true
+		}
+	}
+	return false
+}
+
 // ModuleByPath returns the module corresponding the specified path.
 func (d *CodeAnalyzer) ModuleByPath(path string) *Module {
 	return d.modulesByPath[path]
@@ -131,12 +199,12 @@ func (d *CodeAnalyzer) IterateModule(f func(*Module)) {
 	if d.stdModule != nil {
 		f(d.stdModule)
 	}
-	if d.wdModule != nil {
-		f(d.wdModule)
+	for _, m := range d.wdModules {
+		f(m)
 	}
 	for i := range d.nonToolchainModules {
 		m := &d.nonToolchainModules[i]
-		if m != d.wdModule {
+		if !d.IsWorkingDirectoryModule(m) {
 			f(m)
 		}
 	}
@@ -166,6 +234,47 @@ func (d *CodeAnalyzer) ObjectReferences(obj types.Object) []Identifier {
 	return dups
 }
 
+// IterateObjectReferences calls f for each referenced object
+// with its references. The references should not be modified.
+func (d *CodeAnalyzer) IterateObjectReferences(f func(types.Object, []Identifier)) {
+	for obj, ids := range d.objectRefs {
+		f(obj, ids)
+	}
+}
+
+// ObjectUseCount returns the number of the references to the given object.
+// The identifiers declaring the object are not counted.
+func (d *CodeAnalyzer) ObjectUseCount(obj types.Object) int {
+	n := 0
+	for _, id := range d.objectRefs[obj] {
+		if id.FileInfo.Pkg.PPkg.TypesInfo.Defs[id.AstIdent] != obj {
+			n++
+		}
+	}
+	return n
+}
+
+// PackageExternalUseCounts returns the numbers of the references to the
+// objects declared in each package from other packages, keyed by package
+// import paths. The uses of universe objects are counted for "builtin".
+func (d *CodeAnalyzer) PackageExternalUseCounts() map[string]int {
+	var counts = make(map[string]int, len(d.packageList))
+	for obj, ids := range d.objectRefs {
+		path := "builtin"
+		if pkg := obj.Pkg(); pkg != nil {
+			path = pkg.Path()
+		}
+		n := 0
+		for _, id := range ids {
+			if id.FileInfo.Pkg.Path != path {
+				n++
+			}
+		}
+		counts[path] += n
+	}
+	return counts
+}
+
 // Please reset it after using.
 func (d *CodeAnalyzer) tempTypeLookupTable() map[uint32]struct{} {
 	if d.tempTypeLookup == nil {
@@ -181,6 +290,16 @@ func (d *CodeAnalyzer) resetTempTypeLookupTable() {
 	}
 }
 
+// NumTypeInfos returns the number of the registered types.
+func (d *CodeAnalyzer) NumTypeInfos() int {
+	return len(d.allTypeInfos)
+}
+
+// TypeInfoAt returns the registered type at the specified index.
+func (d *CodeAnalyzer) TypeInfoAt(i int) *TypeInfo {
+	return d.allTypeInfos[i]
+}
+
 // NumPackages returns packages count.
 func (d *CodeAnalyzer) NumPackages() int {
 	return len(d.packageList)
@@ -517,6 +636,13 @@ func (d *CodeAnalyzer) CheckTypeMethodContributingToTypeImplementations(pkg, typ
 	return ok
 }
 
+// InstantiatedTypes returns the instantiated types of a generic type name,
+// including the instantiations of generic interface types which are only
+// used in implementation relations.
+func (d *CodeAnalyzer) InstantiatedTypes(tn *TypeName) []*TypeInfo {
+	return d.typeInstantiations[tn]
+}
+
 // CleanImplements returns a clean list of the implementions for a TypeInfo.
 func (d *CodeAnalyzer) CleanImplements(self *TypeInfo, includingUnnamed bool) []Implementation {
 	// remove:
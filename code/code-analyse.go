@@ -68,12 +68,15 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 	d.findImplementations()
 	methodCache := &typeutil.MethodSetCache{}
 	d.forbidRegisterTypes = false
+	d.findGenericImplementations()
+	d.findConstraintSatisfactions()
 	logProgress(SubTask_FindImplementations)
 
 	d.registerNamedInterfaceMethodsForInvolvedTypeNames()
 	logProgress(SubTask_RegisterInterfaceMethodsForTypes)
 
 	d.collectObjectReferences()
+	d.collectDeprecatedObjects()
 	logProgress(SubTask_CollectObjectReferences)
 
 	d.collectCodeExamples() // need the pkg.Directory confirmed in the last step
@@ -84,6 +87,7 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 	logProgress(SubTask_CacheSourceFiles)
 
 	d.collectSomeRuntimeFunctionPositions()
+	d.collectStdAPIVersions()
 	logProgress(SubTask_CollectRuntimeFunctionPositions)
 
 	for _, pkg := range d.packageList {
@@ -1644,9 +1648,13 @@ func (d *CodeAnalyzer) sortPackagesByDepHeight() {
 	var old = d.builtinPkg.DepHeight
 	d.builtinPkg.DepHeight = 0
 
-	// The analyse order:
+	// The analyse order. Sort by paths for the same heights, so that
+	// the package indexes (used in the search index) are deterministic.
 	sort.Slice(d.packageList, func(i, j int) bool {
-		return d.packageList[i].DepHeight < d.packageList[j].DepHeight
+		if d.packageList[i].DepHeight != d.packageList[j].DepHeight {
+			return d.packageList[i].DepHeight < d.packageList[j].DepHeight
+		}
+		return d.packageList[i].Path < d.packageList[j].Path
 	})
 
 	d.builtinPkg.DepHeight = old
@@ -0,0 +1,2129 @@
+//go:build go1.23
+// +build go1.23
+
+package code
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"go/version"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/objectpath"
+)
+
+// The on-disk cache stores the type-checking results (the types.Package
+// and types.Info of a package) of the packages in std and in versioned
+// (so immutable) dependency modules. When a cached result is valid, it is
+// restored instead of type-checking the package again, so that a restart
+// only needs to type-check the packages in the working directory modules.
+// The analysis of golds itself is cross-package, so it is always re-done.
+//
+// A package is cacheable only if it is a std or cmd package, or belongs
+// to a module with a version and without a replacement, and all of its
+// dependencies are cacheable. The cache key of a package is derived from
+// the Go versions (of the analyzed toolchain and of the toolchain building
+// golds), the type sizes, the module path and version, the package file
+// names and the cache keys of the dependency packages. The contents of
+// versioned modules and released toolchains are immutable, so the sizes
+// and modification times of the package files are only used for the std
+// and cmd packages of a development toolchain (and for the files generated
+// by cgo).
+
+const (
+	typesCacheMagic         = "golds types cache\n"
+	typesCacheFormatVersion = 1
+)
+
+// loadPackagesWithCache works like packages.Load, but restores the
+// type-checking results of the cacheable packages from the cache in
+// cacheDir if they are valid, and saves the missing ones into cacheDir.
+// config must request at least the modes requested by ParsePackages.
+func loadPackagesWithCache(cacheDir string, config *packages.Config, toolchain ToolchainInfo, args ...string) ([]*packages.Package, error) {
+	var listConfig = &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+			packages.NeedImports | packages.NeedDeps | packages.NeedModule | packages.NeedTypesSizes,
+		Tests: config.Tests,
+		Env:   config.Env,
+	}
+	roots, err := packages.Load(listConfig, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var versionDir = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, toolchain.Version)
+	if versionDir == "" {
+		versionDir = "unknown"
+	}
+
+	var goroot = toolchain.Root
+	if goroot == "" {
+		goroot = runtime.GOROOT()
+	}
+
+	var ld = &cachedPackagesLoader{
+		config:     config,
+		fset:       token.NewFileSet(),
+		dir:        filepath.Join(cacheDir, "types", versionDir),
+		toolchain:  toolchain.Version,
+		gorootSrc:  filepath.Join(goroot, "src") + string(filepath.Separator),
+		items:      make(map[string]*cachedPackage, 1024),
+		tokenFiles: make(map[string]*token.File, 8192),
+	}
+	packages.Visit(roots, nil, func(ppkg *packages.Package) {
+		ld.items[ppkg.ID] = &cachedPackage{ppkg: ppkg}
+	})
+
+	var wg sync.WaitGroup
+	for _, ppkg := range roots {
+		wg.Add(1)
+		go func(item *cachedPackage) {
+			defer wg.Done()
+			ld.load(item)
+		}(ld.items[ppkg.ID])
+	}
+	wg.Wait()
+
+	// ParsePackages doesn't request NeedModule.
+	for _, item := range ld.items {
+		item.ppkg.Module = nil
+	}
+
+	return roots, nil
+}
+
+type cachedPackagesLoader struct {
+	config    *packages.Config
+	fset      *token.FileSet
+	dir       string // the cache directory for the current toolchain
+	toolchain string
+	gorootSrc string
+
+	items map[string]*cachedPackage // by package IDs
+
+	mu         sync.Mutex
+	tokenFiles map[string]*token.File // by file paths
+}
+
+type cachedPackage struct {
+	ppkg *packages.Package
+	once sync.Once
+	key  string // blank for non-cacheable packages
+	path string // the cache file path
+
+	files     []*ast.File
+	parseErrs []error
+}
+
+// load loads a package after its dependency packages are loaded.
+// The files of the package are parsed when loading the dependencies.
+func (ld *cachedPackagesLoader) load(item *cachedPackage) {
+	item.once.Do(func() {
+		var wg sync.WaitGroup
+		if item.ppkg.PkgPath != "unsafe" {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				item.files, item.parseErrs = ld.parseFiles(item.ppkg.CompiledGoFiles)
+			}()
+		}
+		for _, imp := range item.ppkg.Imports {
+			wg.Add(1)
+			go func(item *cachedPackage) {
+				defer wg.Done()
+				ld.load(item)
+			}(ld.items[imp.ID])
+		}
+		wg.Wait()
+
+		ld.loadPackage(item)
+	})
+}
+
+// loadPackage mirrors the loadPackage method of the loader in go/packages.
+func (ld *cachedPackagesLoader) loadPackage(item *cachedPackage) {
+	var ppkg = item.ppkg
+	ppkg.Fset = ld.fset
+	if ppkg.PkgPath == "unsafe" {
+		ppkg.Types = types.Unsafe
+		ppkg.Syntax = []*ast.File{}
+		ppkg.TypesInfo = new(types.Info)
+		item.key = "unsafe"
+		return
+	}
+
+	var appendError = func(err error) {
+		switch err := err.(type) {
+		case *os.PathError:
+			ppkg.Errors = append(ppkg.Errors, packages.Error{
+				Pos:  err.Path + ":1",
+				Msg:  err.Err.Error(),
+				Kind: packages.ParseError,
+			})
+		case scanner.ErrorList:
+			for _, err := range err {
+				ppkg.Errors = append(ppkg.Errors, packages.Error{
+					Pos:  err.Pos.String(),
+					Msg:  err.Msg,
+					Kind: packages.ParseError,
+				})
+			}
+		case types.Error:
+			ppkg.TypeErrors = append(ppkg.TypeErrors, err)
+			ppkg.Errors = append(ppkg.Errors, packages.Error{
+				Pos:  err.Fset.Position(err.Pos).String(),
+				Msg:  err.Msg,
+				Kind: packages.TypeError,
+			})
+		default:
+			ppkg.Errors = append(ppkg.Errors, packages.Error{
+				Pos:  "-",
+				Msg:  err.Error(),
+				Kind: packages.UnknownError,
+			})
+		}
+	}
+
+	ppkg.Syntax = item.files
+	for _, err := range item.parseErrs {
+		appendError(err)
+	}
+
+	var cacheable = len(ppkg.Errors) == 0 && ld.prepareCacheKey(item)
+	if cacheable {
+		tpkg, info, err := ld.restore(item)
+		if err == nil {
+			ppkg.Types, ppkg.TypesInfo = tpkg, info
+			return
+		}
+	}
+
+	ppkg.Types = types.NewPackage(ppkg.PkgPath, ppkg.Name)
+	ppkg.TypesInfo = newTypesInfo()
+
+	var importer = importerFunc(func(path string) (*types.Package, error) {
+		if path == "unsafe" {
+			return types.Unsafe, nil
+		}
+		if ipkg := ppkg.Imports[path]; ipkg != nil {
+			return ipkg.Types, nil
+		}
+		return nil, fmt.Errorf("no metadata for %s", path)
+	})
+	var conf = &types.Config{
+		Importer: importer,
+		Error:    appendError,
+		Sizes:    ppkg.TypesSizes,
+	}
+	if ppkg.Module != nil && ppkg.Module.GoVersion != "" {
+		conf.GoVersion = "go" + ppkg.Module.GoVersion
+	}
+	types.NewChecker(conf, ld.fset, ppkg.Types, ppkg.TypesInfo).Files(ppkg.Syntax)
+
+	if len(ppkg.Errors) == 0 {
+		for _, imp := range ppkg.Imports {
+			if imp.IllTyped {
+				ppkg.IllTyped = true
+				break
+			}
+		}
+	} else {
+		ppkg.IllTyped = true
+	}
+
+	if ppkg.IllTyped {
+		item.key = "" // the dependent packages are not cacheable
+	} else if cacheable {
+		ld.save(item)
+	}
+}
+
+func newTypesInfo() *types.Info {
+	var info = &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Scopes:     make(map[ast.Node]*types.Scope),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	initTypesInfoInstances(info)
+	return info
+}
+
+// We use a counting semaphore to limit the number of parallel file reads.
+var cacheLoaderIOLimit = make(chan bool, 20)
+
+func (ld *cachedPackagesLoader) parseFiles(filenames []string) ([]*ast.File, []error) {
+	var wg sync.WaitGroup
+	var files = make([]*ast.File, len(filenames))
+	var errs = make([]error, len(filenames))
+	for i, filename := range filenames {
+		wg.Add(1)
+		go func(i int, filename string) {
+			defer wg.Done()
+			cacheLoaderIOLimit <- true
+			src, err := os.ReadFile(filename)
+			<-cacheLoaderIOLimit
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			var parseFile = ld.config.ParseFile
+			if parseFile == nil {
+				parseFile = func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+					return parser.ParseFile(fset, filename, src, parser.AllErrors|parser.ParseComments)
+				}
+			}
+			files[i], errs[i] = parseFile(ld.fset, filename, src)
+			if files[i] != nil {
+				ld.mu.Lock()
+				ld.tokenFiles[filename] = ld.fset.File(files[i].Pos())
+				ld.mu.Unlock()
+			}
+		}(i, filename)
+	}
+	wg.Wait()
+
+	var parsed = files[:0]
+	for _, f := range files {
+		if f != nil {
+			parsed = append(parsed, f)
+		}
+	}
+	var errors = errs[:0]
+	for _, err := range errs {
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return parsed, errors
+}
+
+func (ld *cachedPackagesLoader) tokenFile(filename string) *token.File {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	return ld.tokenFiles[filename]
+}
+
+// prepareCacheKey sets the cache key and the cache file path of a package
+// and reports whether or not the package is cacheable.
+func (ld *cachedPackagesLoader) prepareCacheKey(item *cachedPackage) bool {
+	var ppkg = item.ppkg
+
+	var dir, moduleDir string
+	var immutable = true
+	if m := ppkg.Module; m == nil {
+		dir, moduleDir = ld.gorootSrc, "std"
+		immutable = version.IsValid(ld.toolchain)
+	} else if m.Path == "cmd" {
+		dir, moduleDir = ld.gorootSrc, "cmd"
+		immutable = version.IsValid(ld.toolchain)
+	} else if m.Version != "" && m.Replace == nil && m.Dir != "" {
+		escapedPath, err := module.EscapePath(m.Path)
+		if err != nil {
+			return false
+		}
+		escapedVersion, err := module.EscapeVersion(m.Version)
+		if err != nil {
+			return false
+		}
+		dir, moduleDir = m.Dir+string(filepath.Separator), filepath.FromSlash(escapedPath+"@"+escapedVersion)
+	} else {
+		return false
+	}
+	var pkgPath = ppkg.PkgPath // std and cmd package paths need no escaping
+	if ppkg.Module != nil && ppkg.Module.Path != "cmd" {
+		escapedPath, err := module.EscapePath(pkgPath)
+		if err != nil {
+			return false
+		}
+		pkgPath = escapedPath
+	}
+
+	var h = sha256.New()
+	fmt.Fprintf(h, "%d\n%s\n%s\n%T%v\n", typesCacheFormatVersion, runtime.Version(), ld.toolchain, ppkg.TypesSizes, ppkg.TypesSizes)
+	fmt.Fprintf(h, "%s\n%s\n%s\n", ppkg.ID, ppkg.PkgPath, ppkg.Name)
+	if m := ppkg.Module; m != nil {
+		fmt.Fprintf(h, "%s@%s go%s\n", m.Path, m.Version, m.GoVersion)
+	}
+	for _, f := range ppkg.GoFiles {
+		if !strings.HasPrefix(f, dir) {
+			return false
+		}
+	}
+	// For packages using cgo, some compiled files are
+	// generated in the build cache directory.
+	for _, f := range ppkg.CompiledGoFiles {
+		if immutable && strings.HasPrefix(f, dir) {
+			fmt.Fprintf(h, "%s\n", f[len(dir):])
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			return false
+		}
+		fmt.Fprintf(h, "%s %d %d\n", f, info.Size(), info.ModTime().UnixNano())
+	}
+	var imports = make([]string, 0, len(ppkg.Imports))
+	for path := range ppkg.Imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	for _, path := range imports {
+		var dep = ld.items[ppkg.Imports[path].ID]
+		if dep.key == "" {
+			return false
+		}
+		fmt.Fprintf(h, "%s %s %s\n", path, dep.ppkg.ID, dep.key)
+	}
+
+	item.key = hex.EncodeToString(h.Sum(nil))
+	item.path = filepath.Join(ld.dir, moduleDir, filepath.FromSlash(pkgPath)+".types")
+	return true
+}
+
+// dependencyPackages returns all the dependency packages of a package, by paths.
+func (ld *cachedPackagesLoader) dependencyPackages(ppkg *packages.Package) map[string]*types.Package {
+	var deps = make(map[string]*types.Package, 64)
+	var collect func(ppkg *packages.Package)
+	collect = func(ppkg *packages.Package) {
+		for _, imp := range ppkg.Imports {
+			if _, ok := deps[imp.PkgPath]; !ok {
+				deps[imp.PkgPath] = imp.Types
+				collect(imp)
+			}
+		}
+	}
+	collect(ppkg)
+	return deps
+}
+
+func (ld *cachedPackagesLoader) restore(item *cachedPackage) (*types.Package, *types.Info, error) {
+	data, err := os.ReadFile(item.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var d = &typesCacheDecoder{
+		ld:   ld,
+		ppkg: item.ppkg,
+		deps: ld.dependencyPackages(item.ppkg),
+	}
+	return d.decode(data, item.key)
+}
+
+func (ld *cachedPackagesLoader) save(item *cachedPackage) {
+	var e = &typesCacheEncoder{
+		ppkg: item.ppkg,
+		pkg:  item.ppkg.Types,
+		info: item.ppkg.TypesInfo,
+		fset: ld.fset,
+	}
+	data, err := e.encode(item.key)
+	if err != nil {
+		return // not cacheable
+	}
+
+	// Write atomically, for other golds processes might be reading it.
+	if err := os.MkdirAll(filepath.Dir(item.path), 0755); err != nil {
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(item.path), filepath.Base(item.path)+".*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), item.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+//=== The cache file format
+
+// A cache file is composed of a header, a record section and an info
+// section. The header contains the magic string, the format version and
+// the cache key. Each record in the record section creates or finds
+// a types.Object or a types.Type, and a record only references
+// the records before it (by index+1, 0 means nil). The info section
+// contains the entries of the types.Info maps, keyed by the orders of
+// the AST nodes in the package files (by ast.Inspect).
+
+// Record kinds.
+const (
+	_ = iota
+
+	// objects
+	recUniverseObject // name
+	recErrorMethod    //
+	recScopeObject    // pkgpath, name
+	recObjectPath     // pkgpath, objectpath
+	recMemberObject   // type, member kind, index
+	recTypeName       // owned object head, kind[, type]
+	recVar            // owned object head, flags, type
+	recFunc           // owned object head, signature
+	recConst          // owned object head, type, value
+	recPkgName        // owned object head, imported pkgpath
+	recLabel          // owned object head
+
+	// types
+	recBasic         // kind
+	recPointer       // elem
+	recSlice         // elem
+	recArray         // elem, len
+	recMap           // key, elem
+	recChan          // dir, elem
+	recTuple         // nil?, vars
+	recSignature     // recv, recv tparams, tparams, params, results, variadic
+	recStruct        // fields, tags
+	recInterface     // methods, embeddeds, implicit
+	recUnion         // terms
+	recTypeParam     // obj
+	recNamed         // obj, tparams
+	recAlias         // obj, tparams, rhs
+	recInstance      // origin, args
+	recTypeOf        // obj
+	recUnderlyingOf  // type
+	recFillTypeParam // tparam, constraint
+	recFillNamed     // named, underlying, methods
+)
+
+// Member kinds.
+const (
+	memberMethod = iota
+	memberField
+	memberInterfaceMethod
+)
+
+// Object parents (for owned objects).
+const (
+	parentNone         = iota
+	parentPackageScope // inserted
+	parentFileScope
+	parentLocal // in a scope derived from a file scope
+	parentLabel
+	parentInit // package level, but not inserted
+)
+
+// Flags of info entries.
+const (
+	infoDef = 1 << iota
+	infoUse
+	infoImplicit
+	infoType
+)
+
+var errBadTypesCache = errors.New("bad types cache")
+
+type typesCacheAbort struct{ reason string }
+
+//=== writer and reader
+
+type cacheWriter struct {
+	buf  []byte
+	strs map[string]uint64
+}
+
+func (w *cacheWriter) uint(v uint64) { w.buf = binary.AppendUvarint(w.buf, v) }
+func (w *cacheWriter) int(v int64)   { w.buf = binary.AppendVarint(w.buf, v) }
+
+func (w *cacheWriter) bool(b bool) {
+	if b {
+		w.uint(1)
+	} else {
+		w.uint(0)
+	}
+}
+
+func (w *cacheWriter) bytes(bs []byte) {
+	w.uint(uint64(len(bs)))
+	w.buf = append(w.buf, bs...)
+}
+
+func (w *cacheWriter) string(s string) {
+	if i, ok := w.strs[s]; ok {
+		w.uint(i)
+		return
+	}
+	if w.strs == nil {
+		w.strs = make(map[string]uint64, 1024)
+	}
+	w.strs[s] = uint64(len(w.strs)) + 1
+	w.uint(0)
+	w.bytes([]byte(s))
+}
+
+type cacheReader struct {
+	data []byte
+	strs []string
+}
+
+func (r *cacheReader) uint() uint64 {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		panic(errBadTypesCache)
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *cacheReader) int() int64 {
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		panic(errBadTypesCache)
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *cacheReader) bool() bool { return r.uint() != 0 }
+
+func (r *cacheReader) bytes() []byte {
+	n := r.uint()
+	if n > uint64(len(r.data)) {
+		panic(errBadTypesCache)
+	}
+	bs := r.data[:n:n]
+	r.data = r.data[n:]
+	return bs
+}
+
+func (r *cacheReader) string() string {
+	i := r.uint()
+	if i == 0 {
+		s := string(r.bytes())
+		r.strs = append(r.strs, s)
+		return s
+	}
+	if i > uint64(len(r.strs)) {
+		panic(errBadTypesCache)
+	}
+	return r.strs[i-1]
+}
+
+//=== TypeAndValue modes
+
+// The mode of a types.TypeAndValue is unexported. A TypeAndValue is
+// restored by copying a template value with the same predicate results
+// and then setting its Type and Value fields.
+
+func typeAndValueModeKey(tv types.TypeAndValue) uint64 {
+	var key uint64
+	for i, b := range [...]bool{tv.IsVoid(), tv.IsType(), tv.IsBuiltin(), tv.IsValue(),
+		tv.IsNil(), tv.Addressable(), tv.Assignable(), tv.HasOk(), tv.Value != nil} {
+		if b {
+			key |= 1 << i
+		}
+	}
+	return key
+}
+
+var typeAndValueTemplates = sync.OnceValue(func() map[uint64]types.TypeAndValue {
+	const src = `package p
+
+var x int
+var m map[int]int
+var c chan int
+
+func f() {}
+
+func g() {
+	f()
+	_ = len(m)
+	_ = x
+	_ = m[0]
+	_ = x + 1
+	_ = 1
+	_ = m == nil
+	var v, ok = m[0]
+	_, _ = v, ok
+	_, _ = <-c
+	type T int
+	_ = T(1)
+}
+`
+	var fset = token.NewFileSet()
+	var templates = make(map[uint64]types.TypeAndValue)
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		return templates
+	}
+	var info = &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	if _, err := new(types.Config).Check("p", fset, []*ast.File{f}, info); err != nil {
+		return templates
+	}
+	for _, tv := range info.Types {
+		templates[typeAndValueModeKey(tv)] = tv
+	}
+	return templates
+})
+
+//=== encoder
+
+type typesCacheEncoder struct {
+	ppkg *packages.Package
+	pkg  *types.Package
+	info *types.Info
+	fset *token.FileSet
+
+	rec        cacheWriter
+	numRecords uint64
+
+	objRefs  map[types.Object]uint64
+	typeRefs map[types.Type]uint64
+	busy     map[any]bool
+
+	fileScopes map[*types.Scope]int
+
+	// Objects which are members of foreign or instantiated named types.
+	members map[types.Object]objectMember
+	// Types of foreign objects and underlying types of foreign
+	// and instantiated named types.
+	typeOwners       map[types.Type]types.Object
+	underlyingOwners map[types.Type]types.Type
+	visited          map[types.Type]bool
+
+	pendings    []*types.Named     // named types to fill
+	tparams     []*types.TypeParam // type parameters to set constraints
+	fillStates  map[*types.Named]int
+	boundTParam map[*types.TypeParam]bool
+	interfaces  []*types.Interface
+
+	objectPaths *objectpath.Encoder
+}
+
+type objectMember struct {
+	owner types.Type
+	kind  int
+	index int
+}
+
+func (e *typesCacheEncoder) abort(format string, args ...any) {
+	panic(typesCacheAbort{fmt.Sprintf(format, args...)})
+}
+
+func (e *typesCacheEncoder) encode(key string) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if a, ok := r.(typesCacheAbort); ok {
+				err = errors.New(a.reason)
+			} else {
+				// objectpath and go/types might panic on unexpected types.
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	e.objRefs = make(map[types.Object]uint64, 4096)
+	e.typeRefs = make(map[types.Type]uint64, 4096)
+	e.busy = make(map[any]bool)
+	e.fileScopes = make(map[*types.Scope]int, len(e.ppkg.Syntax))
+	e.members = make(map[types.Object]objectMember, 1024)
+	e.typeOwners = make(map[types.Type]types.Object, 1024)
+	e.underlyingOwners = make(map[types.Type]types.Type, 256)
+	e.visited = make(map[types.Type]bool, 4096)
+	e.fillStates = make(map[*types.Named]int, 256)
+	e.boundTParam = make(map[*types.TypeParam]bool, 64)
+	e.objectPaths = new(objectpath.Encoder)
+
+	for i, f := range e.ppkg.Syntax {
+		scope := e.info.Scopes[f]
+		if scope == nil {
+			e.abort("file scope not found")
+		}
+		e.fileScopes[scope] = i
+	}
+
+	e.registerMembers()
+
+	// info section
+	var info cacheWriter
+	for _, f := range e.ppkg.Syntax {
+		e.encodeFileInfo(&info, f)
+	}
+	e.setConstraints()
+
+	// package data
+	var pkgData cacheWriter
+	pkgData.string(e.pkg.Name())
+	pkgData.uint(uint64(len(e.pkg.Imports())))
+	for _, imp := range e.pkg.Imports() {
+		pkgData.string(imp.Path())
+	}
+	pkgData.uint(uint64(len(e.interfaces)))
+	for _, iface := range e.interfaces {
+		pkgData.uint(e.typeRefs[iface])
+	}
+
+	var w cacheWriter
+	w.buf = append(w.buf, typesCacheMagic...)
+	w.uint(typesCacheFormatVersion)
+	w.string(key)
+	w.uint(uint64(len(e.ppkg.Syntax)))
+	w.uint(e.numRecords)
+	w.bytes(e.rec.buf)
+	w.bytes(pkgData.buf)
+	w.bytes(info.buf)
+	return w.buf, nil
+}
+
+// registerMembers collects the objects which are members of the foreign
+// and instantiated named types which are used in the current package.
+func (e *typesCacheEncoder) registerMembers() {
+	for _, obj := range e.info.Uses {
+		if pkg := obj.Pkg(); pkg != nil && pkg != e.pkg {
+			switch t := obj.Type().(type) {
+			case *types.Signature, *types.Struct, *types.Interface:
+				if _, ok := e.typeOwners[t]; !ok {
+					e.typeOwners[t] = obj
+				}
+			}
+		}
+		e.visitType(obj.Type())
+	}
+	for _, obj := range e.info.Defs {
+		if obj != nil {
+			e.visitType(obj.Type())
+		}
+	}
+	for _, obj := range e.info.Implicits {
+		e.visitType(obj.Type())
+	}
+	for _, tv := range e.info.Types {
+		e.visitType(tv.Type)
+	}
+}
+
+func (e *typesCacheEncoder) visitType(t types.Type) {
+	if t == nil || e.visited[t] {
+		return
+	}
+	e.visited[t] = true
+
+	switch t := t.(type) {
+	case *types.Basic:
+	case *types.Pointer:
+		e.visitType(t.Elem())
+	case *types.Slice:
+		e.visitType(t.Elem())
+	case *types.Array:
+		e.visitType(t.Elem())
+	case *types.Map:
+		e.visitType(t.Key())
+		e.visitType(t.Elem())
+	case *types.Chan:
+		e.visitType(t.Elem())
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			e.visitType(t.At(i).Type())
+		}
+	case *types.Signature:
+		e.visitType(t.Params())
+		e.visitType(t.Results())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			e.visitType(t.Field(i).Type())
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			e.visitType(t.EmbeddedType(i))
+		}
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			e.visitType(t.ExplicitMethod(i).Type())
+		}
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			e.visitType(t.Term(i).Type())
+		}
+	case *types.TypeParam:
+		e.visitType(t.Constraint())
+	case *types.Alias:
+		e.visitType(t.Rhs())
+		if targs := t.TypeArgs(); targs != nil {
+			for i := 0; i < targs.Len(); i++ {
+				e.visitType(targs.At(i))
+			}
+		}
+	case *types.Named:
+		var instance = t.TypeArgs().Len() > 0
+		if instance {
+			for i := 0; i < t.TypeArgs().Len(); i++ {
+				e.visitType(t.TypeArgs().At(i))
+			}
+			e.visitType(t.Origin())
+		} else if t.Obj().Pkg() == e.pkg || t.Obj().Pkg() == nil {
+			return
+		}
+
+		// The members of an instance might be the ones of its origin type,
+		// if the type arguments are the type parameters of the origin type.
+		var origin = t.Origin()
+		for i := 0; i < t.NumMethods(); i++ {
+			m := t.Method(i)
+			if instance && m == origin.Method(i) {
+				continue
+			}
+			e.registerMember(m, t, memberMethod, i)
+			if instance && m.Type() != origin.Method(i).Type() {
+				e.typeOwners[m.Type()] = m
+			}
+		}
+		var underlying = t.Underlying()
+		if instance && underlying == origin.Underlying() {
+			return
+		}
+		var originUnderlying = origin.Underlying()
+		switch u := underlying.(type) {
+		case *types.Struct:
+			e.underlyingOwners[u] = t
+			for i := 0; i < u.NumFields(); i++ {
+				f := u.Field(i)
+				if instance && f == originUnderlying.(*types.Struct).Field(i) {
+					continue
+				}
+				e.registerMember(f, t, memberField, i)
+				if f.Embedded() || instance {
+					e.visitType(f.Type())
+				}
+			}
+		case *types.Interface:
+			e.underlyingOwners[u] = t
+			for i := 0; i < u.NumExplicitMethods(); i++ {
+				m := u.ExplicitMethod(i)
+				if instance && m == originUnderlying.(*types.Interface).ExplicitMethod(i) {
+					continue
+				}
+				e.registerMember(m, t, memberInterfaceMethod, i)
+				if instance && m.Type() != originUnderlying.(*types.Interface).ExplicitMethod(i).Type() {
+					e.typeOwners[m.Type()] = m
+				}
+			}
+			for i := 0; i < u.NumEmbeddeds(); i++ {
+				e.visitType(u.EmbeddedType(i))
+			}
+		default:
+			if instance {
+				e.visitType(underlying)
+			}
+		}
+	}
+}
+
+func (e *typesCacheEncoder) registerMember(obj types.Object, owner types.Type, kind, index int) {
+	if _, ok := e.members[obj]; !ok {
+		e.members[obj] = objectMember{owner, kind, index}
+	}
+}
+
+func (e *typesCacheEncoder) newRecord(kind int) {
+	e.rec.uint(uint64(kind))
+}
+
+func (e *typesCacheEncoder) endRecord() uint64 {
+	e.numRecords++
+	return e.numRecords
+}
+
+func (e *typesCacheEncoder) enter(x any) {
+	if e.busy[x] {
+		e.abort("cyclic reference to %v", x)
+	}
+	e.busy[x] = true
+}
+
+func (e *typesCacheEncoder) leave(x any) {
+	delete(e.busy, x)
+}
+
+func (e *typesCacheEncoder) pos(pos token.Pos) {
+	if !pos.IsValid() {
+		e.rec.string("")
+		return
+	}
+	f := e.fset.File(pos)
+	if f == nil {
+		e.abort("file of position not found")
+	}
+	e.rec.string(f.Name())
+	e.rec.uint(uint64(f.Offset(pos)))
+}
+
+func (e *typesCacheEncoder) objRef(obj types.Object) uint64 {
+	if obj == nil {
+		return 0
+	}
+	if ref, ok := e.objRefs[obj]; ok {
+		return ref
+	}
+	e.enter(obj)
+	ref := e.encodeObject(obj)
+	e.leave(obj)
+	e.objRefs[obj] = ref
+
+	// Make sure the types of type names are created.
+	if tn, ok := obj.(*types.TypeName); ok && tn.Pkg() == e.pkg && !e.busy[tn.Type()] {
+		e.typeRef(tn.Type())
+	}
+	// The receivers of the methods of unnamed interfaces are set
+	// when the interfaces are created.
+	if f, ok := obj.(*types.Func); ok && f.Pkg() == e.pkg {
+		if recv := f.Type().(*types.Signature).Recv(); recv != nil {
+			if iface, ok := recv.Type().(*types.Interface); ok && !e.busy[types.Type(iface)] {
+				e.typeRef(iface)
+			}
+		}
+	}
+	return ref
+}
+
+func (e *typesCacheEncoder) encodeObject(obj types.Object) uint64 {
+	if obj.Parent() == types.Universe {
+		e.newRecord(recUniverseObject)
+		e.rec.string(obj.Name())
+		return e.endRecord()
+	}
+
+	var pkg = obj.Pkg()
+	if pkg == nil {
+		if obj == types.Universe.Lookup("error").Type().Underlying().(*types.Interface).ExplicitMethod(0) {
+			e.newRecord(recErrorMethod)
+			return e.endRecord()
+		}
+		if _, ok := obj.(*types.Var); !ok {
+			e.abort("unknown object without package: %v", obj)
+		}
+		// Parameters of the signatures of builtin function calls are copied.
+	}
+
+	if pkg != nil && pkg != e.pkg {
+		if obj.Parent() == pkg.Scope() {
+			e.newRecord(recScopeObject)
+			e.rec.string(pkg.Path())
+			e.rec.string(obj.Name())
+			return e.endRecord()
+		}
+	}
+
+	if m, ok := e.members[obj]; ok {
+		named := m.owner.(*types.Named)
+		if named.TypeArgs().Len() > 0 {
+			e.ensureFilled(named.Origin())
+		}
+		owner := e.typeRef(m.owner)
+		e.newRecord(recMemberObject)
+		e.rec.uint(owner)
+		e.rec.uint(uint64(m.kind))
+		e.rec.uint(uint64(m.index))
+		return e.endRecord()
+	}
+
+	if v, ok := obj.(*types.Var); ok {
+		// Parameters of foreign and instantiated signatures are copied.
+		if v.IsField() && (v.Origin() != v || pkg != e.pkg) {
+			if pkg == nil || v.Origin() != v {
+				e.abort("unsupported field: %v", obj)
+			}
+			e.objectPath(obj)
+			return e.endRecord()
+		}
+	} else if pkg != e.pkg {
+		e.objectPath(obj)
+		return e.endRecord()
+	} else if f, ok := obj.(*types.Func); ok && f.Origin() != f {
+		// Methods of instantiated unnamed interfaces are copied.
+		if recv := f.Type().(*types.Signature).Recv(); recv == nil || !isInterfaceLiteral(recv.Type()) {
+			e.abort("unsupported instantiated function: %v", obj)
+		}
+	}
+
+	switch obj := obj.(type) {
+	case *types.TypeName:
+		var kind, typ uint64
+		switch t := obj.Type().(type) {
+		case *types.Named:
+			if t.Obj() != obj {
+				kind, typ = 3, e.typeRef(t)
+			}
+		case *types.TypeParam:
+			if t.Obj() != obj {
+				kind, typ = 3, e.typeRef(t)
+			} else {
+				kind = 1
+			}
+		case *types.Alias:
+			if t.Obj() != obj {
+				kind, typ = 3, e.typeRef(t)
+			} else {
+				kind = 2
+			}
+		default:
+			kind, typ = 3, e.typeRef(t)
+		}
+		e.newRecord(recTypeName)
+		e.objectHead(obj)
+		e.rec.uint(kind)
+		if kind == 3 {
+			e.rec.uint(typ)
+		}
+	case *types.Var:
+		typ := e.typeRef(obj.Type())
+		e.newRecord(recVar)
+		e.objectHead(obj)
+		var flags uint64
+		if obj.IsField() {
+			flags |= 1
+		}
+		if obj.Embedded() {
+			flags |= 2
+		}
+		e.rec.uint(flags)
+		e.rec.uint(typ)
+	case *types.Func:
+		sig := e.typeRef(obj.Type())
+		e.newRecord(recFunc)
+		e.objectHead(obj)
+		e.rec.uint(sig)
+	case *types.Const:
+		typ := e.typeRef(obj.Type())
+		e.newRecord(recConst)
+		e.objectHead(obj)
+		e.rec.uint(typ)
+		e.constant(&e.rec, obj.Val())
+	case *types.PkgName:
+		e.newRecord(recPkgName)
+		e.objectHead(obj)
+		e.rec.string(obj.Imported().Path())
+	case *types.Label:
+		e.newRecord(recLabel)
+		e.objectHead(obj)
+	default:
+		e.abort("unsupported object: %v", obj)
+	}
+	return e.endRecord()
+}
+
+func isInterfaceLiteral(t types.Type) bool {
+	_, ok := t.(*types.Interface)
+	return ok
+}
+
+func (e *typesCacheEncoder) objectPath(obj types.Object) {
+	path, err := e.objectPaths.For(obj)
+	if err != nil {
+		e.abort("%s", err)
+	}
+	e.newRecord(recObjectPath)
+	e.rec.string(obj.Pkg().Path())
+	e.rec.string(string(path))
+}
+
+// objectHead encodes the package, name, position and parent of an object.
+func (e *typesCacheEncoder) objectHead(obj types.Object) {
+	switch pkg := obj.Pkg(); pkg {
+	case e.pkg:
+		e.rec.uint(0)
+	case nil:
+		e.rec.uint(1)
+	default:
+		e.rec.uint(2)
+		e.rec.string(pkg.Path())
+	}
+	e.rec.string(obj.Name())
+	e.pos(obj.Pos())
+
+	var parent = obj.Parent()
+	switch {
+	case parent == nil:
+		e.rec.uint(parentNone)
+	case obj.Pkg() != e.pkg:
+		e.rec.uint(parentLocal)
+		e.rec.uint(0)
+	case parent == e.pkg.Scope():
+		if parent.Lookup(obj.Name()) == obj {
+			e.rec.uint(parentPackageScope)
+		} else {
+			e.rec.uint(parentInit)
+		}
+	default:
+		if _, ok := obj.(*types.Label); ok {
+			e.rec.uint(parentLabel)
+			return
+		}
+		if i, ok := e.fileScopes[parent]; ok {
+			e.rec.uint(parentFileScope)
+			e.rec.uint(uint64(i))
+			return
+		}
+		for s := parent.Parent(); s != nil; s = s.Parent() {
+			if i, ok := e.fileScopes[s]; ok {
+				e.rec.uint(parentLocal)
+				e.rec.uint(uint64(i) + 1)
+				return
+			}
+		}
+		e.abort("unknown parent scope of %v", obj)
+	}
+}
+
+func (e *typesCacheEncoder) typeRef(t types.Type) uint64 {
+	if t == nil {
+		return 0
+	}
+	if ref, ok := e.typeRefs[t]; ok {
+		return ref
+	}
+	e.enter(t)
+	ref := e.encodeType(t)
+	e.leave(t)
+	e.typeRefs[t] = ref
+	return ref
+}
+
+func (e *typesCacheEncoder) refs(refs []uint64) {
+	e.rec.uint(uint64(len(refs)))
+	for _, ref := range refs {
+		e.rec.uint(ref)
+	}
+}
+
+func (e *typesCacheEncoder) tparamRefs(list *types.TypeParamList) []uint64 {
+	var refs = make([]uint64, list.Len())
+	for i := range refs {
+		tp := list.At(i)
+		if e.boundTParam[tp] {
+			e.abort("type parameter %v is bound more than once", tp)
+		}
+		e.boundTParam[tp] = true
+		refs[i] = e.typeRef(tp)
+	}
+	return refs
+}
+
+func (e *typesCacheEncoder) encodeType(t types.Type) uint64 {
+	if owner, ok := e.typeOwners[t]; ok {
+		obj := e.objRef(owner)
+		e.newRecord(recTypeOf)
+		e.rec.uint(obj)
+		return e.endRecord()
+	}
+	if named, ok := e.underlyingOwners[t]; ok {
+		if n := named.(*types.Named); n.TypeArgs().Len() > 0 {
+			e.ensureFilled(n.Origin())
+		}
+		ref := e.typeRef(named)
+		e.newRecord(recUnderlyingOf)
+		e.rec.uint(ref)
+		return e.endRecord()
+	}
+
+	switch t := t.(type) {
+	case *types.Basic:
+		e.newRecord(recBasic)
+		e.rec.uint(uint64(t.Kind()))
+		e.rec.string(t.Name())
+	case *types.Pointer:
+		elem := e.typeRef(t.Elem())
+		e.newRecord(recPointer)
+		e.rec.uint(elem)
+	case *types.Slice:
+		elem := e.typeRef(t.Elem())
+		e.newRecord(recSlice)
+		e.rec.uint(elem)
+	case *types.Array:
+		elem := e.typeRef(t.Elem())
+		e.newRecord(recArray)
+		e.rec.uint(elem)
+		e.rec.int(t.Len())
+	case *types.Map:
+		key, elem := e.typeRef(t.Key()), e.typeRef(t.Elem())
+		e.newRecord(recMap)
+		e.rec.uint(key)
+		e.rec.uint(elem)
+	case *types.Chan:
+		elem := e.typeRef(t.Elem())
+		e.newRecord(recChan)
+		e.rec.uint(uint64(t.Dir()))
+		e.rec.uint(elem)
+	case *types.Tuple:
+		var vars []uint64
+		if t != nil {
+			vars = make([]uint64, t.Len())
+			for i := range vars {
+				vars[i] = e.objRef(t.At(i))
+			}
+		}
+		e.newRecord(recTuple)
+		e.rec.bool(t == nil)
+		e.refs(vars)
+	case *types.Signature:
+		// types.NewSignatureType needs the core types of variadic
+		// parameters, which might be unknown when decoding.
+		if t.Variadic() {
+			switch t.Params().At(t.Params().Len() - 1).Type().(type) {
+			case *types.Slice, *types.Basic:
+			default:
+				e.abort("unsupported variadic parameter type")
+			}
+		}
+		var recv uint64
+		if r := t.Recv(); r != nil {
+			if _, ok := r.Type().(*types.Interface); !ok {
+				recv = e.objRef(r)
+			}
+		}
+		rtps := e.tparamRefs(t.RecvTypeParams())
+		tps := e.tparamRefs(t.TypeParams())
+		params, results := e.typeRef(t.Params()), e.typeRef(t.Results())
+		e.newRecord(recSignature)
+		e.rec.uint(recv)
+		e.refs(rtps)
+		e.refs(tps)
+		e.rec.uint(params)
+		e.rec.uint(results)
+		e.rec.bool(t.Variadic())
+	case *types.Struct:
+		var fields = make([]uint64, t.NumFields())
+		for i := range fields {
+			fields[i] = e.objRef(t.Field(i))
+		}
+		e.newRecord(recStruct)
+		e.refs(fields)
+		for i := range fields {
+			e.rec.string(t.Tag(i))
+		}
+	case *types.Interface:
+		if t.IsImplicit() && t.NumExplicitMethods() == 0 && t.NumEmbeddeds() == 0 {
+			e.abort("empty implicit interface")
+		}
+		var methods = make([]uint64, t.NumExplicitMethods())
+		for i := range methods {
+			methods[i] = e.objRef(t.ExplicitMethod(i))
+		}
+		var embeddeds = make([]uint64, t.NumEmbeddeds())
+		for i := range embeddeds {
+			embeddeds[i] = e.typeRef(t.EmbeddedType(i))
+		}
+		e.newRecord(recInterface)
+		e.refs(methods)
+		e.refs(embeddeds)
+		e.rec.bool(t.IsImplicit())
+		e.interfaces = append(e.interfaces, t)
+	case *types.Union:
+		var terms = make([]uint64, t.Len())
+		for i := range terms {
+			terms[i] = e.typeRef(t.Term(i).Type())
+		}
+		e.newRecord(recUnion)
+		e.refs(terms)
+		for i := range terms {
+			e.rec.bool(t.Term(i).Tilde())
+		}
+	case *types.TypeParam:
+		if t.Obj().Pkg() != e.pkg {
+			e.abort("foreign type parameter %v", t)
+		}
+		obj := e.objRef(t.Obj())
+		e.newRecord(recTypeParam)
+		e.rec.uint(obj)
+		e.tparams = append(e.tparams, t)
+	case *types.Alias:
+		if t.TypeArgs().Len() > 0 {
+			return e.encodeInstance(t.Origin(), t.TypeArgs())
+		}
+		if t.Obj().Pkg() != e.pkg {
+			obj := e.objRef(t.Obj())
+			e.newRecord(recTypeOf)
+			e.rec.uint(obj)
+			break
+		}
+		obj := e.objRef(t.Obj())
+		tps := e.tparamRefs(t.TypeParams())
+		rhs := e.typeRef(t.Rhs())
+		e.newRecord(recAlias)
+		e.rec.uint(obj)
+		e.refs(tps)
+		e.rec.uint(rhs)
+	case *types.Named:
+		if t.TypeArgs().Len() > 0 {
+			return e.encodeInstance(t.Origin(), t.TypeArgs())
+		}
+		if pkg := t.Obj().Pkg(); pkg != e.pkg {
+			obj := e.objRef(t.Obj())
+			e.newRecord(recTypeOf)
+			e.rec.uint(obj)
+			break
+		}
+		obj := e.objRef(t.Obj())
+		tps := e.tparamRefs(t.TypeParams())
+		e.newRecord(recNamed)
+		e.rec.uint(obj)
+		e.refs(tps)
+		e.pendings = append(e.pendings, t)
+	default:
+		e.abort("unsupported type: %T", t)
+	}
+	return e.endRecord()
+}
+
+func (e *typesCacheEncoder) encodeInstance(origin types.Type, targs *types.TypeList) uint64 {
+	orig := e.typeRef(origin)
+	var args = make([]uint64, targs.Len())
+	for i := range args {
+		args[i] = e.typeRef(targs.At(i))
+	}
+	e.newRecord(recInstance)
+	e.rec.uint(orig)
+	e.refs(args)
+	return e.endRecord()
+}
+
+// ensureFilled makes sure that the fill record of an owned named type
+// is encoded before the records which need its underlying type or methods.
+func (e *typesCacheEncoder) ensureFilled(named *types.Named) {
+	if named.Obj().Pkg() != e.pkg {
+		return
+	}
+	e.typeRef(named)
+	switch e.fillStates[named] {
+	case 1:
+		e.abort("named type %v is being filled", named)
+	case 2:
+		return
+	}
+	e.fill(named)
+}
+
+func (e *typesCacheEncoder) drainPendings() {
+	for len(e.pendings) > 0 {
+		t := e.pendings[0]
+		e.pendings = e.pendings[1:]
+		e.fill(t)
+	}
+}
+
+// setConstraints encodes the constraints of type parameters. It is called
+// at the end, for types.TypeParam.SetConstraint might expand instantiated
+// constraints, so the fill records of their origin types must be encoded
+// before.
+func (e *typesCacheEncoder) setConstraints() {
+	e.drainPendings()
+	for len(e.tparams) > 0 {
+		var tparams = e.tparams
+		e.tparams = nil
+		var constraints = make([]uint64, len(tparams))
+		for i, tp := range tparams {
+			constraints[i] = e.typeRef(tp.Constraint())
+		}
+		e.drainPendings()
+		for i, tp := range tparams {
+			e.newRecord(recFillTypeParam)
+			e.rec.uint(e.typeRefs[tp])
+			e.rec.uint(constraints[i])
+			e.endRecord()
+		}
+	}
+}
+
+func (e *typesCacheEncoder) fill(named *types.Named) {
+	if e.fillStates[named] != 0 {
+		return
+	}
+	e.fillStates[named] = 1
+	underlying := e.typeRef(named.Underlying())
+	var methods = make([]uint64, named.NumMethods())
+	for i := range methods {
+		methods[i] = e.objRef(named.Method(i))
+	}
+	e.newRecord(recFillNamed)
+	e.rec.uint(e.typeRefs[named])
+	e.rec.uint(underlying)
+	e.refs(methods)
+	e.endRecord()
+	e.fillStates[named] = 2
+}
+
+func (e *typesCacheEncoder) constant(w *cacheWriter, v constant.Value) {
+	switch v.Kind() {
+	default:
+		w.uint(0)
+	case constant.Bool:
+		w.uint(1)
+		w.bool(constant.BoolVal(v))
+	case constant.String:
+		w.uint(2)
+		w.bytes([]byte(constant.StringVal(v)))
+	case constant.Int:
+		w.uint(3)
+		switch x := constant.Val(v).(type) {
+		case int64:
+			w.uint(0)
+			w.int(x)
+		case *big.Int:
+			bs, err := x.GobEncode()
+			if err != nil {
+				e.abort("%s", err)
+			}
+			w.uint(1)
+			w.bytes(bs)
+		default:
+			e.abort("unknown int constant %v", v)
+		}
+	case constant.Float:
+		w.uint(4)
+		var bs []byte
+		var err error
+		switch x := constant.Val(v).(type) {
+		case *big.Rat:
+			w.uint(0)
+			bs, err = x.GobEncode()
+		case *big.Float:
+			w.uint(1)
+			bs, err = x.GobEncode()
+		default:
+			e.abort("unknown float constant %v", v)
+		}
+		if err != nil {
+			e.abort("%s", err)
+		}
+		w.bytes(bs)
+	case constant.Complex:
+		w.uint(5)
+		e.constant(w, constant.Real(v))
+		e.constant(w, constant.Imag(v))
+	}
+}
+
+func (e *typesCacheEncoder) encodeFileInfo(w *cacheWriter, file *ast.File) {
+	var templates = typeAndValueTemplates()
+	var entries, order, last int
+	var body cacheWriter
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		defer func() { order++ }()
+
+		var flags uint64
+		var def, use, implicit types.Object
+		var tv types.TypeAndValue
+		if id, ok := n.(*ast.Ident); ok {
+			if obj, ok := e.info.Defs[id]; ok {
+				flags |= infoDef
+				def = obj
+			}
+			if obj, ok := e.info.Uses[id]; ok {
+				flags |= infoUse
+				use = obj
+			}
+		}
+		if obj, ok := e.info.Implicits[n]; ok {
+			flags |= infoImplicit
+			implicit = obj
+		}
+		if expr, ok := n.(ast.Expr); ok {
+			if tv, ok = e.info.Types[expr]; ok {
+				flags |= infoType
+			}
+		}
+		if flags == 0 {
+			return true
+		}
+
+		// Object and type records must be encoded before the entry.
+		var defRef, useRef, implicitRef, typeRef uint64
+		if flags&infoDef != 0 {
+			defRef = e.objRef(def)
+		}
+		if flags&infoUse != 0 {
+			useRef = e.objRef(use)
+		}
+		if flags&infoImplicit != 0 {
+			implicitRef = e.objRef(implicit)
+		}
+		var modeKey uint64
+		if flags&infoType != 0 {
+			modeKey = typeAndValueModeKey(tv)
+			if _, ok := templates[modeKey]; !ok {
+				e.abort("unknown TypeAndValue mode %d", modeKey)
+			}
+			typeRef = e.typeRef(tv.Type)
+		}
+		e.drainPendings()
+
+		entries++
+		body.uint(uint64(order - last))
+		last = order
+		body.uint(flags)
+		if flags&infoDef != 0 {
+			body.uint(defRef)
+		}
+		if flags&infoUse != 0 {
+			body.uint(useRef)
+		}
+		if flags&infoImplicit != 0 {
+			body.uint(implicitRef)
+		}
+		if flags&infoType != 0 {
+			body.uint(modeKey)
+			body.uint(typeRef)
+			if tv.Value != nil {
+				e.constant(&body, tv.Value)
+			}
+		}
+		return true
+	})
+	w.uint(uint64(entries))
+	w.bytes(body.buf)
+}
+
+//=== decoder
+
+type typesCacheDecoder struct {
+	ld   *cachedPackagesLoader
+	ppkg *packages.Package
+	deps map[string]*types.Package
+
+	pkg  *types.Package
+	info *types.Info
+	ctxt *types.Context
+
+	rec     cacheReader
+	records []any // types.Object or types.Type
+
+	fileScopes  []*types.Scope
+	localScopes map[*types.Scope][]*types.Scope // by local scope roots
+	foreignRoot *types.Scope
+	labelRoot   *types.Scope
+}
+
+func (d *typesCacheDecoder) decode(data []byte, key string) (tpkg *types.Package, info *types.Info, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tpkg, info, err = nil, nil, fmt.Errorf("%v", r)
+		}
+	}()
+
+	if !bytes.HasPrefix(data, []byte(typesCacheMagic)) {
+		return nil, nil, errBadTypesCache
+	}
+	var r = &cacheReader{data: data[len(typesCacheMagic):]}
+	if r.uint() != typesCacheFormatVersion || r.string() != key {
+		return nil, nil, errBadTypesCache
+	}
+	if r.uint() != uint64(len(d.ppkg.Syntax)) {
+		return nil, nil, errBadTypesCache
+	}
+	var numRecords = r.uint()
+	d.rec.data = r.bytes()
+	var pkgData = &cacheReader{data: r.bytes()}
+	var infoData = &cacheReader{data: r.bytes()}
+
+	d.pkg = types.NewPackage(d.ppkg.PkgPath, d.ppkg.Name)
+	d.info = newTypesInfo()
+	d.ctxt = types.NewContext()
+	d.fileScopes = make([]*types.Scope, len(d.ppkg.Syntax))
+	for i, f := range d.ppkg.Syntax {
+		d.fileScopes[i] = types.NewScope(d.pkg.Scope(), f.Pos(), f.End(), "file")
+	}
+	d.localScopes = make(map[*types.Scope][]*types.Scope)
+	d.foreignRoot = types.NewScope(types.Universe, token.NoPos, token.NoPos, "")
+
+	d.records = make([]any, 0, numRecords)
+	for i := uint64(0); i < numRecords; i++ {
+		d.records = append(d.records, d.decodeRecord())
+	}
+	if len(d.rec.data) != 0 {
+		return nil, nil, errBadTypesCache
+	}
+
+	if pkgData.string() != d.pkg.Name() {
+		return nil, nil, errBadTypesCache
+	}
+	var imports = make([]*types.Package, pkgData.uint())
+	for i := range imports {
+		imports[i] = d.depPackage(pkgData.string())
+	}
+	d.pkg.SetImports(imports)
+	for n := pkgData.uint(); n > 0; n-- {
+		d.typeAt(pkgData.uint()).(*types.Interface).Complete()
+	}
+
+	for _, f := range d.ppkg.Syntax {
+		d.decodeFileInfo(infoData, f)
+	}
+	if len(infoData.data) != 0 {
+		return nil, nil, errBadTypesCache
+	}
+
+	d.pkg.MarkComplete()
+	return d.pkg, d.info, nil
+}
+
+func (d *typesCacheDecoder) depPackage(path string) *types.Package {
+	if path == "unsafe" {
+		return types.Unsafe
+	}
+	if pkg := d.deps[path]; pkg != nil {
+		return pkg
+	}
+	panic(fmt.Errorf("package %s not found", path))
+}
+
+func (d *typesCacheDecoder) at(ref uint64) any {
+	if ref == 0 {
+		return nil
+	}
+	if ref > uint64(len(d.records)) {
+		panic(errBadTypesCache)
+	}
+	return d.records[ref-1]
+}
+
+func (d *typesCacheDecoder) objAt(ref uint64) types.Object {
+	if x := d.at(ref); x != nil {
+		return x.(types.Object)
+	}
+	return nil
+}
+
+func (d *typesCacheDecoder) typeAt(ref uint64) types.Type {
+	if x := d.at(ref); x != nil {
+		return x.(types.Type)
+	}
+	return nil
+}
+
+func (d *typesCacheDecoder) obj() types.Object { return d.objAt(d.rec.uint()) }
+func (d *typesCacheDecoder) typ() types.Type   { return d.typeAt(d.rec.uint()) }
+
+func (d *typesCacheDecoder) types() []types.Type {
+	var ts = make([]types.Type, d.rec.uint())
+	for i := range ts {
+		ts[i] = d.typ()
+	}
+	return ts
+}
+
+func (d *typesCacheDecoder) tparams() []*types.TypeParam {
+	var tps = make([]*types.TypeParam, d.rec.uint())
+	for i := range tps {
+		tps[i] = d.typ().(*types.TypeParam)
+	}
+	return tps
+}
+
+func (d *typesCacheDecoder) vars() []*types.Var {
+	var vars = make([]*types.Var, d.rec.uint())
+	for i := range vars {
+		vars[i] = d.obj().(*types.Var)
+	}
+	return vars
+}
+
+func (d *typesCacheDecoder) pos(r *cacheReader) token.Pos {
+	filename := r.string()
+	if filename == "" {
+		return token.NoPos
+	}
+	f := d.ld.tokenFile(filename)
+	if f == nil {
+		panic(fmt.Errorf("file %s not found", filename))
+	}
+	return f.Pos(int(r.uint()))
+}
+
+type objectHead struct {
+	pkg    *types.Package
+	name   string
+	pos    token.Pos
+	parent int
+	root   *types.Scope
+}
+
+func (d *typesCacheDecoder) objectHead() objectHead {
+	var h objectHead
+	switch d.rec.uint() {
+	case 0:
+		h.pkg = d.pkg
+	case 1:
+	case 2:
+		h.pkg = d.depPackage(d.rec.string())
+	default:
+		panic(errBadTypesCache)
+	}
+	h.name = d.rec.string()
+	h.pos = d.pos(&d.rec)
+	h.parent = int(d.rec.uint())
+	switch h.parent {
+	case parentFileScope:
+		h.root = d.fileScope(d.rec.uint())
+	case parentLocal:
+		if i := d.rec.uint(); i == 0 {
+			h.root = d.foreignRoot
+		} else {
+			h.root = d.fileScope(i - 1)
+		}
+	}
+	return h
+}
+
+func (d *typesCacheDecoder) fileScope(i uint64) *types.Scope {
+	if i >= uint64(len(d.fileScopes)) {
+		panic(errBadTypesCache)
+	}
+	return d.fileScopes[i]
+}
+
+// declare sets the parent scope of an owned object.
+func (d *typesCacheDecoder) declare(h objectHead, obj types.Object) types.Object {
+	switch h.parent {
+	case parentNone:
+	case parentPackageScope:
+		if d.pkg.Scope().Insert(obj) != nil {
+			panic(errBadTypesCache)
+		}
+	case parentFileScope:
+		if h.root.Insert(obj) != nil {
+			panic(errBadTypesCache)
+		}
+	case parentLocal:
+		d.insertLocal(h.root, obj)
+	case parentLabel:
+		if d.labelRoot == nil {
+			d.labelRoot = types.NewScope(nil, token.NoPos, token.NoPos, "")
+		}
+		d.insertLocal(d.labelRoot, obj)
+	case parentInit:
+		// For init functions, whose parents are package scopes but
+		// which are not inserted into package scopes.
+		types.NewScope(types.Universe, token.NoPos, token.NoPos, "").Insert(obj)
+	default:
+		panic(errBadTypesCache)
+	}
+	return obj
+}
+
+// insertLocal inserts an object into the first scope (derived from root)
+// which doesn't contain an object with the same name.
+func (d *typesCacheDecoder) insertLocal(root *types.Scope, obj types.Object) {
+	var scopes = d.localScopes[root]
+	for _, s := range scopes {
+		if s.Insert(obj) == nil {
+			return
+		}
+	}
+	s := types.NewScope(root, token.NoPos, token.NoPos, "")
+	s.Insert(obj)
+	d.localScopes[root] = append(scopes, s)
+}
+
+func (d *typesCacheDecoder) decodeRecord() any {
+	switch kind := d.rec.uint(); kind {
+	default:
+		panic(errBadTypesCache)
+	case recUniverseObject:
+		if obj := types.Universe.Lookup(d.rec.string()); obj != nil {
+			return obj
+		}
+		panic(errBadTypesCache)
+	case recErrorMethod:
+		return types.Universe.Lookup("error").Type().Underlying().(*types.Interface).ExplicitMethod(0)
+	case recScopeObject:
+		pkg := d.depPackage(d.rec.string())
+		if obj := pkg.Scope().Lookup(d.rec.string()); obj != nil {
+			return obj
+		}
+		panic(errBadTypesCache)
+	case recObjectPath:
+		pkg := d.depPackage(d.rec.string())
+		obj, err := objectpath.Object(pkg, objectpath.Path(d.rec.string()))
+		if err != nil {
+			panic(err)
+		}
+		return obj
+	case recMemberObject:
+		named := d.typ().(*types.Named)
+		kind, i := d.rec.uint(), int(d.rec.uint())
+		switch kind {
+		case memberMethod:
+			return named.Method(i)
+		case memberField:
+			return named.Underlying().(*types.Struct).Field(i)
+		case memberInterfaceMethod:
+			return named.Underlying().(*types.Interface).ExplicitMethod(i)
+		}
+		panic(errBadTypesCache)
+	case recTypeName:
+		h := d.objectHead()
+		var obj *types.TypeName
+		switch d.rec.uint() {
+		case 0, 1, 2: // the type will be set by NewNamed, NewTypeParam or NewAlias
+			obj = types.NewTypeName(h.pos, h.pkg, h.name, nil)
+		case 3:
+			obj = types.NewTypeName(h.pos, h.pkg, h.name, d.typ())
+		default:
+			panic(errBadTypesCache)
+		}
+		return d.declare(h, obj)
+	case recVar:
+		h := d.objectHead()
+		flags, typ := d.rec.uint(), d.typ()
+		if flags&1 != 0 {
+			return d.declare(h, types.NewField(h.pos, h.pkg, h.name, typ, flags&2 != 0))
+		}
+		return d.declare(h, types.NewVar(h.pos, h.pkg, h.name, typ))
+	case recFunc:
+		h := d.objectHead()
+		return d.declare(h, types.NewFunc(h.pos, h.pkg, h.name, d.typ().(*types.Signature)))
+	case recConst:
+		h := d.objectHead()
+		typ := d.typ()
+		return d.declare(h, types.NewConst(h.pos, h.pkg, h.name, typ, d.constant(&d.rec)))
+	case recPkgName:
+		h := d.objectHead()
+		return d.declare(h, types.NewPkgName(h.pos, h.pkg, h.name, d.depPackage(d.rec.string())))
+	case recLabel:
+		h := d.objectHead()
+		return d.declare(h, types.NewLabel(h.pos, h.pkg, h.name))
+
+	case recBasic:
+		kind, name := types.BasicKind(d.rec.uint()), d.rec.string()
+		if kind < 0 || int(kind) >= len(types.Typ) {
+			panic(errBadTypesCache)
+		}
+		if t := types.Typ[kind]; t.Name() == name {
+			return t
+		}
+		if obj := types.Universe.Lookup(name); obj != nil {
+			if t, ok := obj.Type().(*types.Basic); ok && t.Kind() == kind {
+				return t // byte and rune
+			}
+		}
+		panic(errBadTypesCache)
+	case recPointer:
+		return types.NewPointer(d.typ())
+	case recSlice:
+		return types.NewSlice(d.typ())
+	case recArray:
+		elem := d.typ()
+		return types.NewArray(elem, d.rec.int())
+	case recMap:
+		key := d.typ()
+		return types.NewMap(key, d.typ())
+	case recChan:
+		dir := types.ChanDir(d.rec.uint())
+		return types.NewChan(dir, d.typ())
+	case recTuple:
+		isNil, vars := d.rec.bool(), d.vars()
+		if isNil {
+			return (*types.Tuple)(nil)
+		}
+		return types.NewTuple(vars...)
+	case recSignature:
+		var recv *types.Var
+		if obj := d.obj(); obj != nil {
+			recv = obj.(*types.Var)
+		}
+		rtps, tps := d.tparams(), d.tparams()
+		params, _ := d.typ().(*types.Tuple)
+		results, _ := d.typ().(*types.Tuple)
+		return types.NewSignatureType(recv, rtps, tps, params, results, d.rec.bool())
+	case recStruct:
+		fields := d.vars()
+		var tags = make([]string, len(fields))
+		for i := range tags {
+			tags[i] = d.rec.string()
+		}
+		return types.NewStruct(fields, tags)
+	case recInterface:
+		var methods = make([]*types.Func, d.rec.uint())
+		for i := range methods {
+			methods[i] = d.obj().(*types.Func)
+		}
+		embeddeds := d.types()
+		iface := types.NewInterfaceType(methods, embeddeds)
+		if d.rec.bool() {
+			iface.MarkImplicit()
+		}
+		return iface
+	case recUnion:
+		ts := d.types()
+		var terms = make([]*types.Term, len(ts))
+		for i, t := range ts {
+			terms[i] = types.NewTerm(d.rec.bool(), t)
+		}
+		return types.NewUnion(terms)
+	case recTypeParam:
+		return types.NewTypeParam(d.obj().(*types.TypeName), nil)
+	case recNamed:
+		named := types.NewNamed(d.obj().(*types.TypeName), nil, nil)
+		if tps := d.tparams(); len(tps) > 0 {
+			named.SetTypeParams(tps)
+		}
+		return named
+	case recAlias:
+		obj, tps := d.obj().(*types.TypeName), d.tparams()
+		alias := types.NewAlias(obj, d.typ())
+		if len(tps) > 0 {
+			alias.SetTypeParams(tps)
+		}
+		return alias
+	case recInstance:
+		origin, args := d.typ(), d.types()
+		t, err := types.Instantiate(d.ctxt, origin, args, false)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	case recTypeOf:
+		return d.obj().Type()
+	case recUnderlyingOf:
+		return d.typ().Underlying()
+	case recFillTypeParam:
+		tp := d.typ().(*types.TypeParam)
+		tp.SetConstraint(d.typ())
+		return tp
+	case recFillNamed:
+		named := d.typ().(*types.Named)
+		named.SetUnderlying(d.typ())
+		var n = d.rec.uint()
+		for i := uint64(0); i < n; i++ {
+			named.AddMethod(d.obj().(*types.Func))
+		}
+		if uint64(named.NumMethods()) != n {
+			panic(errBadTypesCache)
+		}
+		return named
+	}
+}
+
+func (d *typesCacheDecoder) constant(r *cacheReader) constant.Value {
+	switch r.uint() {
+	case 0:
+		return constant.MakeUnknown()
+	case 1:
+		return constant.MakeBool(r.bool())
+	case 2:
+		return constant.MakeString(string(r.bytes()))
+	case 3:
+		if r.uint() == 0 {
+			return constant.MakeInt64(r.int())
+		}
+		var x big.Int
+		if err := x.GobDecode(r.bytes()); err != nil {
+			panic(err)
+		}
+		return constant.Make(&x)
+	case 4:
+		if r.uint() == 0 {
+			var x big.Rat
+			if err := x.GobDecode(r.bytes()); err != nil {
+				panic(err)
+			}
+			return constant.Make(&x)
+		}
+		var x big.Float
+		if err := x.GobDecode(r.bytes()); err != nil {
+			panic(err)
+		}
+		return constant.Make(&x)
+	case 5:
+		re := d.constant(r)
+		im := d.constant(r)
+		return constant.BinaryOp(re, token.ADD, constant.MakeImag(im))
+	}
+	panic(errBadTypesCache)
+}
+
+func (d *typesCacheDecoder) decodeFileInfo(r *cacheReader, file *ast.File) {
+	var templates = typeAndValueTemplates()
+	var entries = r.uint()
+	var body = &cacheReader{data: r.bytes()}
+	if entries == 0 {
+		return
+	}
+	var next = int(body.uint())
+	var order int
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || entries == 0 {
+			return false
+		}
+		defer func() { order++ }()
+		if order != next {
+			return true
+		}
+
+		var flags = body.uint()
+		if flags&(infoDef|infoUse) != 0 {
+			id, ok := n.(*ast.Ident)
+			if !ok {
+				panic(errBadTypesCache)
+			}
+			if flags&infoDef != 0 {
+				d.info.Defs[id] = d.objAt(body.uint())
+			}
+			if flags&infoUse != 0 {
+				d.info.Uses[id] = d.objAt(body.uint())
+			}
+		}
+		if flags&infoImplicit != 0 {
+			d.info.Implicits[n] = d.objAt(body.uint())
+		}
+		if flags&infoType != 0 {
+			expr, ok := n.(ast.Expr)
+			if !ok {
+				panic(errBadTypesCache)
+			}
+			var modeKey = body.uint()
+			tv, ok := templates[modeKey]
+			if !ok {
+				panic(errBadTypesCache)
+			}
+			tv.Type = d.typeAt(body.uint())
+			tv.Value = nil
+			if modeKey&(1<<8) != 0 {
+				tv.Value = d.constant(body)
+			}
+			if typeAndValueModeKey(tv) != modeKey {
+				panic(errBadTypesCache)
+			}
+			d.info.Types[expr] = tv
+		}
+
+		if entries--; entries > 0 {
+			next += int(body.uint())
+		}
+		return true
+	})
+	if entries != 0 || len(body.data) != 0 {
+		panic(errBadTypesCache)
+	}
+}
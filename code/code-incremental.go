@@ -0,0 +1,290 @@
+package code
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ChangedPackages holds the re-parsed results of some changed packages
+// and the packages (directly or indirectly) depending on them.
+type ChangedPackages struct {
+	pkgs  []*Package          // sorted by dependency heights
+	ppkgs []*packages.Package // the new parse results, one for each in pkgs
+	docs  []string            // the new one-line docs, one for each in pkgs
+}
+
+// NumPackages returns the number of the packages needing to be re-analyzed.
+func (cp *ChangedPackages) NumPackages() int {
+	return len(cp.pkgs)
+}
+
+// ReparseChangedPackages re-parses the specified changed packages and their
+// reverse dependencies. The parse results (including the type-checking results)
+// of the other packages are used as the dependencies of the re-parsed ones,
+// so that the unchanged packages don't need to be re-parsed.
+//
+// An error is returned if the changed packages are unable to be re-parsed alone,
+// for example, some imports are changed, or some packages are added or removed.
+// In such cases, all packages need to be re-parsed.
+//
+// The analysis result is not modified by this method, so it is safe to
+// use the analysis result concurrently when this method is running.
+func (d *CodeAnalyzer) ReparseChangedPackages(changed []*Package) (*ChangedPackages, error) {
+	var affected = make(map[*Package]bool, len(changed)*4)
+	var collect func(pkg *Package)
+	collect = func(pkg *Package) {
+		if affected[pkg] {
+			return
+		}
+		affected[pkg] = true
+		for _, dep := range pkg.DepedBys {
+			collect(dep)
+		}
+	}
+	for _, pkg := range changed {
+		collect(pkg)
+	}
+
+	var cp = &ChangedPackages{pkgs: make([]*Package, 0, len(affected))}
+	var paths = make([]string, 0, len(affected))
+	for pkg := range affected {
+		if pkg.IsFake() || d.IsStandardPackage(pkg) {
+			return nil, fmt.Errorf("package %s can't be re-parsed alone", pkg.Path)
+		}
+		cp.pkgs = append(cp.pkgs, pkg)
+		paths = append(paths, pkg.Path)
+	}
+	sort.Slice(cp.pkgs, func(i, j int) bool {
+		return cp.pkgs[i].Index < cp.pkgs[j].Index
+	})
+
+	// Only list the files and imports here.
+	var config = &packages.Config{
+		Mode: packages.NeedName | packages.NeedImports |
+			packages.NeedFiles | packages.NeedCompiledGoFiles,
+		Env: d.packagesConfigEnv(),
+	}
+	ppkgs, err := packages.Load(config, paths...)
+	if err != nil {
+		return nil, fmt.Errorf("packages.Load (list changed packages): %w", err)
+	}
+	var listedPPkgs = make(map[string]*packages.Package, len(ppkgs))
+	for _, ppkg := range ppkgs {
+		if len(ppkg.Errors) > 0 {
+			return nil, &LoadError{Errs: []error{ppkg.Errors[0]}}
+		}
+		listedPPkgs[ppkg.PkgPath] = ppkg
+	}
+
+	var pkgsByID = make(map[string]*Package, len(d.packageList))
+	for _, pkg := range d.packageList {
+		if pkg.PPkg != nil {
+			pkgsByID[pkg.PPkg.ID] = pkg
+		}
+	}
+	var newPPkgs = make(map[*Package]*packages.Package, len(cp.pkgs))
+	var ppkgOf = func(pkg *Package) *packages.Package {
+		if ppkg := newPPkgs[pkg]; ppkg != nil {
+			return ppkg
+		}
+		return pkg.PPkg
+	}
+
+	for _, pkg := range cp.pkgs {
+		listed := listedPPkgs[pkg.Path]
+		if listed == nil {
+			return nil, fmt.Errorf("package %s is not found", pkg.Path)
+		}
+		if listed.Name != pkg.PPkg.Name {
+			return nil, fmt.Errorf("the name of package %s is changed", pkg.Path)
+		}
+		if len(listed.GoFiles) != len(listed.CompiledGoFiles) {
+			return nil, fmt.Errorf("package %s uses cgo", pkg.Path)
+		}
+		if len(listed.Imports) != len(pkg.PPkg.Imports) {
+			return nil, fmt.Errorf("the imports of package %s are changed", pkg.Path)
+		}
+		var imports = make(map[string]*packages.Package, len(listed.Imports))
+		for path, imp := range listed.Imports {
+			dep := pkgsByID[imp.ID]
+			if old := pkg.PPkg.Imports[path]; dep == nil || old == nil || old.ID != imp.ID {
+				return nil, fmt.Errorf("the imports of package %s are changed", pkg.Path)
+			}
+			imports[path] = ppkgOf(dep)
+		}
+
+		var fset = pkg.PPkg.Fset
+		var files = make([]*ast.File, 0, len(listed.CompiledGoFiles))
+		var oneLineDoc string
+		for _, filename := range listed.CompiledGoFiles {
+			const mode = parser.AllErrors | parser.ParseComments
+			file, err := parser.ParseFile(fset, filename, nil, mode)
+			if err != nil {
+				return nil, &LoadError{Errs: []error{err}}
+			}
+			if oneLineDoc == "" && file.Doc != nil {
+				oneLineDoc = doc.Synopsis(file.Doc.Text())
+			}
+			files = append(files, file)
+		}
+
+		var typeErrs []error
+		var info = &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Implicits:  make(map[ast.Node]types.Object),
+			Scopes:     make(map[ast.Node]*types.Scope),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		}
+		initTypesInfoInstances(info)
+		var config = &types.Config{
+			Importer: importerFunc(func(path string) (*types.Package, error) {
+				if path == "unsafe" {
+					return types.Unsafe, nil
+				}
+				if imp := imports[path]; imp != nil && imp.Types != nil {
+					return imp.Types, nil
+				}
+				return nil, fmt.Errorf("no metadata for %s", path)
+			}),
+			Error: func(err error) {
+				typeErrs = append(typeErrs, err)
+			},
+			Sizes: pkg.PPkg.TypesSizes,
+		}
+		var tpkg = types.NewPackage(pkg.Path, listed.Name)
+		types.NewChecker(config, fset, tpkg, info).Files(files)
+		if len(typeErrs) > 0 {
+			return nil, &LoadError{Errs: typeErrs}
+		}
+
+		var ppkg = *pkg.PPkg
+		ppkg.GoFiles = listed.GoFiles
+		ppkg.CompiledGoFiles = listed.CompiledGoFiles
+		ppkg.OtherFiles = listed.OtherFiles
+		ppkg.IgnoredFiles = listed.IgnoredFiles
+		ppkg.Imports = imports
+		ppkg.Syntax = files
+		ppkg.Types = tpkg
+		ppkg.TypesInfo = info
+		ppkg.Errors = nil
+		ppkg.IllTyped = false
+		newPPkgs[pkg] = &ppkg
+
+		cp.ppkgs = append(cp.ppkgs, &ppkg)
+		cp.docs = append(cp.docs, oneLineDoc)
+	}
+
+	return cp, nil
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// NewAnalyzerWithChangedPackages creates a new analyzer for the packages
+// re-parsed by ReparseChangedPackages. The new analyzer shares the module
+// info and the parse results (including the type-checking results) of the
+// unchanged packages with d, and uses the re-parsed results for the changed
+// ones. Its AnalyzePackages method needs to be called before using it.
+//
+// d is not modified by this method or by analyzing the new analyzer,
+// so it is safe to keep using d when the new analyzer is being analyzed.
+func (d *CodeAnalyzer) NewAnalyzerWithChangedPackages(cp *ChangedPackages) *CodeAnalyzer {
+	var nd = &CodeAnalyzer{
+		cacheDir: d.cacheDir,
+		debug:    d.debug,
+	}
+
+	// Copy modules.
+	var modules = make(map[*Module]*Module, len(d.modulesByPath)+1)
+	nd.nonToolchainModules = make([]Module, len(d.nonToolchainModules))
+	for i := range d.nonToolchainModules {
+		nd.nonToolchainModules[i] = d.nonToolchainModules[i]
+		modules[&d.nonToolchainModules[i]] = &nd.nonToolchainModules[i]
+	}
+	var moduleOf = func(m *Module) *Module {
+		if m == nil {
+			return nil
+		}
+		nm := modules[m]
+		if nm == nil {
+			nm = new(Module)
+			*nm = *m
+			modules[m] = nm
+		}
+		return nm
+	}
+	nd.stdModule = moduleOf(d.stdModule)
+	nd.wdModule = moduleOf(d.wdModule)
+	nd.wdModules = make([]*Module, len(d.wdModules))
+	for i, m := range d.wdModules {
+		nd.wdModules[i] = moduleOf(m)
+	}
+	nd.modulesByPath = make(map[string]*Module, len(d.modulesByPath))
+	for path, m := range d.modulesByPath {
+		nd.modulesByPath[path] = moduleOf(m)
+	}
+
+	// Copy packages.
+	var pkgs = make(map[*Package]*Package, len(d.packageList))
+	nd.packageList = make([]*Package, len(d.packageList))
+	for i, pkg := range d.packageList {
+		npkg := &Package{
+			PPkg:        pkg.PPkg,
+			Path:        pkg.Path,
+			OneLineDoc:  pkg.OneLineDoc,
+			Directory:   pkg.Directory,
+			module:      moduleOf(pkg.module),
+			wrongModule: pkg.wrongModule,
+		}
+		pkgs[pkg] = npkg
+		nd.packageList[i] = npkg
+	}
+	for i, pkg := range cp.pkgs {
+		npkg := pkgs[pkg]
+		npkg.PPkg = cp.ppkgs[i]
+		npkg.OneLineDoc = cp.docs[i]
+	}
+	var packagesOf = func(list []*Package) []*Package {
+		if list == nil {
+			return nil
+		}
+		newList := make([]*Package, len(list))
+		for i, pkg := range list {
+			newList[i] = pkgs[pkg]
+		}
+		return newList
+	}
+	for _, pkg := range d.packageList {
+		npkg := pkgs[pkg]
+		npkg.Deps = packagesOf(pkg.Deps)
+		npkg.DepedBys = packagesOf(pkg.DepedBys)
+	}
+	nd.packageTable = make(map[string]*Package, len(d.packageTable))
+	for path, pkg := range d.packageTable {
+		nd.packageTable[path] = pkgs[pkg]
+	}
+	nd.builtinPkg = pkgs[d.builtinPkg]
+	nd.stats.Packages = int32(len(nd.packageList))
+
+	// Rebuild the package hierarchies and requirements of modules.
+	for m, nm := range modules {
+		nm.Pkgs = packagesOf(m.Pkgs)
+		nm.Requires, nm.RequiredBys = nil, nil
+		nm.rootPkg = nil
+	}
+	for _, m := range nd.modulesByPath {
+		m.buildPackageHierarchy()
+	}
+	nd.confirmModuleRequirements()
+
+	return nd
+}
@@ -14,11 +14,13 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"golang.org/x/mod/modfile"
 	"golang.org/x/tools/go/packages"
 
 	"go101.org/golds/internal/util"
@@ -115,10 +117,12 @@ func hasMatchedPackages(arg string) bool {
 //	return pkgs, nil
 //}
 
-func validateArgumentsAndSetOptions(args []string, toolchainPath string) ([]string, bool, error) {
+// The returned envs are the extra environment variables needed
+// to run go commands for the returned args.
+func validateArgumentsAndSetOptions(args []string, toolchainPath string) (_ []string, envs []string, _ bool, _ error) {
 	if len(args) == 0 {
 		//panic("should not")
-		return []string{"."}, false, nil
+		return []string{"."}, nil, false, nil
 	}
 
 	hasToolchain := false
@@ -130,10 +134,20 @@ func validateArgumentsAndSetOptions(args []string, toolchainPath string) ([]stri
 	} else {
 		hasOthers := false
 		oldArgs := args
-		args = args[:0]
+		args = make([]string, 0, len(oldArgs))
 		for _, p := range oldArgs {
 			if p == "std" {
 				args = append(args, p)
+			} else if p == "work" || filepath.Base(p) == "go.work" {
+				patterns, env, err := workspacePackagePatterns(p)
+				if err != nil {
+					return nil, nil, hasToolchain, err
+				}
+				if env != "" {
+					envs = append(envs, env)
+				}
+				hasOthers = true
+				args = append(args, patterns...)
 			} else if strings.HasPrefix(p, "cmd/") || p == "cmd" || p == "toolchain" {
 				if p == "toolchain" {
 					p = "cmd"
@@ -175,7 +189,54 @@ func validateArgumentsAndSetOptions(args []string, toolchainPath string) ([]stri
 		//}
 	}
 
-	return args, hasToolchain, nil
+	return args, envs, hasToolchain, nil
+}
+
+// workspacePackagePatterns returns the package patterns for all the modules
+// used in a go.work file. If goworkFile is "work", the go.work file
+// reported by "go env GOWORK" is used. Otherwise, the returned env
+// makes go commands use the specified go.work file.
+func workspacePackagePatterns(goworkFile string) (patterns []string, env string, _ error) {
+	if goworkFile == "work" {
+		output, err := util.RunShell(time.Second*5, "", nil, "go", "env", "GOWORK")
+		if err != nil {
+			return nil, "", fmt.Errorf("go env GOWORK error: %w", err)
+		}
+		goworkFile = string(bytes.TrimSpace(output))
+		if goworkFile == "" || goworkFile == "off" {
+			return nil, "", errors.New("no go.work file is found for the work argument")
+		}
+	} else {
+		absPath, err := filepath.Abs(goworkFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("get absolute path of %s error: %w", goworkFile, err)
+		}
+		goworkFile = absPath
+		env = "GOWORK=" + goworkFile
+	}
+
+	data, err := os.ReadFile(goworkFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("read %s error: %w", goworkFile, err)
+	}
+	workFile, err := modfile.ParseWork(goworkFile, data, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("parse %s error: %w", goworkFile, err)
+	}
+
+	workDir := filepath.Dir(goworkFile)
+	patterns = make([]string, 0, len(workFile.Use))
+	for _, use := range workFile.Use {
+		dir := use.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workDir, dir)
+		}
+		patterns = append(patterns, filepath.ToSlash(dir)+"/...")
+	}
+	if len(patterns) == 0 {
+		return nil, "", fmt.Errorf("no modules are used in %s", goworkFile)
+	}
+	return patterns, env, nil
 }
 
 type LoadError struct {
@@ -186,15 +247,24 @@ func (le *LoadError) Error() string { // just to implement error
 	return fmt.Sprintf("%d errors", len(le.Errs))
 }
 
+// loadPackages loads packages with the on-disk cache if it is enabled.
+func (d *CodeAnalyzer) loadPackages(config *packages.Config, toolchain ToolchainInfo, args ...string) ([]*packages.Package, error) {
+	if d.cacheDir == "" {
+		return packages.Load(config, args...)
+	}
+	return loadPackagesWithCache(d.cacheDir, config, toolchain, args...)
+}
+
 // ParsePackages parses input packages.
 func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...int32), completeModuleInfo func(*Module), toolchain ToolchainInfo, args ...string) error {
 	// the length of the input args is not zero for sure.
 	oldArgs := args
 
-	args, hasToolchain, err := validateArgumentsAndSetOptions(args, toolchain.Cmd)
+	args, goEnv, hasToolchain, err := validateArgumentsAndSetOptions(args, toolchain.Cmd)
 	if err != nil {
 		return err
 	}
+	d.goEnv = goEnv
 
 	if len(args) == 0 {
 		if len(oldArgs) != 1 || strings.HasPrefix(oldArgs[0], ".") {
@@ -267,6 +337,7 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 			packages.NeedCompiledGoFiles | packages.NeedTypesSizes |
 			packages.NeedSyntax | packages.NeedTypesInfo,
 		Tests: false, // ToDo: parse tests
+		Env:   d.packagesConfigEnv(),
 		// It looks, if Tests is set to true, "golds std" panics with error:
 		// * panic: TypeName for reflect.EmbedWithUnexpMeth not found
 		// * or panic: TypeName for runtime.LFNode not found
@@ -317,7 +388,7 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 	}
 
 	// load all others
-	ppkgs, err := packages.Load(configForParsing, args...)
+	ppkgs, err := d.loadPackages(configForParsing, toolchain, args...)
 	if err != nil {
 		return fmt.Errorf("packages.Load (parse packages): %w", err)
 	}
@@ -347,7 +418,7 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 
 	// For "golds main.go" cases.
 	if !hasRuntime {
-		runtimePPkgs, err := packages.Load(configForParsing, "runtime")
+		runtimePPkgs, err := d.loadPackages(configForParsing, toolchain, "runtime")
 		if err != nil {
 			return fmt.Errorf("packages.Load (parse runtime package): %w", err)
 		}
@@ -448,6 +519,12 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 			dep.DepedBys = append(dep.DepedBys, pkg)
 		}
 	}
+	// Keep the orders stable, for they affect the orders of some outputs.
+	for _, pkg := range d.packageList {
+		deps, depedBys := pkg.Deps, pkg.DepedBys
+		sort.Slice(deps, func(i, j int) bool { return deps[i].Path < deps[j].Path })
+		sort.Slice(depedBys, func(i, j int) bool { return depedBys[i].Path < depedBys[j].Path })
+	}
 
 	logProgress(true, SubTask_CollectPackages, int32(len(d.packageList)))
 
@@ -480,6 +557,7 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 	for _, m := range d.modulesByPath {
 		m.buildPackageHierarchy()
 	}
+	d.confirmModuleRequirements()
 
 	logProgress(true, SubTask_CollectModules, int32(len(d.modulesByPath)))
 
@@ -488,6 +566,43 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 	return nil
 }
 
+// Module requirements are confirmed by package imports.
+func (d *CodeAnalyzer) confirmModuleRequirements() {
+	type requirement struct {
+		m, required *Module
+	}
+	var requirements = make(map[requirement]struct{}, len(d.modulesByPath)*4)
+	for _, pkg := range d.packageList {
+		m := pkg.module
+		if m == nil {
+			continue
+		}
+		for _, dep := range pkg.Deps {
+			required := dep.module
+			if required == nil || required == m || required == d.stdModule {
+				continue
+			}
+			r := requirement{m, required}
+			if _, ok := requirements[r]; ok {
+				continue
+			}
+			requirements[r] = struct{}{}
+			m.Requires = append(m.Requires, required)
+			required.RequiredBys = append(required.RequiredBys, m)
+		}
+	}
+
+	sortModules := func(modules []*Module) {
+		sort.Slice(modules, func(a, b int) bool {
+			return modules[a].Path < modules[b].Path
+		})
+	}
+	for _, m := range d.modulesByPath {
+		sortModules(m.Requires)
+		sortModules(m.RequiredBys)
+	}
+}
+
 //var newlineBrace = []byte{'\n', '{'}
 //var newline = []byte{'\n'}
 //var space = []byte{' '}
@@ -500,7 +615,7 @@ func (d *CodeAnalyzer) confirmPackageModules(args []string, hasToolchain bool, t
 
 	// In the output, packages under GOROOT have not .module info.
 	cmdAndArgs := append([]string{"go", "list", "-deps", "-json"}, args...)
-	output, err := util.RunShell(time.Minute*3, "", nil, cmdAndArgs...)
+	output, err := util.RunShell(time.Minute*3, "", d.goEnv, cmdAndArgs...)
 	if err != nil {
 		// log.Printf("%s", output) // debug(ToDo: need a debug verbose flag)
 		return fmt.Errorf("unable to list packages and modules info: %s: %w", strings.Join(cmdAndArgs, " "), err)
@@ -700,10 +815,29 @@ func (d *CodeAnalyzer) confirmPackageModules(args []string, hasToolchain bool, t
 		// Not weird. Toolchain depends on some golang.org/x/... packages.
 	}
 
+	if hasToolchain {
+		d.wdModules = []*Module{d.wdModule}
+	}
 	for i := range d.nonToolchainModules {
 		m := &d.nonToolchainModules[i]
 		if m.ActualVersion() == "" && m.Replace.Path == "" {
-			d.wdModule = m
+			d.wdModules = append(d.wdModules, m)
+		}
+	}
+	// With a go.work file, there might be multiple working directory modules.
+	// The one containing the current directory is preferred as the primary one.
+	if len(d.wdModules) > 0 {
+		d.wdModule = d.wdModules[len(d.wdModules)-1]
+		if len(d.wdModules) > 1 {
+			wd, matchedLen := util.WorkingDirectory(), 0
+			for _, m := range d.wdModules {
+				if m.Dir == "" || len(m.Dir) <= matchedLen {
+					continue
+				}
+				if wd == m.Dir || strings.HasPrefix(wd, m.Dir+string(filepath.Separator)) {
+					d.wdModule, matchedLen = m, len(m.Dir)
+				}
+			}
 		}
 	}
 	// Confirm wdModule firstly so that the vendor directory could be determined,
@@ -762,7 +896,7 @@ func (d *CodeAnalyzer) confirmPackageModules(args []string, hasToolchain bool, t
 
 	for i := range d.nonToolchainModules {
 		m := &d.nonToolchainModules[i]
-		if m != d.wdModule && m.ActualVersion() == "" && strings.HasPrefix(m.Replace.Dir, ".") {
+		if !d.IsWorkingDirectoryModule(m) && m.ActualVersion() == "" && strings.HasPrefix(m.Replace.Dir, ".") {
 			log.Printf("!!! the version of module %s is not confirmed, weird", m.Path)
 		}
 	}
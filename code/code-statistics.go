@@ -1,6 +1,7 @@
 package code
 
 import (
+	"encoding/json"
 	"reflect"
 )
 
@@ -124,6 +125,37 @@ func (tl *TopList) TryToInit(n int) {
 	}
 }
 
+// MarshalJSON encodes a TopList with its items represented by strings,
+// in the forms of "pkgpath", "pkgpath/filename", "pkgpath.Name"
+// or "pkgpath.TypeName.Selector".
+func (tl TopList) MarshalJSON() ([]byte, error) {
+	items := make([]string, 0, len(tl.Items))
+	for _, item := range tl.Items {
+		switch v := item.(type) {
+		case *string:
+			items = append(items, *v)
+		case *Package:
+			items = append(items, v.Path)
+		case *struct {
+			*Package
+			Filename string
+		}:
+			items = append(items, v.Package.Path+"/"+v.Filename)
+		case *struct {
+			*TypeName
+			*Selector
+		}:
+			items = append(items, v.TypeName.Pkg.Path+"."+v.TypeName.Name()+"."+v.Selector.Name())
+		case Resource:
+			items = append(items, v.Package().Path+"."+v.Name())
+		}
+	}
+	return json.Marshal(struct {
+		Criteria int
+		Items    []string
+	}{tl.Criteria, items})
+}
+
 // Push trys to add a new top item.
 func (tl *TopList) Push(n int, obj interface{}) {
 	if n < tl.Criteria {
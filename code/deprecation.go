@@ -0,0 +1,156 @@
+package code
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// IsDeprecationDoc returns whether or not the specified document text
+// contains a deprecation notice, which is a paragraph starting with
+// "Deprecated: ".
+// See https://go.dev/wiki/Deprecated.
+func IsDeprecationDoc(doc string) bool {
+	for doc != "" {
+		var para string
+		if i := strings.Index(doc, "\n\n"); i >= 0 {
+			para, doc = doc[:i], doc[i+2:]
+		} else {
+			para, doc = doc, ""
+		}
+		if strings.HasPrefix(strings.TrimLeft(para, "\n"), "Deprecated: ") {
+			return true
+		}
+	}
+	return false
+}
+
+func isDeprecationCommentGroup(cg *ast.CommentGroup) bool {
+	return cg != nil && IsDeprecationDoc(cg.Text())
+}
+
+// collectDeprecatedObjects finds out the deprecated packages and
+// the deprecated objects declared in them, including package-level
+// type names, functions, variables and constants, methods and
+// fields (of both named and unnamed struct types).
+func (d *CodeAnalyzer) collectDeprecatedObjects() {
+	d.deprecatedObjects = map[types.Object]struct{}{}
+
+	for _, pkg := range d.packageList {
+		if pkg.PPkg.TypesInfo == nil {
+			continue
+		}
+		var reg = func(ids ...*ast.Ident) {
+			for _, id := range ids {
+				if obj := pkg.PPkg.TypesInfo.Defs[id]; obj != nil {
+					d.deprecatedObjects[obj] = struct{}{}
+				}
+			}
+		}
+		// Fields and interface methods, including the ones
+		// in nested unnamed struct and interface types.
+		var regFieldsInType = func(t ast.Expr) {
+			ast.Inspect(t, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.FuncLit:
+					return false
+				case *ast.Field:
+					if isDeprecationCommentGroup(n.Doc) {
+						if len(n.Names) == 0 { // embedded
+							reg(embeddedFieldIdent(n.Type))
+						} else {
+							reg(n.Names...)
+						}
+					}
+				}
+				return true
+			})
+		}
+
+		for _, file := range pkg.PPkg.Syntax {
+			if isDeprecationCommentGroup(file.Doc) {
+				pkg.Deprecated = true
+			}
+			for _, decl := range file.Decls {
+				switch decl := decl.(type) {
+				case *ast.FuncDecl:
+					if isDeprecationCommentGroup(decl.Doc) {
+						reg(decl.Name)
+					}
+				case *ast.GenDecl:
+					declDeprecated := isDeprecationCommentGroup(decl.Doc)
+					for _, spec := range decl.Specs {
+						switch spec := spec.(type) {
+						case *ast.TypeSpec:
+							if declDeprecated || isDeprecationCommentGroup(spec.Doc) {
+								reg(spec.Name)
+							}
+							regFieldsInType(spec.Type)
+						case *ast.ValueSpec:
+							if declDeprecated || isDeprecationCommentGroup(spec.Doc) {
+								reg(spec.Names...)
+							}
+							if spec.Type != nil {
+								regFieldsInType(spec.Type)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func embeddedFieldIdent(t ast.Expr) *ast.Ident {
+	for {
+		switch e := t.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.StarExpr:
+			t = e.X
+		case *ast.SelectorExpr:
+			return e.Sel
+		case *ast.IndexExpr:
+			t = e.X
+		case *ast.IndexListExpr:
+			t = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// IsObjectDeprecated returns whether or not the specified object
+// is documented as deprecated.
+func (d *CodeAnalyzer) IsObjectDeprecated(obj types.Object) bool {
+	if obj == nil {
+		return false
+	}
+	if _, ok := d.deprecatedObjects[obj]; ok {
+		return true
+	}
+	// Instantiated functions, methods and fields.
+	switch o := obj.(type) {
+	case *types.Func:
+		if origin := originFunc(o); origin != o {
+			_, ok := d.deprecatedObjects[origin]
+			return ok
+		}
+	case *types.Var:
+		if origin := originVar(o); origin != o {
+			_, ok := d.deprecatedObjects[origin]
+			return ok
+		}
+	}
+	return false
+}
+
+// DeprecatedObjects returns all the collected deprecated objects
+// (in an unspecified order).
+func (d *CodeAnalyzer) DeprecatedObjects() []types.Object {
+	objs := make([]types.Object, 0, len(d.deprecatedObjects))
+	for obj := range d.deprecatedObjects {
+		objs = append(objs, obj)
+	}
+	return objs
+}
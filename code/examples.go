@@ -0,0 +1,170 @@
+package code
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// classifyExamples associates the examples of a package with the exported
+// functions, types and methods of the package, by following the naming
+// convention used by go doc: ExampleFoo, ExampleT_Method and the variants
+// of them with suffixes starting with lower-case letters, such as
+// ExampleFoo_second and ExampleT_Method_second.
+//
+// Package-level examples and examples not matching any identifier
+// are associated with the blank identifier path.
+func (pkg *Package) classifyExamples() {
+	pkg.ExamplesByIdentifier = nil
+	if len(pkg.Examples) == 0 {
+		return
+	}
+
+	// example name -> identifier path
+	ids := map[string]string{"": ""}
+	if pkg.PackageAnalyzeResult != nil {
+		for _, tn := range pkg.AllTypeNames {
+			if tn.Exported() {
+				ids[tn.Name()] = tn.Name()
+			}
+		}
+		for _, f := range pkg.AllFunctions {
+			if !f.Exported() {
+				continue
+			}
+			if !f.IsMethod() {
+				ids[f.Name()] = f.Name()
+				continue
+			}
+			_, tn, _ := f.ReceiverTypeName()
+			if tn != nil && tn.Exported() {
+				ids[tn.Name()+"_"+f.Name()] = tn.Name() + "." + f.Name()
+			}
+		}
+	}
+
+	pkg.ExamplesByIdentifier = make(map[string][]*doc.Example)
+	for _, ex := range pkg.Examples {
+		id, matched := "", false
+		// Try all possible split points for the suffix, starting
+		// at the end of the name (the no suffix case).
+		for i := len(ex.Name); i >= 0; i = strings.LastIndexByte(ex.Name[:i], '_') {
+			prefix, suffix, ok := splitExampleName(ex.Name, i)
+			if !ok {
+				continue
+			}
+			if id, matched = ids[prefix]; matched {
+				ex.Suffix = suffix
+				break
+			}
+		}
+		if !matched {
+			ex.Suffix = ""
+		}
+		pkg.ExamplesByIdentifier[id] = append(pkg.ExamplesByIdentifier[id], ex)
+	}
+
+	for _, exs := range pkg.ExamplesByIdentifier {
+		sort.SliceStable(exs, func(a, b int) bool {
+			return exs[a].Suffix < exs[b].Suffix
+		})
+	}
+}
+
+func splitExampleName(s string, i int) (prefix, suffix string, ok bool) {
+	if i == len(s) {
+		return s, "", true
+	}
+	if i == len(s)-1 {
+		return "", "", false
+	}
+	prefix, suffix = s[:i], s[i+1:]
+	r, size := utf8.DecodeRuneInString(suffix)
+	return prefix, suffix, size > 0 && unicode.IsLower(r)
+}
+
+// ExamplesOf returns the examples associated with the specified identifier
+// path, which is like "Name" or "Type.Method". Blank means package-level.
+func (pkg *Package) ExamplesOf(id string) []*doc.Example {
+	return pkg.ExamplesByIdentifier[id]
+}
+
+// The same as the ones used in go/doc.
+var (
+	noteMarker    = `([A-Z][A-Z]+)\(([^)]+)\):?`                    // MARKER(uid), MARKER at least 2 chars, uid at least 1 char
+	noteMarkerRx  = regexp.MustCompile(`^[ \t]*` + noteMarker)      // MARKER(uid) at text start
+	noteCommentRx = regexp.MustCompile(`^/[/*][ \t]*` + noteMarker) // MARKER(uid) at comment start
+)
+
+// collectNotes collects the notes, such as "// BUG(who): text",
+// in the source files of a package, like go/doc does.
+// A note ends at the end of its comment group or at the start
+// of another note in the same comment group.
+func (pkg *Package) collectNotes() {
+	pkg.Notes = nil
+
+	var readNote = func(list []*ast.Comment) {
+		text := (&ast.CommentGroup{List: list}).Text()
+		m := noteMarkerRx.FindStringSubmatchIndex(text)
+		if m == nil {
+			return
+		}
+		body := strings.TrimSpace(text[m[1]:])
+		if body == "" {
+			return
+		}
+		if pkg.Notes == nil {
+			pkg.Notes = make(map[string][]*doc.Note)
+		}
+		marker := text[m[2]:m[3]]
+		pkg.Notes[marker] = append(pkg.Notes[marker], &doc.Note{
+			Pos:  list[0].Pos(),
+			End:  list[len(list)-1].End(),
+			UID:  text[m[4]:m[5]],
+			Body: body,
+		})
+	}
+
+	for _, file := range pkg.PPkg.Syntax {
+		for _, group := range file.Comments {
+			i := -1 // index of the most recent note start
+			for j, c := range group.List {
+				if noteCommentRx.MatchString(c.Text) {
+					if i >= 0 {
+						readNote(group.List[i:j])
+					}
+					i = j
+				}
+			}
+			if i >= 0 {
+				readNote(group.List[i:])
+			}
+		}
+	}
+}
+
+// NoteMarkers returns the sorted markers of the notes in a package,
+// with "BUG" being the first one if it exists.
+func (pkg *Package) NoteMarkers() []string {
+	markers := make([]string, 0, len(pkg.Notes))
+	for marker := range pkg.Notes {
+		markers = append(markers, marker)
+	}
+	sort.Slice(markers, func(a, b int) bool {
+		if markers[a] == "BUG" || markers[b] == "BUG" {
+			return markers[a] == "BUG"
+		}
+		return markers[a] < markers[b]
+	})
+	return markers
+}
+
+// NotePosition returns the position of a note in a package.
+func (pkg *Package) NotePosition(note *doc.Note) token.Position {
+	return pkg.PPkg.Fset.PositionFor(note.Pos, false)
+}
@@ -22,6 +22,8 @@ type Module struct {
 	Path    string
 	Version string
 
+	GoVersion string // the go directive in go.mod, blank for std and toolchain modules
+
 	// ...
 	Replace moduleReplacement
 
@@ -41,6 +43,11 @@ type Module struct {
 
 	Pkgs []*Package // seen packages
 
+	// Requirements are confirmed by package imports,
+	// so only seen modules are involved. The std module is excluded.
+	Requires    []*Module
+	RequiredBys []*Module
+
 	// The package hierarchy.
 	// In a package hierarchy, there are some fake nonexisting packages.
 	// For a fake package, only its name is important.
@@ -78,6 +85,12 @@ func (m *Module) ActualDir() string {
 	return m.Dir
 }
 
+// RootPackage returns the root of the package hierarchy of a Module.
+// The root package might be a fake one.
+func (m *Module) RootPackage() *Package {
+	return m.rootPkg
+}
+
 func (m *Module) buildPackageHierarchy() {
 	if len(m.Pkgs) == 0 {
 		return
@@ -377,8 +390,11 @@ type Package struct {
 	SourceFiles           []SourceFileInfo
 	ExampleFiles          []*ast.File
 	Examples              []*doc.Example
+	ExamplesByIdentifier  map[string][]*doc.Example // identifier path ("Name", "Type.Method", or blank for package-level) -> examples
+	Notes                 map[string][]*doc.Note    // marker (like "BUG") -> notes
 
 	OneLineDoc  string
+	Deprecated  bool // whether or not the package documentation contains a deprecation notice
 	Directory   string
 	module      *Module
 	wrongModule bool // whether or not Package.Path is prefixed by module path
@@ -417,6 +433,12 @@ func (p *Package) ParentPackage() *Package {
 	return p.parent
 }
 
+// ChildPackages returns the child packages in the package hierarchy.
+// Some of them might be fake ones.
+func (p *Package) ChildPackages() []*Package {
+	return p.children
+}
+
 // PackageAnalyzeResult holds the analysis result of a Go package.
 type PackageAnalyzeResult struct {
 	AllTypeNames []*TypeName // not including instantiated ones
@@ -592,6 +614,7 @@ const (
 	analyseCompleted Attribute = 1 << (31 - iota)
 	directSelectorsCollected
 	promotedSelectorsCollected
+	satisfiedBysCollected
 
 	// Higher bits are for runtime-only flags.
 	AtributesPersistentMask Attribute = (1 << 25) - 1
@@ -902,6 +925,12 @@ type TypeInfo struct {
 	// For interface types.
 	ImplementedBys []*TypeInfo
 
+	//>> 1.18
+	// For constraint-only interface types.
+	// Use CodeAnalyzer.SatisfiedBys to get it.
+	satisfiedBys []*TypeInfo
+	//<<
+
 	//
 	Aliases []*TypeName
 
@@ -962,6 +991,12 @@ type TypeInfo struct {
 	//counter2 int32
 }
 
+// Index returns the global index of a type.
+// It is the index of the type in the registered types.
+func (t *TypeInfo) Index() int {
+	return int(t.index)
+}
+
 // Kind returns the kinds (as reflect.Kind) of a type.
 func (t *TypeInfo) Kind() reflect.Kind {
 	return Kind(t.TT)
@@ -1763,6 +1798,13 @@ type Identifier struct {
 	AstIdent *ast.Ident
 }
 
+// Instantiation represents the instantiation sites of a generic
+// type or function with the same type argument list.
+type Instantiation struct {
+	TypeArgs []types.Type
+	Sites    []Identifier
+}
+
 //type PackageLevelIdentifier struct {
 //	FileInfo *SourceFileInfo
 //	Examples []*Example
@@ -0,0 +1,203 @@
+package code
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// stdAPIVersions records the Go versions which introduced
+// the standard packages and their exported identifiers.
+// The info is parsed from the api/go1*.txt files in GOROOT.
+type stdAPIVersions struct {
+	packages    map[string]string            // package path -> version
+	identifiers map[string]map[string]string // package path -> identifier path -> version
+}
+
+// collectStdAPIVersions parses the api/go1*.txt files in GOROOT.
+// It does nothing if the files are not found.
+func (d *CodeAnalyzer) collectStdAPIVersions() {
+	if d.stdAPIVersions != nil || d.stdModule == nil || d.stdModule.RepositoryDir == "" {
+		return
+	}
+
+	files, err := filepath.Glob(filepath.Join(d.stdModule.RepositoryDir, "api", "go1*.txt"))
+	if err != nil || len(files) == 0 {
+		return
+	}
+
+	type apiFile struct {
+		path    string
+		version string
+		minor   int
+	}
+	apiFiles := make([]apiFile, 0, len(files))
+	for _, f := range files {
+		version := strings.TrimSuffix(filepath.Base(f), ".txt")
+		minor := 0
+		if v := strings.TrimPrefix(version, "go1"); v != "" {
+			n, err := strconv.Atoi(strings.TrimPrefix(v, "."))
+			if err != nil || v[0] != '.' {
+				continue
+			}
+			minor = n
+		}
+		apiFiles = append(apiFiles, apiFile{path: f, version: version, minor: minor})
+	}
+	sort.Slice(apiFiles, func(a, b int) bool {
+		return apiFiles[a].minor < apiFiles[b].minor
+	})
+
+	versions := &stdAPIVersions{
+		packages:    make(map[string]string, 256),
+		identifiers: make(map[string]map[string]string, 256),
+	}
+	for _, af := range apiFiles {
+		versions.parseAPIFile(af.path, af.version)
+	}
+	d.stdAPIVersions = versions
+}
+
+func (vs *stdAPIVersions) parseAPIFile(path, version string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		pkgPath, id := parseAPILine(scanner.Text())
+		if pkgPath == "" {
+			continue
+		}
+		if _, ok := vs.packages[pkgPath]; !ok {
+			vs.packages[pkgPath] = version
+		}
+		if id == "" {
+			continue
+		}
+		ids := vs.identifiers[pkgPath]
+		if ids == nil {
+			ids = make(map[string]string, 32)
+			vs.identifiers[pkgPath] = ids
+		}
+		if _, ok := ids[id]; !ok {
+			ids[id] = version
+		}
+	}
+}
+
+// parseAPILine parses a line in an api/go1*.txt file, such as
+//
+//	pkg bytes, func Clone([]uint8) []uint8 #45038
+//	pkg syscall (linux-386), const AF_ALG = 38
+//	pkg net/http, method (*Request) Clone(context.Context) *Request
+//	pkg database/sql, type Null[$0 interface{}] struct, V $0 #60370
+//	pkg io, type ByteWriter interface, WriteByte(uint8) error
+//
+// The returned id is like "Name" or "Type.Selector". It is blank
+// for lines not denoting exported identifiers.
+func parseAPILine(line string) (pkgPath, id string) {
+	if i := strings.Index(line, " #"); i >= 0 {
+		line = line[:i]
+	}
+	if i := strings.Index(line, " //"); i >= 0 {
+		line = line[:i]
+	}
+	if !strings.HasPrefix(line, "pkg ") {
+		return "", ""
+	}
+	line = line[len("pkg "):]
+	i := strings.Index(line, ", ")
+	if i < 0 {
+		return "", ""
+	}
+	pkgPath, line = line[:i], line[i+2:]
+	if j := strings.IndexByte(pkgPath, ' '); j >= 0 { // (GOOS-GOARCH)
+		pkgPath = pkgPath[:j]
+	}
+
+	kind, rest, _ := strings.Cut(line, " ")
+	switch kind {
+	case "func", "const", "var":
+		return pkgPath, leadingIdentifier(rest)
+	case "method":
+		if !strings.HasPrefix(rest, "(") {
+			return pkgPath, ""
+		}
+		k := strings.IndexByte(rest, ')')
+		if k < 0 {
+			return pkgPath, ""
+		}
+		recv := leadingIdentifier(strings.TrimLeft(rest[1:k], "*"))
+		name := leadingIdentifier(strings.TrimLeft(rest[k+1:], " "))
+		if recv == "" || name == "" {
+			return pkgPath, ""
+		}
+		return pkgPath, recv + "." + name
+	case "type":
+		name := leadingIdentifier(rest)
+		if name == "" {
+			return pkgPath, ""
+		}
+		rest = rest[len(name):]
+		if strings.HasPrefix(rest, "[") { // type parameter list
+			depth := 0
+			for k, c := range rest {
+				if c == '[' {
+					depth++
+				} else if c == ']' {
+					depth--
+					if depth == 0 {
+						rest = rest[k+1:]
+						break
+					}
+				}
+			}
+		}
+		k := strings.Index(rest, ", ")
+		if k < 0 {
+			return pkgPath, name
+		}
+		sel := rest[k+2:]
+		if strings.HasPrefix(sel, "embedded ") {
+			sel = strings.TrimLeft(sel[len("embedded "):], "*")
+			if dot := strings.LastIndexByte(sel, '.'); dot >= 0 {
+				sel = sel[dot+1:]
+			}
+		}
+		sel = leadingIdentifier(sel)
+		if sel == "" || sel == "unexported" {
+			return pkgPath, ""
+		}
+		return pkgPath, name + "." + sel
+	}
+	return pkgPath, ""
+}
+
+func leadingIdentifier(s string) string {
+	for i, c := range s {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c > 127) {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// StdAPIVersion returns the Go version (like "go1.21") which introduced
+// the specified standard package (if id is blank) or identifier.
+// The identifier is like "Name" or "Type.Selector". Blank is returned
+// if the info is unavailable.
+func (d *CodeAnalyzer) StdAPIVersion(pkgPath, id string) string {
+	if d.stdAPIVersions == nil {
+		return ""
+	}
+	if id == "" {
+		return d.stdAPIVersions.packages[pkgPath]
+	}
+	return d.stdAPIVersions.identifiers[pkgPath][id]
+}
@@ -3,9 +3,8 @@ module go101.org/golds
 go 1.20
 
 require (
+	golang.org/x/mod v0.14.0
 	golang.org/x/net v0.19.0
 	golang.org/x/text v0.14.0
 	golang.org/x/tools v0.16.0
 )
-
-require golang.org/x/mod v0.14.0 // indirect
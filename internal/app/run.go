@@ -104,7 +104,7 @@ func run() {
 	verboseMode := *verboseFlag || *vFlag
 
 	// files serving mode
-	if flag.NArg() == 0 && !*genFlag {
+	if flag.NArg() == 0 && !*genFlag && *apiDiffFlag == "" {
 		log.SetFlags(0)
 
 		if *dirFlag == "" {
@@ -204,6 +204,16 @@ func run() {
 		*nounexporteds = true
 	}
 
+	var cacheDir string
+	if *cacheFlag {
+		cacheDir = *cacheDirFlag
+		if cacheDir == "" {
+			if userCacheDir, err := os.UserCacheDir(); err == nil {
+				cacheDir = filepath.Join(userCacheDir, "golds")
+			}
+		}
+	}
+
 	options := server.PageOutputOptions{
 		GoldsVersion:           Version,
 		PreferredLang:          *langFlag,
@@ -217,9 +227,18 @@ func run() {
 		RenderDocLinks:         *renderDocLinksFlag,
 		UnfoldAllInitially:     *unfoldAllInitiallyFlag,
 		Theme:                  *themeFlag,
+		WatchSourceChanges:     *watchFlag,
+		RunExamples:            *runExamplesFlag,
+		CacheDirectory:         cacheDir,
 		VerboseLogs:            verboseMode,
 	}
 
+	// api diff mode
+	if diffRange := *apiDiffFlag; diffRange != "" {
+		server.APIDiff(options, flag.Args(), diffRange, validateDir(*dirFlag, true), silentMode, printUsage)
+		return
+	}
+
 	// static docs generating mode
 	if gen := *genFlag; gen {
 		outputDir := validateDir(*dirFlag, true)
@@ -229,11 +248,14 @@ func run() {
 			//printUsage(os.Stdout)
 		case "testdata":
 			server.GenTestData(flag.Args(), outputDir, silentMode, printUsage)
+		case "json":
+			server.GenJSON(options, flag.Args(), outputDir, silentMode, printUsage)
+		case "markdown":
+			server.GenMarkdown(options, flag.Args(), outputDir, silentMode, printUsage)
 		case "docs":
 			viewDocsCommand := func(docsDir string) string {
 				return os.Args[0] + " -dir=" + docsDir
 			}
-			// ToDo: also support json format output
 			server.GenDocs(options, flag.Args(), outputDir, silentMode, printUsage, *moregcFlag, viewDocsCommand)
 		}
 
@@ -267,7 +289,7 @@ var verboseFlag = flag.Bool("verbose", false, "verbose mode")
 // var updateFlag = flag.Bool("update", false, "update self")
 var versionFlag = flag.Bool("version", false, "show version info")
 var genFlag = flag.Bool("gen", false, "HTML generation mode")
-var genIntentFlag = flag.String("gen-intent", "docs", "docs | testdata")
+var genIntentFlag = flag.String("gen-intent", "docs", "docs | json | markdown | testdata")
 var langFlag = flag.String("lang", "", "docs generation language tag")
 var dirFlag = flag.String("dir", "", "directory for file serving or HTML generation")
 var portFlag = flag.String("port", "", "preferred server port [1024, 65536]. Default: 56789 or 9999")
@@ -296,6 +318,15 @@ var wdPkgsListingMannerFlag = flag.String("wdpkgs-listing", "", "specify how to
 
 var themeFlag = flag.String("theme", "auto", "auto | light | dark")
 
+var watchFlag = flag.Bool("watch", false, "re-analyze packages on source changes")
+
+var runExamplesFlag = flag.Bool("run-examples", false, "allow running examples on package details pages")
+
+var cacheFlag = flag.Bool("cache", true, "cache type-checking results on disk (use -cache=false to disable it)")
+var cacheDirFlag = flag.String("cache-dir", "", "directory of the on-disk type-checking cache. Default: $UserCacheDir/golds")
+
+var apiDiffFlag = flag.String("api-diff", "", "OLD[..NEW]: report exported API changes between two git revisions or module versions")
+
 func printVersion(out io.Writer) {
 	fmt.Fprintf(out, "Golds %s\n", Version)
 }
@@ -333,6 +364,14 @@ Options:
 		logs in docs generation mode.
 	-gen
 		Static HTML docs generation mode.
+	-gen-intent=docs|json|markdown
+		What to generate in the generation mode
+		(default is docs):
+		* docs: the static HTML docs pages.
+		* json: a golds-analysis.json file which
+		  contains all the analysis results.
+		* markdown: a Markdown file for each package
+		  and an index.md file listing the packages.
 	-dir=<ContentDirectory>|memory
 		Specify the docs generation or file
 		serving diretory. A new created subfolder
@@ -400,6 +439,39 @@ Options:
 		  it exists).
 		* light
 		* dark
+	-watch
+		Watch the source files of the packages not
+		in the module cache (docs serving mode only).
+		The packages will be re-analyzed in background
+		on .go and go.mod file changes. Open pages will
+		show a notice when newer docs are available.
+	-run-examples
+		Show a "Run" button for each example on
+		package details pages (docs serving mode
+		only). Clicking it runs the example with
+		"go test" locally and shows the output.
+		Disabled by default, for it executes code.
+	-cache
+		Cache the type-checking results of the packages
+		in std and in the dependency modules on disk,
+		so that a restart only needs to type-check the
+		packages in the working directory modules. The
+		analysis of golds itself is always re-done.
+		Enabled by default (only for golds built with
+		Go 1.23+). Use -cache=false to disable it.
+	-cache-dir
+		Specify the directory of the on-disk cache.
+		The default value is the golds directory in
+		the user cache directory.
+	-api-diff=OLD[..NEW]
+		Report the exported API changes between
+		two versions into an api-diff.json file and
+		an api-diff.html page in the directory
+		specified by the -dir flag. A version is
+		either a git revision of the repository
+		containing the current directory or a
+		ModulePath@Version. The working tree is
+		used if NEW is omitted.
 
 Examples:
 	%[1]v std
@@ -412,11 +484,25 @@ Examples:
 	%[1]v ./...
 		Show docs of all the packages
 		within the current directory.
+	%[1]v work
+		Show docs of all the packages in the
+		modules used by the current go.work file.
+	%[1]v path/to/go.work
+		Show docs of all the packages in the
+		modules used by the specified go.work file.
 	%[1]v -gen -dir=./generated ./...
 		Generate HTML docs pages into the path
 		specified by the -dir flag for the
 		packages under the current directory
 		and their dependency packages.
+	%[1]v -gen -gen-intent=json -dir=./analysis ./...
+		Write the analysis results of the packages
+		under the current directory and their
+		dependency packages into a JSON file.
+	%[1]v -api-diff=v1.2.0 -dir=./apidiff ./...
+		Report the exported API changes of the
+		packages under the current directory
+		since the git tag v1.2.0.
 	%[1]v -dir=. -s
 		Serve the files in working directory
 		without opening a browser window.
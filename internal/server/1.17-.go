@@ -46,3 +46,6 @@ func (ds *docServer) writeTypeParameterListCallbackForTypeName(page *htmlPage, p
 func (ds *docServer) writeTypeParameterListCallbackForFunction(page *htmlPage, pkg *code.Package, fv *code.Function) func() {
 	return nil
 }
+
+func (ds *docServer) writeTypeArgConstraints(page *htmlPage, docPkg *code.Package, forTypeName *code.TypeName, t *code.TypeInfo) {
+}
@@ -122,3 +122,37 @@ func (ds *docServer) writeTypeParameterListCallbackForFunction(page *htmlPage, p
 
 	return nil
 }
+
+// writeTypeArgConstraints writes the non-trivial constraints
+// satisfied by the type arguments of an instantiated type.
+func (ds *docServer) writeTypeArgConstraints(page *htmlPage, docPkg *code.Package, forTypeName *code.TypeName, t *code.TypeInfo) {
+	if t.Instantiated == nil || t.TypeName == nil || t.TypeName.AstSpec == nil || t.TypeName.AstSpec.TypeParams == nil {
+		return
+	}
+	ntt, ok := t.TypeName.Denoting.TT.(*types.Named)
+	if !ok || ntt.TypeParams().Len() != len(t.Instantiated.TypeArgs) {
+		return
+	}
+
+	var i, n = 0, 0
+	for _, fld := range t.TypeName.AstSpec.TypeParams.List {
+		for range fld.Names {
+			if itt, ok := ntt.TypeParams().At(i).Constraint().Underlying().(*types.Interface); !ok || !itt.Empty() {
+				if n == 0 {
+					page.WriteString(" <i>(")
+				} else {
+					page.WriteString(page.Translation().Text_Comma())
+				}
+				n++
+				var typeArg = &t.Instantiated.TypeArgs[i]
+				ds.WriteAstType(page, typeArg.Expr, typeArg.Pkg, docPkg, true, nil, forTypeName, nil)
+				page.WriteString(page.Translation().Text_SatisfiesConstraint())
+				ds.WriteAstType(page, fld.Type, t.TypeName.Pkg, docPkg, true, nil, forTypeName, nil)
+			}
+			i++
+		}
+	}
+	if n > 0 {
+		page.WriteString(")</i>")
+	}
+}
@@ -0,0 +1,52 @@
+//go:build !go1.19
+// +build !go1.19
+
+package server
+
+import (
+	"go/doc"
+	"go/types"
+
+	"go101.org/golds/code"
+)
+
+// writeDocComment renders a doc comment with the custom markdown renderer,
+// for the go/doc/comment package is only available since Go 1.19.
+// The renderer doesn't create ids for headings, so owner is not used.
+func (ds *docServer) writeDocComment(page *htmlPage, currentPkg *code.Package, owner, indent, doc string) {
+	ds.docRenderer.Render(page, doc, indent, true, ds.docLinkURLMaker(page, currentPkg))
+}
+
+// writeDoc renders a doc comment as plain text with code blocks indented,
+// for the Markdown printer of go/doc/comment is only available since Go 1.19.
+func (mw *markdownWriter) writeDoc(text string) {
+	doc.ToText(mw, text, "", "    ", 80)
+}
+
+// originFunc returns the generic method of an instantiated method, like
+// the (*types.Func).Origin method (only available since Go 1.19) does.
+func originFunc(f *types.Func) *types.Func {
+	sig, ok := f.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return f
+	}
+	rt := sig.Recv().Type()
+	if ptr, ok := rt.(*types.Pointer); ok {
+		rt = ptr.Elem()
+	}
+	// Named types have no Origin methods before Go 1.18.
+	named, ok := rt.(interface{ Origin() *types.Named })
+	if !ok {
+		return f
+	}
+	origin := named.Origin()
+	if origin == rt {
+		return f
+	}
+	for i := 0; i < origin.NumMethods(); i++ {
+		if m := origin.Method(i); m.Name() == f.Name() {
+			return m
+		}
+	}
+	return f
+}
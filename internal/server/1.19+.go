@@ -0,0 +1,324 @@
+//go:build go1.19
+// +build go1.19
+
+package server
+
+import (
+	"fmt"
+	"go/doc/comment"
+	"go/types"
+	"strings"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/util"
+)
+
+// writeDocComment renders a doc comment by following the Go doc comment
+// syntax (https://go.dev/doc/comment), including headings, lists, code
+// blocks, links, link definitions and doc links. The line layout of the
+// comment is kept, for the output is enclosed in a pre element.
+//
+// The ids of headings are prefixed with owner, the name of the identifier
+// the doc belongs to, for there are many docs in a page.
+func (ds *docServer) writeDocComment(page *htmlPage, currentPkg *code.Package, owner, indent, doc string) {
+	parser := comment.Parser{
+		LookupPackage: func(name string) (string, bool) {
+			return ds.lookupDocLinkPackage(currentPkg, name)
+		},
+		LookupSym: func(recv, name string) bool {
+			return lookupDocLinkSymbol(currentPkg, recv, name)
+		},
+	}
+
+	w := docCommentWriter{
+		ds:          ds,
+		page:        page,
+		currentPkg:  currentPkg,
+		owner:       owner,
+		indent:      indent,
+		atLineStart: true,
+	}
+	w.writeBlocks(parser.Parse(doc).Content)
+}
+
+func originFunc(f *types.Func) *types.Func {
+	return f.Origin()
+}
+
+// lookupDocLinkPackage resolves the package name in a doc link,
+// such as the "pkg" in [pkg.Name], to a package import path.
+func (ds *docServer) lookupDocLinkPackage(currentPkg *code.Package, name string) (string, bool) {
+	if name == currentPkg.PPkg.Name {
+		return "", true
+	}
+	for _, dep := range currentPkg.Deps {
+		if dep.PPkg.Name == name {
+			return dep.Path, true
+		}
+	}
+
+	// Like the custom renderer, also try the child, sibling and
+	// module top-level packages of the current package.
+	m := currentPkg.Module()
+	if m == nil {
+		return "", false
+	}
+	if pkg := m.PackageByPath(currentPkg.Path + "/" + name); pkg != nil {
+		return pkg.Path, true
+	}
+	if i := strings.LastIndexByte(currentPkg.Path, '/'); i > 0 {
+		if pkg := m.PackageByPath(currentPkg.Path[:i+1] + name); pkg != nil {
+			return pkg.Path, true
+		}
+	}
+	if pkg := m.PackageByPath(currentPkg.ModulePath() + "/" + name); pkg != nil {
+		return pkg.Path, true
+	}
+	return "", false
+}
+
+// lookupDocLinkSymbol reports whether or not the specified package-level
+// resource (recv is blank) or selector of a type exists in a package.
+func lookupDocLinkSymbol(pkg *code.Package, recv, name string) bool {
+	if recv == "" {
+		return pkg.SearchResourceByName(name) != nil
+	}
+	tn := pkg.TypeNameByName(recv)
+	return tn != nil && tn.Denoting.SelectorByName(name) != nil
+}
+
+// docCommentWriter writes the blocks of a parsed doc comment. Each line
+// is prefixed with the indent, and the lines in lists and code blocks
+// are further prefixed with list markers and extra indents.
+type docCommentWriter struct {
+	ds         *docServer
+	page       *htmlPage
+	currentPkg *code.Package
+	owner      string
+	indent     string
+
+	linePrefix  string // for all lines in the current block
+	itemPrefix  string // for the first line of the current list item
+	started     bool   // whether or not any line has been written
+	atLineStart bool
+	newLines    int // the number of pending line breaks
+}
+
+// startLine writes the pending line breaks and the line prefixes
+// if the writer is at the start of a line.
+func (w *docCommentWriter) startLine() {
+	if !w.atLineStart {
+		return
+	}
+	w.atLineStart = false
+
+	if w.started {
+		for i := 0; i < w.newLines; i++ {
+			w.page.WriteString("\n")
+			if i+1 < w.newLines {
+				w.page.WriteString(w.indent) // blank line
+			}
+		}
+	}
+	w.started = true
+	w.newLines = 0
+
+	w.page.WriteString(w.indent)
+	if w.itemPrefix != "" {
+		w.page.WriteString(w.itemPrefix)
+		w.itemPrefix = ""
+	} else {
+		w.page.WriteString(w.linePrefix)
+	}
+}
+
+// breakLines makes sure there are at least n line breaks
+// before the next written text.
+func (w *docCommentWriter) breakLines(n int) {
+	w.atLineStart = true
+	if w.newLines < n {
+		w.newLines = n
+	}
+}
+
+func (w *docCommentWriter) writeBlocks(blocks []comment.Block) {
+	for i, b := range blocks {
+		if i > 0 {
+			if list, ok := b.(*comment.List); ok && !list.BlankBefore() {
+				w.breakLines(1)
+			} else {
+				w.breakLines(2)
+			}
+		}
+
+		switch b := b.(type) {
+		case *comment.Paragraph:
+			w.writeTexts(b.Text)
+		case *comment.Heading:
+			id := b.DefaultID()
+			if w.owner != "" {
+				id = w.owner + "-" + id
+			}
+			w.startLine()
+			fmt.Fprintf(w.page, `<span class="md-heading" id="%[1]s"><a href="#%[1]s">`, id)
+			w.writeTexts(b.Text)
+			w.page.WriteString(`</a></span>`)
+		case *comment.Code:
+			oldPrefix := w.linePrefix
+			w.linePrefix += "\t"
+			w.writeString(strings.TrimSuffix(b.Text, "\n"))
+			w.linePrefix = oldPrefix
+		case *comment.List:
+			for k, item := range b.Items {
+				if k > 0 {
+					if b.BlankBetween() {
+						w.breakLines(2)
+					} else {
+						w.breakLines(1)
+					}
+				}
+				marker := "  - "
+				if item.Number != "" {
+					marker = "  " + item.Number + ". "
+				}
+				oldPrefix := w.linePrefix
+				w.itemPrefix = oldPrefix + marker
+				w.linePrefix = oldPrefix + strings.Repeat(" ", len(marker))
+				w.writeBlocks(item.Content)
+				w.linePrefix = oldPrefix
+			}
+		}
+	}
+}
+
+func (w *docCommentWriter) writeTexts(texts []comment.Text) {
+	for _, t := range texts {
+		switch t := t.(type) {
+		case comment.Plain:
+			w.writeString(string(t))
+		case comment.Italic:
+			w.startLine()
+			w.page.WriteString("<i>")
+			w.writeString(string(t))
+			w.page.WriteString("</i>")
+		case *comment.Link:
+			if renderDocLinks {
+				w.writeLink(t.URL, t.Text)
+			} else {
+				w.writeTexts(t.Text)
+			}
+		case *comment.DocLink:
+			w.writeLink(w.docLinkURL(t), t.Text)
+		}
+	}
+}
+
+// writeString writes HTML escaped text, which might contain line breaks.
+func (w *docCommentWriter) writeString(s string) {
+	for s != "" {
+		line, rest, found := strings.Cut(s, "\n")
+		if line != "" {
+			w.startLine()
+			w.page.AsHTMLEscapeWriter().WriteString(line)
+		}
+		if found {
+			w.atLineStart = true
+			w.newLines++
+		}
+		s = rest
+	}
+}
+
+func (w *docCommentWriter) writeLink(url string, texts []comment.Text) {
+	if url == "" {
+		w.writeTexts(texts)
+		return
+	}
+	w.startLine()
+	w.page.WriteString(`<a href="`)
+	util.WriteUrlEscapedString(w.page, url)
+	w.page.WriteString(`">`)
+	w.writeTexts(texts)
+	w.page.WriteString(`</a>`)
+}
+
+// docLinkURL returns the URL of a doc link, or blank if the
+// denoted package or resource is not found.
+func (w *docCommentWriter) docLinkURL(link *comment.DocLink) string {
+	if !renderDocLinks {
+		return ""
+	}
+
+	pkg := w.currentPkg
+	if link.ImportPath != "" {
+		pkg = w.ds.analyzer.PackageByPath(link.ImportPath)
+		if pkg == nil {
+			return ""
+		}
+	}
+
+	if link.Name == "" {
+		if pkg == w.currentPkg {
+			return ""
+		}
+		return buildPageHref(w.page.PathInfo, createPagePathInfo1(ResTypePackage, pkg.Path), nil, "")
+	}
+
+	if link.Recv == "" {
+		res := pkg.SearchResourceByName(link.Name)
+		if res == nil {
+			return ""
+		}
+		return w.ds.buildDocLinkResourceURL(w.page, pkg, res)
+	}
+
+	tn := pkg.TypeNameByName(link.Recv)
+	if tn == nil {
+		return ""
+	}
+	sel := tn.Denoting.SelectorByName(link.Name)
+	if sel == nil {
+		return ""
+	}
+	return w.ds.buildDocLinkSelectorURL(w.page, pkg, sel)
+}
+
+// writeDoc renders a doc comment as Markdown. Doc links to the
+// analyzed packages point to the generated Markdown files.
+func (mw *markdownWriter) writeDoc(text string) {
+	var analyzer = mw.ds.analyzer
+	parser := &comment.Parser{
+		LookupPackage: func(name string) (importPath string, ok bool) {
+			if analyzer.PackageByPath(name) != nil {
+				return name, true
+			}
+			for _, dep := range mw.pkg.Deps {
+				if dep.PPkg.Name == name {
+					return dep.Path, true
+				}
+			}
+			return "", false
+		},
+		LookupSym: func(recv, name string) bool {
+			return lookupDocLinkSymbol(mw.pkg, recv, name)
+		},
+	}
+	printer := &comment.Printer{
+		HeadingLevel: 4,
+		DocLinkURL: func(link *comment.DocLink) string {
+			pkgPath := link.ImportPath
+			if pkgPath == "" {
+				pkgPath = mw.pkg.Path
+			}
+			anchor := link.Name
+			if link.Recv != "" {
+				anchor = link.Recv
+			}
+			if analyzer.PackageByPath(pkgPath) == nil {
+				return link.DefaultURL("https://pkg.go.dev")
+			}
+			return mw.packageLink(pkgPath, anchor)
+		},
+	}
+	mw.Write(printer.Markdown(parser.Parse(text)))
+}
@@ -0,0 +1,15 @@
+//go:build !go1.20
+// +build !go1.20
+
+package server
+
+import (
+	"os/exec"
+	"time"
+)
+
+// exec.Cmd.WaitDelay is only available since Go 1.20. Without it,
+// waiting for a killed command might block until the child processes
+// started by the command exit.
+func setCommandWaitDelay(cmd *exec.Cmd, d time.Duration) {
+}
@@ -0,0 +1,13 @@
+//go:build go1.20
+// +build go1.20
+
+package server
+
+import (
+	"os/exec"
+	"time"
+)
+
+func setCommandWaitDelay(cmd *exec.Cmd, d time.Duration) {
+	cmd.WaitDelay = d
+}
@@ -1,13 +1,22 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
+	"go101.org/golds/code"
 	"go101.org/golds/internal/util"
+	"golang.org/x/tools/go/packages"
 )
 
 func init() {
@@ -148,6 +157,63 @@ func TestPreviousVersion(t *testing.T) {
 	}
 }
 
+func TestSearchMatchScore(t *testing.T) {
+	type testCase struct {
+		name, query string
+		score       int
+	}
+	var testCases = []testCase{
+		{"Buffer", "Buffer", 5},
+		{"Buffer", "buffer", 4},
+		{"BufferSize", "Buffer", 3},
+		{"BufferSize", "buf", 2},
+		{"NewBuffer", "buf", 1},
+		{"Reader", "buf", 0},
+	}
+	for _, tc := range testCases {
+		score := searchMatchScore(tc.name, strings.ToLower(tc.name), tc.query, strings.ToLower(tc.query))
+		if score != tc.score {
+			t.Errorf("wrong search match score (%d) for %s with query %s. Expected: %d", score, tc.name, tc.query, tc.score)
+		}
+	}
+}
+
+func TestSourceFilesDigest(t *testing.T) {
+	dir := t.TempDir()
+	dirs := []string{dir}
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile("a.go", "package a")
+	digest := sourceFilesDigest(dirs)
+
+	writeFile("a.txt", "not a source file")
+	if sourceFilesDigest(dirs) != digest {
+		t.Errorf("digest should not change on non-source file changes")
+	}
+
+	writeFile("b.go", "package a")
+	if newDigest := sourceFilesDigest(dirs); newDigest == digest {
+		t.Errorf("digest should change on new source files")
+	} else {
+		digest = newDigest
+	}
+
+	dirDigest := sourceDirectoryDigests(dirs)[dir]
+	writeFile("go.mod", "module a")
+	if sourceFilesDigest(dirs) == digest {
+		t.Errorf("digest should change on go.mod changes")
+	}
+	if newDirDigest := sourceDirectoryDigests(dirs)[dir]; newDirDigest.goMod == dirDigest.goMod {
+		t.Errorf("go.mod digest should change on go.mod changes")
+	} else if newDirDigest.goFiles != dirDigest.goFiles {
+		t.Errorf(".go files digest should not change on go.mod changes")
+	}
+}
+
 func TestDocsForStandardPackages(t *testing.T) {
 	// ...
 	data, err := ioutil.ReadFile(filepath.Join("..", "testing", "data", "testdata.json.tar.gz"))
@@ -248,3 +314,1037 @@ func TestGenerateDocsOfStandardPackages(t *testing.T) {
 	GenDocs(opts, []string{"std"}, "", true, nil, false, nil)
 	GenTestData([]string{"std"}, "", true, nil)
 }
+
+func TestModuleSourceDirectories(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	collect := func() string {
+		dirs := moduleSourceDirectories(root)
+		for i, dir := range dirs {
+			rel, err := filepath.Rel(root, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dirs[i] = filepath.ToSlash(rel)
+		}
+		return strings.Join(dirs, " ")
+	}
+
+	writeFile("go.mod", "module a")
+	writeFile("a.go", "package a")
+	writeFile("b/b.go", "package b")
+	writeFile("b/c/c.go", "package c")
+	writeFile("d/readme.txt", "not a package")
+	writeFile("testdata/t.go", "package t")
+	writeFile("vendor/x.org/v/v.go", "package v")
+	writeFile(".git/g.go", "package g")
+	writeFile("_old/o.go", "package o")
+	writeFile("nested/go.mod", "module nested")
+	writeFile("nested/n.go", "package n")
+
+	if dirs, want := collect(), ". b b/c"; dirs != want {
+		t.Errorf("source directories: %s, want %s", dirs, want)
+	}
+
+	// A new package directory created after watching starts.
+	watched := moduleSourceDirectories(root)
+	digest := sourceFilesDigest(watched)
+	writeFile("e/e.go", "package e")
+	if dirs, want := collect(), ". b b/c e"; dirs != want {
+		t.Errorf("source directories: %s, want %s", dirs, want)
+	}
+	if sourceFilesDigest(moduleSourceDirectories(root)) == digest {
+		t.Errorf("digest should change on new package directories")
+	}
+}
+
+// analyzeTestModule creates a doc server for a module made up of the specified files.
+func analyzeTestModule(t *testing.T, files map[string]string) *docServer {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("create directory for %s error: %s", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write file %s error: %s", name, err)
+		}
+	}
+
+	// The tests analyzing the std packages might turn the modules feature off
+	// and change the page output options.
+	t.Setenv("GO111MODULE", "on")
+	setPageOutputOptions(PageOutputOptions{GoldsVersion: "v0.0.0", PreferredLang: "en-US", SourceReadingStyle: SourceReadingStyle_rich}, true)
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("get working directory error: %s", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("change working directory error: %s", err)
+	}
+	defer os.Chdir(oldDir)
+
+	analyzer := &code.CodeAnalyzer{}
+	if err := analyzer.ParsePackages(nil, nil, code.ToolchainInfo{}, "./..."); err != nil {
+		t.Fatalf("parse packages error: %s", err)
+	}
+	analyzer.AnalyzePackages(nil)
+	ds := &docServer{analyzer: analyzer, phase: Phase_Analyzed}
+	ds.initSettings("en-US")
+	return ds
+}
+
+// requestTestPage requests a page from a doc server
+// and returns the status code and the page content.
+func requestTestPage(t *testing.T, ds *docServer, url string) (int, string) {
+	// Links are hashed in generation mode.
+	oldGenDocsMode := genDocsMode
+	genDocsMode = false
+	defer func() { genDocsMode = oldGenDocsMode }()
+
+	recorder := httptest.NewRecorder()
+	ds.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+	return recorder.Code, recorder.Body.String()
+}
+
+// callDataAPI calls a data API handler and decodes its JSON result into v.
+// It returns the status code.
+func callDataAPI(t *testing.T, handler http.HandlerFunc, url string, v interface{}) int {
+	// The APIs return HTML pages in generation mode.
+	oldGenDocsMode := genDocsMode
+	genDocsMode = false
+	defer func() { genDocsMode = oldGenDocsMode }()
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+	if err := json.Unmarshal(recorder.Body.Bytes(), v); err != nil {
+		t.Fatalf("%s: decode result error: %s\n%s", url, err, recorder.Body.String())
+	}
+	return recorder.Code
+}
+
+var dataAPITestFiles = map[string]string{
+	"go.mod": "module example.com/shapes\n\ngo 1.18\n",
+	"shapes.go": `package shapes
+
+// Shape is implemented by all shapes.
+type Shape interface {
+	Area() float64
+}
+
+type Named struct {
+	Name string
+}
+
+func (n *Named) Rename(name string) { n.Name = name }
+
+type Square struct {
+	Named
+	Side float64
+}
+
+func (s Square) Area() float64 { return s.Side * s.Side }
+
+// Failure embeds the builtin error type.
+type Failure struct {
+	error
+}
+
+func Total(shapes ...Shape) (t float64) {
+	for _, s := range shapes {
+		t += s.Area()
+	}
+	return
+}
+
+var Unit = Square{Side: 1}
+
+var _ = Total(Unit, &Unit)
+
+var Config struct {
+	Debug struct {
+		Level int
+	}
+}
+
+var _ = Config.Debug.Level
+`,
+}
+
+func TestDataAPIs(t *testing.T) {
+	ds := analyzeTestModule(t, dataAPITestFiles)
+
+	// api:package
+	var pkg APIPackage
+	if code := callDataAPI(t, ds.packageAPI, "/api:package?path=example.com/shapes", &pkg); code != http.StatusOK {
+		t.Fatalf("api:package status: %d", code)
+	}
+	if pkg.Name != "shapes" || pkg.Module != "example.com/shapes" {
+		t.Errorf("api:package: name %s, module %s", pkg.Name, pkg.Module)
+	}
+	types := make(map[string]*APIType)
+	for _, tn := range pkg.Types {
+		types[tn.Name] = tn
+	}
+	square := types["Square"]
+	if square == nil {
+		t.Fatalf("api:package: type Square is not found")
+	}
+	if square.Kind != "struct" || square.Position.Package != "example.com/shapes" || square.Position.File != "shapes.go" {
+		t.Errorf("api:package: Square is a %s at %+v", square.Kind, square.Position)
+	}
+	var selectors []string
+	for _, sel := range append(square.Methods, square.Fields...) {
+		if sel.Path != "" {
+			selectors = append(selectors, sel.Path)
+		} else {
+			selectors = append(selectors, sel.Name)
+		}
+	}
+	if got, want := strings.Join(selectors, " "), "Area Named.Rename Named Named.Name Side"; got != want {
+		t.Errorf("api:package: Square selectors: %s, want %s", got, want)
+	}
+	if len(square.Implements) != 1 || square.Implements[0].Name != "Shape" {
+		t.Errorf("api:package: Square implements %+v", square.Implements)
+	}
+	failure := types["Failure"]
+	if failure == nil || len(failure.Methods) != 1 || failure.Methods[0].Name != "Error" {
+		t.Fatalf("api:package: the methods of Failure are not listed correctly: %+v", failure)
+	}
+	if failure.Methods[0].Position.Package == "" {
+		t.Errorf("api:package: the package of the promoted Error method is unknown")
+	}
+
+	// Selectors without packages are handled.
+	shapesPkg := ds.analyzer.PackageByPath("example.com/shapes")
+	for _, tn := range shapesPkg.AllTypeNames {
+		if tn.Name() != "Square" {
+			continue
+		}
+		for _, sel := range tn.Denoting.AllFields {
+			if sel.Name() != "Side" {
+				continue
+			}
+			noPkgSel := &code.Selector{Field: &code.Field{Name: sel.Name(), Type: sel.Field.Type, AstField: sel.Field.AstField}}
+			if got, want := buildAPISelector(noPkgSel, shapesPkg).Position, buildAPISelector(sel, shapesPkg).Position; got != want {
+				t.Errorf("api:package: the position of a selector without package: %+v, want %+v", got, want)
+			}
+		}
+	}
+
+	var apiErr struct{ Error string }
+	if code := callDataAPI(t, ds.packageAPI, "/api:package?path=example.com/none", &apiErr); code != http.StatusNotFound || apiErr.Error == "" {
+		t.Errorf("api:package for a nonexistent package: %d %q", code, apiErr.Error)
+	}
+
+	// api:references
+	var refs APIReferences
+	if code := callDataAPI(t, ds.referencesAPI, "/api:references?pkg=example.com/shapes&id=Unit", &refs); code != http.StatusOK {
+		t.Fatalf("api:references status: %d", code)
+	}
+	// Like the references pages, the declaring identifier is also listed.
+	var positions []string
+	for _, ref := range refs.References {
+		positions = append(positions, fmt.Sprintf("%s/%s:%d:%d", ref.Package, ref.File, ref.Line, ref.Column))
+	}
+	if got, want := strings.Join(positions, " "), "example.com/shapes/shapes.go:33:5 example.com/shapes/shapes.go:35:15 example.com/shapes/shapes.go:35:22"; refs.Kind != "var" || got != want {
+		t.Errorf("api:references: Unit is a %s with references %s, want %s", refs.Kind, got, want)
+	}
+	if code := callDataAPI(t, ds.referencesAPI, "/api:references?pkg=example.com/shapes&id=Square.Area", &refs); code != http.StatusOK {
+		t.Fatalf("api:references status: %d", code)
+	}
+	if refs.Kind != "method" || refs.Identifier != "Square.Area" {
+		t.Errorf("api:references: Square.Area is a %s named %s", refs.Kind, refs.Identifier)
+	}
+
+	// api:implementations
+	var impls APIImplementations
+	if code := callDataAPI(t, ds.implementationsAPI, "/api:implementations?pkg=example.com/shapes&type=Shape", &impls); code != http.StatusOK {
+		t.Fatalf("api:implementations status: %d", code)
+	}
+	if !impls.IsInterface || len(impls.Methods) != 1 || impls.Methods[0].Method != "Area" {
+		t.Fatalf("api:implementations: unexpected result: %+v", impls)
+	}
+	var receivers []string
+	for _, impl := range impls.Methods[0].Implementations {
+		receivers = append(receivers, impl.Receiver.Name)
+		if impl.Position.Package != "example.com/shapes" || impl.Position.Line != 19 {
+			t.Errorf("api:implementations: unexpected position %+v", impl.Position)
+		}
+	}
+	if got := strings.Join(receivers, " "); got != "Square" {
+		t.Errorf("api:implementations: Shape.Area is implemented by %s", got)
+	}
+}
+
+func TestJSONSnapshot(t *testing.T) {
+	ds := analyzeTestModule(t, dataAPITestFiles)
+
+	var buf bytes.Buffer
+	if err := writeJSONSnapshot(&buf, ds.analyzer); err != nil {
+		t.Fatalf("write snapshot error: %s", err)
+	}
+	var snapshot struct {
+		Stats     code.Stats     `json:"stats"`
+		Modules   []JSONModule   `json:"modules"`
+		Packages  []JSONPackage  `json:"packages"`
+		TypeInfos []JSONTypeInfo `json:"typeInfos"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &snapshot); err != nil {
+		t.Fatalf("decode snapshot error: %s", err)
+	}
+	if int(snapshot.Stats.Packages) != len(snapshot.Packages) || len(snapshot.Packages) != ds.analyzer.NumPackages() {
+		t.Errorf("package counts not match: %d, %d and %d", snapshot.Stats.Packages, len(snapshot.Packages), ds.analyzer.NumPackages())
+	}
+
+	var jp *JSONPackage
+	for i := range snapshot.Packages {
+		if snapshot.Packages[i].Path == "example.com/shapes" {
+			jp = &snapshot.Packages[i]
+		}
+	}
+	if jp == nil {
+		t.Fatalf("package example.com/shapes is not found")
+	}
+
+	// The package info is the same as the one returned by api:package.
+	var apiPkg APIPackage
+	callDataAPI(t, ds.packageAPI, "/api:package?path=example.com/shapes", &apiPkg)
+	got, _ := json.Marshal(jp.APIPackage)
+	want, _ := json.Marshal(&apiPkg)
+	if !bytes.Equal(got, want) {
+		t.Errorf("package info not match api:package:\n%s\n%s", got, want)
+	}
+
+	// The declaring identifiers are not uses.
+	refs := make(map[string]string)
+	for _, ref := range jp.References {
+		var uses []string
+		for _, use := range ref.Uses {
+			uses = append(uses, fmt.Sprintf("%d:%d", use.Line, use.Column))
+		}
+		refs[ref.Kind+" "+ref.Identifier] = strings.Join(uses, " ")
+	}
+	for ref, want := range map[string]string{
+		"var Unit":                 "35:15 35:22",
+		"type Square":              "19:9 33:12",
+		"type Named":               "12:10 15:2", // including the embedded field
+		"field Named.Name":         "12:41",
+		"method Shape.Area":        "28:10",
+		"var Config":               "43:9",
+		"field Config.Debug":       "43:16",
+		"field Config.Debug.Level": "43:22",
+		"func Total":               "35:9",
+		"field Square.Side":        "19:43 19:52 33:19",
+		"method Square.Area":       "",
+		"method Named.Rename":      "",
+		"type Failure":             "",
+	} {
+		if refs[ref] != want {
+			t.Errorf("uses of %s: %q, want %q", ref, refs[ref], want)
+		}
+	}
+
+	// Types are listed by their indexes, including unnamed types.
+	var square, shape, unnamedStruct *JSONTypeInfo
+	for i := range snapshot.TypeInfos {
+		ti := &snapshot.TypeInfos[i]
+		if ti.Index != i {
+			t.Fatalf("type %s is at %d, but its index is %d", ti.Type, i, ti.Index)
+		}
+		switch ti.Type {
+		case "example.com/shapes.Square":
+			square = ti
+		case "example.com/shapes.Shape":
+			shape = ti
+		case "struct{Level int}":
+			unnamedStruct = ti
+		}
+	}
+	if square == nil || shape == nil || unnamedStruct == nil {
+		t.Fatalf("some types are not found: %v, %v, %v", square, shape, unnamedStruct)
+	}
+	if square.Package != "example.com/shapes" || square.Name != "Square" || square.Kind != "struct" {
+		t.Errorf("unexpected type info for Square: %+v", square)
+	}
+	var fields []string
+	for _, sel := range square.Fields {
+		fields = append(fields, strings.Join(append(sel.EmbeddingChain, sel.Name), ".")+" "+snapshot.TypeInfos[sel.Type].Type)
+	}
+	if got, want := strings.Join(fields, ", "), "Named example.com/shapes.Named, Named.Name string, Side float64"; got != want {
+		t.Errorf("fields of Square: %s, want %s", got, want)
+	}
+	var implemented bool
+	for _, impl := range square.Implements {
+		if impl.Interface == shape.Index && impl.Impler == square.Index {
+			implemented = true
+		}
+	}
+	var implementedBy bool
+	for _, index := range shape.ImplementedBys {
+		if index == square.Index {
+			implementedBy = true
+		}
+	}
+	if !implemented || !implementedBy {
+		t.Errorf("Square implements Shape: %v, %v", implemented, implementedBy)
+	}
+	if len(unnamedStruct.Fields) != 1 || unnamedStruct.Fields[0].Name != "Level" || unnamedStruct.Kind != "struct" {
+		t.Errorf("unexpected type info for struct{Level int}: %+v", unnamedStruct)
+	}
+}
+
+func TestModulePage(t *testing.T) {
+	ds := analyzeTestModule(t, map[string]string{
+		"go.mod":            "module example.com/app\n\ngo 1.18\n\nrequire example.com/dep v0.1.0\n\nreplace example.com/dep => ./dep\n",
+		"app.go":            "package app\n\nimport _ \"example.com/dep\"\n",
+		"util/util.go":      "package util\n",
+		"util/strs/strs.go": "package strs\n",
+		"dep/go.mod":        "module example.com/dep\n\ngo 1.16\n",
+		"dep/dep.go":        "package dep\n",
+	})
+
+	var assertPage = func(url string, contents ...string) {
+		code, page := requestTestPage(t, ds, url)
+		if code != http.StatusOK {
+			t.Fatalf("%s: status %d", url, code)
+		}
+		for _, content := range contents {
+			if !strings.Contains(page, content) {
+				t.Errorf("%s: %q is not found in\n%s", url, content, page)
+			}
+		}
+	}
+
+	assertPage("/mod:example.com/app",
+		"module <b>example.com/app</b>",
+		"Go Version</span>\n\t1.18\n",
+		`<span class="title">Packages (3)</span>
+	<a href="/pkg:example.com/app">example.com/app</a>
+	  <a href="/pkg:example.com/app/util">util</a>
+	    <a href="/pkg:example.com/app/util/strs">strs</a>
+`,
+		`<span class="title">Requires (1)</span>
+	<a href="/mod:example.com/dep">example.com/dep</a> v0.1.0
+`,
+		`data="/svg:dsm/example.com/app"`,
+	)
+	assertPage("/mod:example.com/dep",
+		"module <b>example.com/dep</b>",
+		"Version</span>\n\tv0.1.0\n",
+		"Replaced By</span>\n\t./dep\n\t",
+		`<span class="title">Packages (1)</span>
+	<a href="/pkg:example.com/dep">example.com/dep</a>
+`,
+		`<span class="title">Required By (1)</span>
+	<a href="/mod:example.com/app">example.com/app</a>
+`,
+	)
+	assertPage("/pkg:example.com/app/util/strs", `<a href="/mod:example.com/app">example.com/app</a>`)
+
+	if code, _ := requestTestPage(t, ds, "/mod:example.com/none"); code != http.StatusNotFound {
+		t.Errorf("status for a nonexistent module: %d", code)
+	}
+}
+
+func TestMarkdownAnchors(t *testing.T) {
+	files := map[string]string{
+		"draw/draw.go": `package draw
+
+import "example.com/shapes"
+
+// Canvas draws shapes. See [shapes.Square.Area] and [Render].
+type Canvas struct {
+	Items []shapes.Shape
+}
+
+// Render renders a [Canvas] with [shapes.Unit].
+func Render(c *Canvas) shapes.Square { return shapes.Unit }
+`,
+	}
+	for name, content := range dataAPITestFiles {
+		files[name] = content
+	}
+	ds := analyzeTestModule(t, files)
+
+	var genMarkdown = func(pkgPath string) string {
+		var buf bytes.Buffer
+		w := bufio.NewWriter(&buf)
+		ds.writePackageMarkdown(w, buildPackageDetailsData(ds.analyzer, pkgPath, collectUnexporteds))
+		w.Flush()
+		return buf.String()
+	}
+
+	var assertMarkdown = func(pkgPath string, contents ...string) {
+		md := genMarkdown(pkgPath)
+		for _, content := range contents {
+			if !strings.Contains(md, content) {
+				t.Errorf("%s: %q is not found in\n%s", pkgPath, content, md)
+			}
+		}
+	}
+
+	assertMarkdown("example.com/shapes",
+		"### <a id=\"name-Shape\"></a>Shape\n",
+		"### <a id=\"name-Square\"></a>Square\n",
+		"### <a id=\"name-Total\"></a>Total\n",
+		"### <a id=\"name-Unit\"></a>Unit\n",
+		"- [Square](#name-Square)\n",
+		"- [draw.Render](shapes/draw.md#name-Render)\n",
+	)
+	assertMarkdown("example.com/shapes/draw",
+		"### <a id=\"name-Canvas\"></a>Canvas\n",
+		"### <a id=\"name-Render\"></a>Render\n",
+		"- [example.com/shapes](../shapes.md)\n",
+		"See [shapes.Square.Area](../shapes.md#name-Square) and [Render](#name-Render).",
+		"- [Render](#name-Render)\n",
+		"[Canvas](#name-Canvas)",
+		"[shapes.Unit](../shapes.md#name-Unit)",
+	)
+}
+
+func TestAPIDiffClassification(t *testing.T) {
+	oldDS := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/lib\n\ngo 1.18\n",
+		"lib.go": `package lib
+
+type Reader interface {
+	Read() string
+}
+
+type Config struct {
+	Name    string
+	Timeout int
+}
+
+func (c Config) Read() string { return c.Name }
+
+type Gone struct{}
+
+func Open(name string) *Config { return &Config{Name: name} }
+
+var Default Config
+
+const Max = 10
+`,
+		"old/old.go": "package old\n\nfunc F() {}\n",
+	})
+	newDS := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/lib\n\ngo 1.18\n",
+		"lib.go": `package lib
+
+type Reader interface {
+	Read() string
+	Close() error
+}
+
+type Config struct {
+	Name    string
+	Timeout int64
+	Debug   bool
+}
+
+func (c *Config) Read() string { return c.Name }
+
+func (c Config) String() string { return c.Name }
+
+func Open(name string) *Config { return &Config{Name: name} }
+
+func Default() Config { return Config{} }
+
+const Max = 20
+
+type Option func(*Config)
+`,
+		"new/new.go": "package new\n",
+	})
+
+	changes := diffAPISnapshots(oldDS.buildAPISnapshot(""), newDS.buildAPISnapshot(""))
+	got := make([]string, len(changes))
+	for i, c := range changes {
+		got[i] = fmt.Sprintf("%s %s %s %s breaking=%v", c.Package, c.Name, c.Kind, c.Change, c.Breaking)
+	}
+	want := []string{
+		"example.com/lib Config implements removed breaking=true",
+		"example.com/lib Config.Debug field added breaking=false",
+		"example.com/lib Config.Read method changed breaking=true",
+		"example.com/lib Config.String method added breaking=false",
+		"example.com/lib Config.Timeout field changed breaking=true",
+		"example.com/lib Default var changed breaking=true",
+		"example.com/lib Gone type removed breaking=true",
+		"example.com/lib Max const changed breaking=true",
+		"example.com/lib Option type added breaking=false",
+		"example.com/lib Reader.Close method added breaking=true",
+		"example.com/lib/new  package added breaking=false",
+		"example.com/lib/old  package removed breaking=true",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("API changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCallHierarchy(t *testing.T) {
+	ds := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/calls\n\ngo 1.18\n",
+		"calls.go": `package calls
+
+type Shape interface {
+	Area() float64
+}
+
+type Square struct{ Side float64 }
+
+func (s Square) Area() float64 { return s.Side * s.Side }
+
+type Circle struct{ Radius float64 }
+
+func (c *Circle) Area() float64 { return scale(c.Radius * c.Radius) }
+
+func scale(v float64) float64 { return v * 3.14 }
+
+func Total(shapes ...Shape) (t float64) {
+	for _, s := range shapes {
+		t += s.Area()
+	}
+	return round(t)
+}
+
+func round(v float64) float64 { return float64(int64(v)) }
+
+func Report() float64 { return Total(Square{1}, &Circle{1}) }
+
+func Main() { Report() }
+`,
+	})
+
+	result, err := ds.buildCallHierarchyData("example.com/calls", "Total")
+	if err != nil {
+		t.Fatalf("build call hierarchy data error: %s", err)
+	}
+	var callees []string
+	for _, c := range result.Callees {
+		callee := c.Func.Identifier()
+		if c.Via != nil {
+			callee += " via " + c.Via.Identifier()
+		}
+		callees = append(callees, fmt.Sprintf("%s (%d calls)", callee, len(c.Sites)))
+	}
+	wantCallees := []string{
+		"Shape.Area (1 calls)",
+		"Circle.Area via Shape.Area (0 calls)",
+		"Square.Area via Shape.Area (0 calls)",
+		"round (1 calls)",
+	}
+	if strings.Join(callees, "\n") != strings.Join(wantCallees, "\n") {
+		t.Errorf("callees:\n%s\nwant:\n%s", strings.Join(callees, "\n"), strings.Join(wantCallees, "\n"))
+	}
+	if len(result.Callers) != 1 || len(result.Callers[0].Callers) != 1 || result.Callers[0].Callers[0].Func.Identifier() != "Report" {
+		t.Errorf("callers of Total should be Report only")
+	}
+
+	// The callers of a concrete method include the dynamic calls
+	// through the interface methods it implements.
+	result, err = ds.buildCallHierarchyData("example.com/calls", "Circle", "Area")
+	if err != nil {
+		t.Fatalf("build call hierarchy data error: %s", err)
+	}
+	if len(result.Callers) != 1 || len(result.Callers[0].Callers) != 1 {
+		t.Fatalf("Circle.Area should have one caller")
+	}
+	if caller := result.Callers[0].Callers[0]; caller.Func.Identifier() != "Total" || caller.Via == nil || caller.Via.Identifier() != "Shape.Area" {
+		t.Errorf("Circle.Area should be called by Total via Shape.Area")
+	}
+
+	// Deeper levels are listed in nested folding blocks.
+	code, page := requestTestPage(t, ds, "/cal:example.com/calls..Total")
+	if code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	for _, content := range []string{
+		`<label for="call-1-fold-calls"><a href="/cal:example.com/calls..Report">Report</a>`,
+		"\n\t\t\t<input type='checkbox' class=\"fold\" id=\"call-2-fold-calls\"><label for=\"call-2-fold-calls\"><a href=\"/cal:example.com/calls..Main\">Main</a>",
+		`<label for="call-4-fold-calls"><a href="/cal:example.com/calls..Circle.Area">Circle.Area</a>`,
+		"\n\t\t<input type='checkbox' class=\"fold\" id=\"call-5-fold-calls\"><label for=\"call-5-fold-calls\"><a href=\"/cal:example.com/calls..scale\">scale</a>",
+	} {
+		if !strings.Contains(page, content) {
+			t.Errorf("%q is not found in\n%s", content, page)
+		}
+	}
+}
+
+func TestConstraintSatisfactions(t *testing.T) {
+	ds := analyzeTestModule(t, map[string]string{
+		"go.mod": "module example.com/cons\n\ngo 1.18\n",
+		"cons.go": `package cons
+
+type Number interface {
+	~int | ~float64
+}
+
+type Named interface {
+	~string
+	Name() string
+}
+
+// Setter is a generic constraint with methods.
+type Setter[P any] interface {
+	*Celsius
+	Set(string) P
+}
+
+type MyInt int
+
+type myInt int
+
+type Celsius float64
+
+func (c *Celsius) Set(string) *Celsius { return c }
+
+type Label string
+
+func (Label) Name() string { return "" }
+
+type Text string
+`,
+	})
+
+	details := buildPackageDetailsData(ds.analyzer, "example.com/cons", collectUnexporteds)
+	var satisfiedBys = func(name string) string {
+		for _, rwp := range details.TypeNames {
+			if td := rwp.Type; td.TypeName.Name() == name {
+				var bys []string
+				for _, by := range td.SatisfiedBys {
+					// Types in the std packages (including builtin) are also listed.
+					if by.BaseType.TypeName.Package().Path == "example.com/cons" {
+						bys = append(bys, by.BaseType.TypeName.Name())
+					}
+				}
+				return strings.Join(bys, " ")
+			}
+		}
+		t.Fatalf("type %s is not found", name)
+		return ""
+	}
+
+	// Unexported types are not listed.
+	var expected = map[string]string{
+		"Number": "Celsius MyInt",
+		"Named":  "Label",
+		"Setter": "",
+		"MyInt":  "",
+	}
+	for name, want := range expected {
+		if got := satisfiedBys(name); got != want {
+			t.Errorf("types satisfying %s: %q, want %q", name, got, want)
+		}
+	}
+
+	code, page := requestTestPage(t, ds, "/imp:example.com/cons.Number")
+	if code != http.StatusOK {
+		t.Fatalf("/imp:example.com/cons.Number: status %d", code)
+	}
+	for _, content := range []string{"Satisfied By", ">MyInt</a>", ">Celsius</a>"} {
+		if !strings.Contains(page, content) {
+			t.Errorf("%q is not found in\n%s", content, page)
+		}
+	}
+	if strings.Contains(page, "myInt") {
+		t.Errorf("unexported type myInt is listed in\n%s", page)
+	}
+
+	// Source pages link the methods of constraints to their imp: pages.
+	if code, page := requestTestPage(t, ds, "/imp:example.com/cons.Setter"); code != http.StatusOK {
+		t.Errorf("/imp:example.com/cons.Setter: status %d\n%s", code, page)
+	}
+}
+
+func TestDependencyGraphs(t *testing.T) {
+	ds := analyzeTestModule(t, map[string]string{
+		"go.mod":     "module example.com/app\n\ngo 1.18\n\nrequire example.com/lib v0.1.0\n\nreplace example.com/lib => ./lib\n",
+		"app.go":     "package app\n\nimport (\n\t_ \"example.com/app/b\"\n\t_ \"example.com/app/c\"\n\t_ \"example.com/app/d\"\n)\n",
+		"b/b.go":     "package b\n\nimport _ \"example.com/app/c\"\n",
+		"c/c.go":     "package c\n\nimport _ \"example.com/lib\"\n",
+		"d/d.go":     "package d\n\nimport _ \"example.com/lib\"\n",
+		"lib/go.mod": "module example.com/lib\n\ngo 1.16\n",
+		"lib/lib.go": "package lib\n",
+	})
+
+	pathInfo := createDependencyGraphPathInfo(dependencyGraphKind_Package, dependencyGraphFormat_SVG, "example.com/app")
+	graph := ds.buildPackageDependencyGraph(pathInfo, "example.com/app", dependencyGraphOptions{})
+	if graph == nil {
+		t.Fatal("dependency graph of example.com/app is not built")
+	}
+
+	// The layers are ordered by package heights, from the highest.
+	var layers []string
+	for _, layer := range graph.layers() {
+		var labels []string
+		for _, n := range layer {
+			labels = append(labels, graph.Nodes[n].Label+"("+graph.Nodes[n].Class+")")
+		}
+		layers = append(layers, strings.Join(labels, " "))
+	}
+	if got, want := strings.Join(layers, "\n"), "example.com/app(root)\nexample.com/app/b(local)\nexample.com/app/c(local) example.com/app/d(local)\nexample.com/lib(other)"; got != want {
+		t.Errorf("layers:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got, want := string(graph.DOT()), `digraph "example.com/app" {
+	node [shape=box, fontname="Courier"];
+	"example.com/app" [module="example.com/app"];
+	"example.com/app/b" [module="example.com/app"];
+	"example.com/app/c" [module="example.com/app"];
+	"example.com/app/d" [module="example.com/app"];
+	"example.com/lib" [module="example.com/lib"];
+	{rank=same; "example.com/app/c"; "example.com/app/d";}
+	"example.com/app" -> "example.com/app/b";
+	"example.com/app" -> "example.com/app/c";
+	"example.com/app" -> "example.com/app/d";
+	"example.com/app/b" -> "example.com/app/c";
+	"example.com/app/c" -> "example.com/lib";
+	"example.com/app/d" -> "example.com/lib";
+}
+`; got != want {
+		t.Errorf("DOT:\n%s\nwant:\n%s", got, want)
+	}
+
+	graphML := string(graph.GraphML())
+	for _, content := range []string{
+		"\t<graph id=\"example.com/app\" edgedefault=\"directed\">\n",
+		"\t\t<node id=\"n4\"><data key=\"label\">example.com/lib</data><data key=\"module\">example.com/lib</data><data key=\"height\">1</data></node>\n",
+		"\t\t<edge source=\"n0\" target=\"n1\"/>\n\t\t<edge source=\"n0\" target=\"n2\"/>\n\t\t<edge source=\"n0\" target=\"n3\"/>\n\t\t<edge source=\"n1\" target=\"n2\"/>\n\t\t<edge source=\"n2\" target=\"n4\"/>\n\t\t<edge source=\"n3\" target=\"n4\"/>\n",
+	} {
+		if !strings.Contains(graphML, content) {
+			t.Errorf("%q is not found in\n%s", content, graphML)
+		}
+	}
+
+	// Depth and module filtering.
+	graph = ds.buildPackageDependencyGraph(pathInfo, "example.com/app", dependencyGraphOptions{Depth: 1, Module: "example.com/app"})
+	if got, want := string(graph.DOT()), `digraph "example.com/app" {
+	node [shape=box, fontname="Courier"];
+	"example.com/app" [module="example.com/app"];
+	"example.com/app/b" [module="example.com/app"];
+	"example.com/app/c" [module="example.com/app"];
+	"example.com/app/d" [module="example.com/app"];
+	{rank=same; "example.com/app/c"; "example.com/app/d";}
+	"example.com/app" -> "example.com/app/b";
+	"example.com/app" -> "example.com/app/c";
+	"example.com/app" -> "example.com/app/d";
+}
+`; got != want {
+		t.Errorf("DOT (depth 1):\n%s\nwant:\n%s", got, want)
+	}
+
+	pathInfo = createDependencyGraphPathInfo(dependencyGraphKind_Module, dependencyGraphFormat_SVG, "example.com/app")
+	graph = ds.buildModuleDependencyGraph(pathInfo, "example.com/app", dependencyGraphOptions{})
+	if got, want := string(graph.DOT()), `digraph "example.com/app" {
+	node [shape=box, fontname="Courier"];
+	"example.com/app" [module="example.com/app"];
+	"example.com/lib" [module="example.com/lib"];
+	"example.com/app" -> "example.com/lib";
+}
+`; got != want {
+		t.Errorf("module DOT:\n%s\nwant:\n%s", got, want)
+	}
+
+	code, svg := requestTestPage(t, ds, "/svg:deps/example.com/app")
+	if code != http.StatusOK {
+		t.Fatalf("/svg:deps/example.com/app: status %d", code)
+	}
+	for _, content := range []string{
+		`<path class="from-0 to-1" `,
+		`<a class="root" href="/dep:example.com/app" `,
+		`<a class="other" href="/dep:example.com/lib" `,
+	} {
+		if !strings.Contains(svg, content) {
+			t.Errorf("%q is not found in\n%s", content, svg)
+		}
+	}
+}
+
+func TestImportChains(t *testing.T) {
+	ds := analyzeTestModule(t, map[string]string{
+		"go.mod":               "module example.com/why\n\ngo 1.18\n",
+		"cmd/tool/main.go":     "package main\n\nimport _ \"example.com/why/x\"\n\nfunc main() {}\n",
+		"cmd/other/main.go":    "package main\n\nimport (\n\t_ \"example.com/why/x\"\n\t_ \"example.com/why/y\"\n)\n\nfunc main() {}\n",
+		"x/x.go":               "package x\n\nimport _ \"example.com/why/z\"\n",
+		"y/y.go":               "package y\n\nimport _ \"example.com/why/z\"\n",
+		"z/z.go":               "package z\n\nimport _ \"example.com/why/t\"\n",
+		"t/t.go":               "package t\n",
+		"lib/lib.go":           "package lib\n\nimport _ \"example.com/why/lib/only\"\n",
+		"lib/only/only.go":     "package only\n",
+		"lib/unused/unused.go": "package unused\n",
+	})
+
+	var chains = func(pkgPath string) string {
+		var lines []string
+		for _, chain := range ds.buildImportChains(ds.analyzer.PackageByPath(pkgPath)) {
+			paths := make([]string, len(chain))
+			for i, pkg := range chain {
+				paths[i] = strings.TrimPrefix(pkg.Path, "example.com/why/")
+			}
+			lines = append(lines, strings.Join(paths, " -> "))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	// The shortest chains from main packages, which go through
+	// the packages with smaller import paths for equal lengths.
+	var expected = map[string]string{
+		"example.com/why/t":          "cmd/other -> x -> z -> t\ncmd/tool -> x -> z -> t",
+		"example.com/why/y":          "cmd/other -> y",
+		"example.com/why/lib/only":   "lib -> lib/only", // no main packages import it
+		"example.com/why/lib/unused": "",
+		"example.com/why/cmd/tool":   "",
+	}
+	for pkgPath, want := range expected {
+		if got := chains(pkgPath); got != want {
+			t.Errorf("import chains of %s:\n%s\nwant:\n%s", pkgPath, got, want)
+		}
+	}
+
+	code, page := requestTestPage(t, ds, "/dep:example.com/why/z")
+	if code != http.StatusOK {
+		t.Fatalf("/dep:example.com/why/z: status %d", code)
+	}
+	want := `<span class="title" id="import-chains">Why Imported (2)</span>
+	<a href="/dep:example.com/why/cmd/other">example.com/why/cmd/other</a> → <a href="/dep:example.com/why/x">example.com/why/x</a> → example.com/why/z
+	<a href="/dep:example.com/why/cmd/tool">example.com/why/cmd/tool</a> → <a href="/dep:example.com/why/x">example.com/why/x</a> → example.com/why/z
+`
+	if !strings.Contains(page, want) {
+		t.Errorf("%q is not found in\n%s", want, page)
+	}
+}
+
+var couplingTestFiles = map[string]string{
+	"go.mod":                "module example.com/layer\n\ngo 1.18\n",
+	"cmd/app/main.go":       "package main\n\nimport (\n\t_ \"example.com/layer\"\n\t_ \"example.com/layer/codec/json\"\n)\n\nfunc main() {}\n",
+	"layer.go":              "package layer\n\nimport (\n\t_ \"example.com/layer/codec\"\n\t_ \"example.com/layer/internal/impl\"\n)\n",
+	"codec/codec.go":        "package codec\n\nimport _ \"example.com/layer/internal/impl\"\n",
+	"codec/json/json.go":    "package json\n\nimport _ \"example.com/layer/codec\"\n",
+	"internal/impl/impl.go": "package impl\n",
+}
+
+func TestPackageCoupling(t *testing.T) {
+	ds := analyzeTestModule(t, couplingTestFiles)
+
+	var result APICoupling
+	if code := callDataAPI(t, ds.couplingAPI, "/api:coupling?module=example.com/layer", &result); code != http.StatusOK {
+		t.Fatalf("api:coupling: status %d", code)
+	}
+	var lines []string
+	for _, pc := range result.Packages {
+		lines = append(lines, fmt.Sprintf("%s %d %d %.2f %d %d", pc.Path, pc.Afferent, pc.Efferent, pc.Instability, pc.DepHeight, pc.DepDepth))
+	}
+	// path, Ca, Ce, I, height and depth
+	if got, want := strings.Join(lines, "\n"), `example.com/layer 1 2 0.67 3 2
+example.com/layer/cmd/app 0 2 1.00 4 0
+example.com/layer/codec 2 1 0.33 2 3
+example.com/layer/codec/json 1 1 0.50 3 2
+example.com/layer/internal/impl 2 0 0.00 1 3`; got != want {
+		t.Errorf("coupling metrics:\n%s\nwant:\n%s", got, want)
+	}
+
+	var apiErr struct{ Error string }
+	if code := callDataAPI(t, ds.couplingAPI, "/api:coupling?module=example.com/none", &apiErr); code != http.StatusNotFound || apiErr.Error == "" {
+		t.Errorf("api:coupling for a nonexistent module: %d %q", code, apiErr.Error)
+	}
+
+	code, page := requestTestPage(t, ds, "/coupling")
+	if code != http.StatusOK {
+		t.Fatalf("/coupling: status %d", code)
+	}
+	want := "\t    2     1  0.33       2      3  <a href=\"/dep:example.com/layer/codec\">example.com/layer/codec</a>\n"
+	if !strings.Contains(page, want) {
+		t.Errorf("%q is not found in\n%s", want, page)
+	}
+}
+
+func TestModuleDSM(t *testing.T) {
+	ds := analyzeTestModule(t, couplingTestFiles)
+
+	var describe = func(dsm *ModuleDSM) string {
+		var lines []string
+		for _, cell := range dsm.Cells {
+			line := dsm.Packages[cell.From].Path + " -> " + dsm.Packages[cell.To].Path
+			if cell.Kind != "" {
+				line += " (" + cell.Kind + ")"
+			}
+			lines = append(lines, line)
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	// The packages are ordered by heights, so all imports are above
+	// the diagonal. The package in the parent directory importing
+	// a non-internal package in a sub-directory breaks the layer order.
+	dsm := ds.buildModuleDSM(ds.analyzer.ModuleByPath("example.com/layer"))
+	var paths []string
+	for _, pkg := range dsm.Packages {
+		paths = append(paths, pkg.Path)
+	}
+	if got, want := strings.Join(paths, " "), "example.com/layer/cmd/app example.com/layer example.com/layer/codec/json example.com/layer/codec example.com/layer/internal/impl"; got != want {
+		t.Errorf("DSM packages:\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := describe(dsm), `example.com/layer/cmd/app -> example.com/layer
+example.com/layer/cmd/app -> example.com/layer/codec/json
+example.com/layer -> example.com/layer/codec (violation)
+example.com/layer -> example.com/layer/internal/impl
+example.com/layer/codec/json -> example.com/layer/codec
+example.com/layer/codec -> example.com/layer/internal/impl`; got != want {
+		t.Errorf("DSM imports:\n%s\nwant:\n%s", got, want)
+	}
+
+	svg := string(dsm.SVG(createDependencyGraphPathInfo(dependencyGraphKind_DSM, dependencyGraphFormat_SVG, "example.com/layer")))
+	want := `<rect class="violation" x="`
+	if !strings.Contains(svg, want) {
+		t.Errorf("%q is not found in\n%s", want, svg)
+	}
+
+	var result APICoupling
+	if code := callDataAPI(t, ds.couplingAPI, "/api:coupling?module=example.com/layer", &result); code != http.StatusOK {
+		t.Fatalf("api:coupling: status %d", code)
+	}
+	if len(result.Modules) != 1 || len(result.Modules[0].Imports) != 6 || result.Modules[0].Imports[2] != (APIDSMCell{From: 1, To: 3, Kind: "violation"}) {
+		t.Errorf("api:coupling DSM: %+v", result.Modules)
+	}
+
+	// Import cycles are rejected by the loader, so the cycle is built by hand.
+	a := &code.Package{Path: "example.com/c/a", PPkg: &packages.Package{}}
+	b := &code.Package{Path: "example.com/c/b", PPkg: &packages.Package{}}
+	c := &code.Package{Path: "example.com/c/b/c", PPkg: &packages.Package{}}
+	a.Deps = []*code.Package{b}
+	b.Deps = []*code.Package{a, c}
+	dsm = ds.buildModuleDSM(&code.Module{Path: "example.com/c", Pkgs: []*code.Package{a, b, c}})
+	if got, want := describe(dsm), `example.com/c/a -> example.com/c/b (cycle)
+example.com/c/b -> example.com/c/a (cycle)
+example.com/c/b -> example.com/c/b/c (violation)`; got != want {
+		t.Errorf("DSM imports:\n%s\nwant:\n%s", got, want)
+	}
+
+	for _, c := range []struct {
+		from, to  string
+		violation bool
+	}{
+		{"example.com/x", "example.com/x/y", true},
+		{"example.com/x", "example.com/x/y/z", true},
+		{"example.com/x", "example.com/x/internal/y", false},
+		{"example.com/x", "example.com/x/y/internal", false},
+		{"example.com/x", "example.com/xy", false},
+		{"example.com/x/y", "example.com/x", false},
+		{"example.com/x/y", "example.com/x/z", false},
+	} {
+		if got := isLayeringViolation(c.from, c.to); got != c.violation {
+			t.Errorf("isLayeringViolation(%q, %q) = %v", c.from, c.to, got)
+		}
+	}
+}
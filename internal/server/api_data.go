@@ -0,0 +1,557 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+// The data APIs return the analysis results shown in the package details,
+// package dependencies, identifier references and method implementation
+// pages as JSON, so that other tools don't need to scrape HTML pages:
+//
+//	api:package?path=PkgPath
+//	api:dependencies?path=PkgPath
+//	api:references?pkg=PkgPath&id=Identifier    (id might be Type.Selector)
+//	api:implementations?pkg=PkgPath&type=TypeName
+//	api:coupling[?module=ModulePath]            ("std" for the std module)
+//
+// On failures, an {"error": "..."} object is returned with a non-200 status.
+// The field names of the following types are stable; new fields might
+// be added in later versions.
+
+// APIPosition is a position in a source file.
+type APIPosition struct {
+	Package string `json:"package"` // import path
+	File    string `json:"file"`    // filename without directory
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+// APIPackage is the result of api:package.
+type APIPackage struct {
+	Path        string `json:"path"`
+	Name        string `json:"name"`
+	Module      string `json:"module,omitempty"`
+	IsStandard  bool   `json:"isStandard"`
+	NumDeps     int    `json:"numDeps"`
+	NumDepedBys int    `json:"numDepedBys"`
+
+	Files     []APIFile   `json:"files"`
+	Types     []*APIType  `json:"types"`
+	Functions []*APIValue `json:"functions"`
+	Variables []*APIValue `json:"variables"`
+	Constants []*APIValue `json:"constants"`
+}
+
+// APIFile is a source file of a package.
+type APIFile struct {
+	Name string `json:"name"`
+	Doc  string `json:"doc,omitempty"`
+}
+
+// APIValue is a function, variable or constant.
+// For a method, Name is in the form TypeName.MethodName.
+type APIValue struct {
+	Package  string      `json:"package"`
+	Name     string      `json:"name"`
+	Type     string      `json:"type"` // qualified with full package paths
+	Exported bool        `json:"exported"`
+	Doc      string      `json:"doc,omitempty"`
+	Position APIPosition `json:"position"`
+}
+
+// APIType is a type name declared in a package.
+type APIType struct {
+	Name       string      `json:"name"`
+	Kind       string      `json:"kind"`
+	IsAlias    bool        `json:"isAlias"`
+	Exported   bool        `json:"exported"`
+	Doc        string      `json:"doc,omitempty"`
+	Position   APIPosition `json:"position"`
+	Popularity int         `json:"popularity"`
+
+	Fields         []*APISelector `json:"fields,omitempty"`
+	Methods        []*APISelector `json:"methods,omitempty"`
+	Implements     []*APITypeRef  `json:"implements,omitempty"`
+	ImplementedBys []*APITypeRef  `json:"implementedBys,omitempty"`
+	Values         []*APIValue    `json:"values,omitempty"`
+	AsInputsOf     []*APIValue    `json:"asInputsOf,omitempty"`
+	AsOutputsOf    []*APIValue    `json:"asOutputsOf,omitempty"`
+}
+
+// APISelector is a field or method of a type.
+type APISelector struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Path     string      `json:"path,omitempty"` // the embedding path, for promoted selectors only
+	Position APIPosition `json:"position"`
+}
+
+// APITypeRef references a (maybe instantiated) named type.
+type APITypeRef struct {
+	Package   string `json:"package"`
+	Name      string `json:"name"` // including type arguments for instantiated types
+	IsPointer bool   `json:"isPointer"`
+}
+
+// APIDependencies is the result of api:dependencies.
+type APIDependencies struct {
+	Path        string   `json:"path"`
+	Name        string   `json:"name"`
+	Imports     []string `json:"imports"`
+	ImportedBys []string `json:"importedBys"`
+}
+
+// APICoupling is the result of api:coupling.
+type APICoupling struct {
+	Packages []APIPackageCoupling `json:"packages"`
+	Modules  []APIModuleDSM       `json:"modules"`
+}
+
+// APIPackageCoupling holds the coupling metrics of a package.
+type APIPackageCoupling struct {
+	Path        string  `json:"path"`
+	Module      string  `json:"module,omitempty"`
+	Afferent    int     `json:"afferent"`
+	Efferent    int     `json:"efferent"`
+	Instability float64 `json:"instability"`
+	DepHeight   int32   `json:"depHeight"`
+	DepDepth    int32   `json:"depDepth"`
+}
+
+// APIModuleDSM is the dependency structure matrix of a module.
+// Imports reference the packages by their indexes in Packages.
+type APIModuleDSM struct {
+	Module   string       `json:"module"`
+	Packages []string     `json:"packages"`
+	Imports  []APIDSMCell `json:"imports"`
+}
+
+// APIDSMCell is an import between two packages in a module.
+type APIDSMCell struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Kind string `json:"kind,omitempty"` // "violation" or "cycle"
+}
+
+// APIReferences is the result of api:references.
+type APIReferences struct {
+	Package    string        `json:"package"`
+	Identifier string        `json:"identifier"`
+	Kind       string        `json:"kind"` // type, func, var, const, field or method
+	References []APIPosition `json:"references"`
+}
+
+// APIImplementations is the result of api:implementations.
+type APIImplementations struct {
+	Package     string                     `json:"package"`
+	Type        string                     `json:"type"`
+	IsInterface bool                       `json:"isInterface"`
+	Methods     []APIMethodImplementations `json:"methods"`
+}
+
+// APIMethodImplementations lists the methods implementing an interface method,
+// or the interface methods implemented by a concrete method.
+type APIMethodImplementations struct {
+	Method          string         `json:"method"`
+	Position        APIPosition    `json:"position"`
+	Implementations []APIMethodRef `json:"implementations"`
+}
+
+// APIMethodRef references a method of a type.
+type APIMethodRef struct {
+	Receiver  APITypeRef  `json:"receiver"`
+	Method    string      `json:"method"`
+	Explicit  bool        `json:"explicit"`
+	Interface bool        `json:"interface"` // whether or not the receiver is an interface type
+	Position  APIPosition `json:"position"`
+}
+
+// api:package?path=xxx
+func (ds *docServer) packageAPI(w http.ResponseWriter, r *http.Request) {
+	ds.serveDataAPI(w, r, func() (interface{}, error) {
+		pkgPath := r.FormValue("path")
+		details := buildPackageDetailsData(ds.analyzer, pkgPath, collectUnexporteds)
+		if details == nil {
+			return nil, fmt.Errorf("package (%s) not found", pkgPath)
+		}
+		return buildAPIPackage(details), nil
+	})
+}
+
+// api:dependencies?path=xxx
+func (ds *docServer) dependenciesAPI(w http.ResponseWriter, r *http.Request) {
+	ds.serveDataAPI(w, r, func() (interface{}, error) {
+		pkgPath := r.FormValue("path")
+		depInfo := ds.buildPackageDependenciesData(pkgPath)
+		if depInfo == nil {
+			return nil, fmt.Errorf("package (%s) not found", pkgPath)
+		}
+
+		var paths = func(pkgs []*PackageForListing) []string {
+			r := make([]string, len(pkgs))
+			for i, p := range pkgs {
+				r[i] = p.Path
+			}
+			return r
+		}
+		return &APIDependencies{
+			Path:        depInfo.ImportPath,
+			Name:        depInfo.Name,
+			Imports:     paths(depInfo.Imports),
+			ImportedBys: paths(depInfo.ImportedBys),
+		}, nil
+	})
+}
+
+// api:references?pkg=xxx&id=yyy
+func (ds *docServer) referencesAPI(w http.ResponseWriter, r *http.Request) {
+	ds.serveDataAPI(w, r, func() (interface{}, error) {
+		pkgPath, identifier := r.FormValue("pkg"), r.FormValue("id")
+		tokens := strings.Split(identifier, ".")
+		if !collectUnexporteds && pkgPath != "builtin" {
+			for _, t := range tokens {
+				if !token.IsExported(t) {
+					return nil, errors.New("unexported identifiers are not collected")
+				}
+			}
+		}
+
+		result, err := ds.buildReferencesData(pkgPath, tokens...)
+		if err != nil {
+			return nil, err
+		}
+
+		refs := &APIReferences{
+			Package:    result.Package.Path,
+			Identifier: result.Identifier,
+			References: make([]APIPosition, 0, result.UsesCount),
+		}
+		if result.Selector != nil {
+			if result.Selector.Field != nil {
+				refs.Kind = "field"
+			} else {
+				refs.Kind = "method"
+			}
+		} else {
+			refs.Kind = resourceKind(result.Resource)
+		}
+		for _, group := range result.References {
+			for _, id := range group.Identifiers {
+				pos := group.Pkg.PPkg.Fset.PositionFor(id.AstIdent.NamePos, false)
+				refs.References = append(refs.References, buildAPIPosition(group.Pkg, pos))
+			}
+		}
+		return refs, nil
+	})
+}
+
+// api:implementations?pkg=xxx&type=yyy
+func (ds *docServer) implementationsAPI(w http.ResponseWriter, r *http.Request) {
+	ds.serveDataAPI(w, r, func() (interface{}, error) {
+		pkgPath, typeName := r.FormValue("pkg"), r.FormValue("type")
+		if !collectUnexporteds && pkgPath != "builtin" && !token.IsExported(typeName) {
+			return nil, errors.New("unexported identifiers are not collected")
+		}
+
+		result, err := ds.buildImplementationData(ds.analyzer, pkgPath, typeName)
+		if err != nil {
+			return nil, err
+		}
+
+		impls := &APIImplementations{
+			Package:     result.Package.Path,
+			Type:        result.TypeName.Name(),
+			IsInterface: result.IsInterface,
+			Methods:     make([]APIMethodImplementations, len(result.Methods)),
+		}
+		for i, mi := range result.Methods {
+			m := &impls.Methods[i]
+			m.Method = mi.Method.Name()
+			m.Position = buildAPIPosition(mi.Method.Package(), mi.Method.Position())
+			m.Implementations = make([]APIMethodRef, len(mi.Implementations))
+			for k, info := range mi.Implementations {
+				m.Implementations[k] = APIMethodRef{
+					Receiver:  *buildAPITypeRef(info.Receiver),
+					Method:    info.Method.Name(),
+					Explicit:  info.Explicit,
+					Interface: info.Interface,
+					Position:  buildAPIPosition(info.Method.Package(), info.Method.Position()),
+				}
+			}
+		}
+		return impls, nil
+	})
+}
+
+// api:coupling?module=xxx
+func (ds *docServer) couplingAPI(w http.ResponseWriter, r *http.Request) {
+	ds.serveDataAPI(w, r, func() (interface{}, error) {
+		modulePath := r.FormValue("module")
+		if modulePath != "" && ds.analyzer.ModuleByPath(modulePath) == nil {
+			return nil, fmt.Errorf("module (%s) not found", modulePath)
+		}
+
+		result := &APICoupling{
+			Packages: []APIPackageCoupling{},
+			Modules:  []APIModuleDSM{},
+		}
+		modules, couplings := ds.buildModuleCouplings()
+		for i, m := range modules {
+			if modulePath != "" && (m == nil || modulePagePath(m) != modulePath) {
+				continue
+			}
+			for _, pc := range couplings[i] {
+				result.Packages = append(result.Packages, APIPackageCoupling{
+					Path:        pc.Package.Path,
+					Module:      packageModulePagePath(pc.Package),
+					Afferent:    pc.Afferent,
+					Efferent:    pc.Efferent,
+					Instability: pc.Instability,
+					DepHeight:   pc.Package.DepHeight,
+					DepDepth:    pc.Package.DepDepth,
+				})
+			}
+			if m == nil {
+				continue
+			}
+
+			dsm := ds.buildModuleDSM(m)
+			apiDSM := APIModuleDSM{
+				Module:   modulePagePath(m),
+				Packages: make([]string, len(dsm.Packages)),
+				Imports:  make([]APIDSMCell, len(dsm.Cells)),
+			}
+			for k, pkg := range dsm.Packages {
+				apiDSM.Packages[k] = pkg.Path
+			}
+			for k, cell := range dsm.Cells {
+				apiDSM.Imports[k] = APIDSMCell{From: cell.From, To: cell.To, Kind: cell.Kind}
+			}
+			result.Modules = append(result.Modules, apiDSM)
+		}
+		return result, nil
+	})
+}
+
+func (ds *docServer) serveDataAPI(w http.ResponseWriter, r *http.Request, build func() (interface{}, error)) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		writeAPIError(w, http.StatusTooEarly, errors.New("analyzing is not done yet"))
+		return
+	}
+
+	result, err := build()
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if genDocsMode {
+		// Only the APIs needing no parameters are linked in generated docs.
+		page := NewHtmlPage(goldsVersion, "", nil, ds.currentTranslation, createPagePathInfo(ResTypeAPI, strings.TrimPrefix(r.URL.Path, "/api:")))
+		page.Write(data)
+		_ = page.Done(w)
+		return
+	}
+
+	w.Write(data)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	data, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func buildAPIPackage(details *PackageDetails) *APIPackage {
+	pkg := details.Package
+	result := &APIPackage{
+		Path:        details.ImportPath,
+		Name:        details.Name,
+		Module:      pkg.ModulePath(),
+		IsStandard:  details.IsStandard,
+		NumDeps:     int(details.NumDeps),
+		NumDepedBys: int(details.NumDepedBys),
+
+		Files:     make([]APIFile, len(details.Files)),
+		Types:     make([]*APIType, len(details.TypeNames)),
+		Functions: buildAPIValues(details.Functions),
+		Variables: buildAPIValues(details.Variables),
+		Constants: buildAPIValues(details.Constants),
+	}
+
+	for i, f := range details.Files {
+		result.Files[i] = APIFile{Name: f.Filename, Doc: f.DocText}
+	}
+
+	for i, rwp := range details.TypeNames {
+		td := rwp.Type
+		tn := td.TypeName
+		t := &APIType{
+			Name:       tn.Name(),
+			Kind:       td.TypeName.Denoting.Kind().String(),
+			IsAlias:    tn.IsAlias(),
+			Exported:   tn.Exported(),
+			Doc:        tn.Documentation(),
+			Position:   buildAPIPosition(pkg, rwp.Position),
+			Popularity: td.Popularity,
+
+			Fields:         make([]*APISelector, len(td.Fields)),
+			Methods:        make([]*APISelector, len(td.Methods)),
+			Implements:     buildAPITypeRefs(td.Implements),
+			ImplementedBys: buildAPITypeRefs(td.ImplementedBys),
+			Values:         buildAPIValueList(td.Values),
+			AsInputsOf:     buildAPIValueList(td.AsInputsOf),
+			AsOutputsOf:    buildAPIValueList(td.AsOutputsOf),
+		}
+		for k, fld := range td.Fields {
+			t.Fields[k] = buildAPISelector(fld.Selector, pkg)
+		}
+		for k, sel := range td.Methods {
+			t.Methods[k] = buildAPISelector(sel, pkg)
+		}
+		result.Types[i] = t
+	}
+
+	return result
+}
+
+func buildAPIPosition(pkg *code.Package, pos token.Position) APIPosition {
+	p := APIPosition{
+		File:   filepath.Base(pos.Filename),
+		Line:   pos.Line,
+		Column: pos.Column,
+	}
+	if pkg != nil {
+		p.Package = pkg.Path
+	}
+	return p
+}
+
+func buildAPIValue(v code.ValueResource) *APIValue {
+	name := v.Name()
+	if f, ok := v.(code.FunctionResource); ok && f.IsMethod() {
+		if _, tn, _ := f.ReceiverTypeName(); tn != nil {
+			name = tn.Name() + "." + name
+		}
+	}
+	return &APIValue{
+		Package:  v.Package().Path,
+		Name:     name,
+		Type:     types.TypeString(v.TType(), nil),
+		Exported: v.Exported(),
+		Doc:      v.Documentation(),
+		Position: buildAPIPosition(v.Package(), v.Position()),
+	}
+}
+
+func buildAPIValues(values []ResourceWithPosition) []*APIValue {
+	result := make([]*APIValue, len(values))
+	for i, rwp := range values {
+		result[i] = buildAPIValue(rwp.Value)
+	}
+	return result
+}
+
+func buildAPIValueList(values []*ValueForListing) []*APIValue {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]*APIValue, len(values))
+	for i, v := range values {
+		result[i] = buildAPIValue(v.ValueResource)
+	}
+	return result
+}
+
+// pkg is the package declaring the type owning the selector.
+// It is used if the package of the selector is unknown (for
+// the unexported selectors declared in the builtin package).
+func buildAPISelector(sel *code.Selector, pkg *code.Package) *APISelector {
+	s := &APISelector{
+		Name: sel.Name(),
+	}
+	if selPkg := sel.Package(); selPkg != nil {
+		s.Position = buildAPIPosition(selPkg, sel.Position())
+	} else {
+		var node ast.Node
+		if sel.Field != nil {
+			node = sel.Field.AstField
+		} else if sel.Method.AstFunc != nil {
+			node = sel.Method.AstFunc
+		} else {
+			node = sel.Method.AstField
+		}
+		// The builtin package shares the file set with other packages.
+		s.Position = buildAPIPosition(pkg, pkg.PPkg.Fset.PositionFor(node.Pos(), false))
+	}
+	if t := sel.Type(); t != nil {
+		s.Type = types.TypeString(t.TT, nil)
+	}
+	if sel.EmbeddingChain != nil {
+		s.Path = selectorPath(sel)
+	}
+	return s
+}
+
+// selectorPath returns the selector name prefixed with the embedding
+// path, such as "*Field.Name", without the "[field] " like prefix.
+func selectorPath(sel *code.Selector) string {
+	s := sel.String()
+	return s[strings.IndexByte(s, ' ')+1:]
+}
+
+func buildAPITypeRef(t *TypeForListing) *APITypeRef {
+	return &APITypeRef{
+		Package:   t.BaseType.TypeName.Package().Path,
+		Name:      t.NameWithTypeArgs,
+		IsPointer: t.IsPointer,
+	}
+}
+
+func buildAPITypeRefs(typeList []*TypeForListing) []*APITypeRef {
+	if len(typeList) == 0 {
+		return nil
+	}
+	result := make([]*APITypeRef, len(typeList))
+	for i, t := range typeList {
+		result[i] = buildAPITypeRef(t)
+	}
+	return result
+}
+
+func resourceKind(res code.Resource) string {
+	switch res.(type) {
+	case *code.TypeName:
+		return "type"
+	case *code.Function:
+		return "func"
+	case *code.Variable:
+		return "var"
+	case *code.Constant:
+		return "const"
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/doc"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/util"
+)
+
+const (
+	// The time limit of running an example, including the build time.
+	exampleRunTimeout = time.Minute
+
+	// The max number of examples running at the same time.
+	maxConcurrentExampleRuns = 2
+)
+
+var exampleRunSlots = make(chan struct{}, maxConcurrentExampleRuns)
+
+// writeExampleRunner writes a "Run" button and an output container
+// for an example, if running examples is enabled.
+func writeExampleRunner(page *htmlPage, pkg *code.Package, ex *doc.Example, indent string) {
+	if !runExamples || genDocsMode {
+		return
+	}
+
+	fmt.Fprintf(page, `%[1]s<span class="js-on example-runner" data-package="%[2]s" data-example="Example%[3]s" data-indent="%[1]s"><label class="button">%[4]s</label><span class="example-output"></span></span>
+`,
+		indent, html.EscapeString(pkg.Path), html.EscapeString(ex.Name), page.Translation().Text_RunExample())
+}
+
+// api:run-example
+//
+// The API runs an example with "go test" in the working directory module,
+// and streams back the output, then the result of comparing the output
+// with the "// Output:" comment of the example.
+func (ds *docServer) runExampleAPI(w http.ResponseWriter, r *http.Request) {
+	if !runExamples || genDocsMode {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "Running examples is not enabled")
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	// Only allow the requests sent from the docs pages.
+	// Browsers always send the Origin header for POST requests.
+	if u, err := url.Parse(r.Header.Get("Origin")); err != nil || u.Host == "" || u.Host != r.Host {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	pkgPath, name := r.FormValue("package"), r.FormValue("example")
+
+	ds.mutex.Lock()
+	var ex *doc.Example
+	var dir string
+	if pkg := ds.analyzer.PackageByPath(pkgPath); pkg != nil {
+		for _, e := range pkg.Examples {
+			if "Example"+e.Name == name {
+				ex, dir = e, pkg.Directory
+				break
+			}
+		}
+		// Run "go test" in the working directory module, so that
+		// the build list (and replacements) of the module is used.
+		if m := ds.analyzer.WorkingDirectoryModule(); m != nil && m.Dir != "" {
+			dir = m.Dir
+		}
+	}
+	goEnv := ds.analyzer.GoCommandEnv()
+	translation := ds.currentTranslation
+	ds.mutex.Unlock()
+
+	if ex == nil || dir == "" {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "Example not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	out := &flushWriter{w: w}
+	out.flusher, _ = w.(http.Flusher)
+
+	select {
+	case exampleRunSlots <- struct{}{}:
+		defer func() { <-exampleRunSlots }()
+	case <-time.After(exampleRunTimeout):
+		fmt.Fprintln(out, translation.Text_ExampleRunResult("busy"))
+		return
+	case <-r.Context().Done():
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), exampleRunTimeout)
+	defer cancel()
+
+	// Examples without output comments are compiled but not run by "go test".
+	hasOutput := ex.Output != "" || ex.EmptyOutput
+
+	cmd := util.NewCommand(ctx, dir, goEnv, "go", "test", "-count=1", "-v", "-vet=off",
+		"-timeout="+exampleRunTimeout.String(), "-run=^"+name+"$", pkgPath)
+	cmd.Stdout, cmd.Stderr = out, out
+	setCommandWaitDelay(cmd, time.Second*3)
+	err := cmd.Run()
+
+	out.Write([]byte{'\n'})
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		fmt.Fprintln(out, translation.Text_ExampleRunResult("timeout"))
+	case err != nil:
+		fmt.Fprintln(out, translation.Text_ExampleRunResult("fail"))
+	case !hasOutput:
+		fmt.Fprintln(out, translation.Text_ExampleRunResult("unverified"))
+	default:
+		fmt.Fprintln(out, translation.Text_ExampleRunResult("pass"))
+		io.WriteString(out, ex.Output)
+	}
+}
+
+// flushWriter flushes each write to the client.
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (fw *flushWriter) Write(data []byte) (int, error) {
+	n, err := fw.w.Write(data)
+	if fw.flusher != nil {
+		fw.flusher.Flush()
+	}
+	return n, err
+}
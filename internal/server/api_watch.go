@@ -0,0 +1,335 @@
+package server
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/util"
+)
+
+// The interval to check whether or not watched source files are changed.
+const watchInterval = time.Second * 2
+
+// docsVersion is increased each time a new analysis result is used
+// in the watch mode. Each HTML page records the version it is built with.
+var docsVersion int32
+
+func writeNewerDocsNotice(page *htmlPage) {
+	fmt.Fprintf(page, `<pre id="newer-docs-notice" class="golds-update hidden" data-docs-version="%d">%s</pre>
+`,
+		atomic.LoadInt32(&docsVersion),
+		page.Translation().Text_NewerDocsAvailable(),
+	)
+}
+
+// api:docs-version
+func (ds *docServer) docsVersionAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"docsVersion": %d}`, atomic.LoadInt32(&docsVersion))
+}
+
+// watchSourceChanges polls the source files of the packages which might be
+// edited by users. When changes are detected, the packages are re-analyzed
+// in background and the old analysis result keeps serving until the new
+// one is ready.
+func (ds *docServer) watchSourceChanges(args []string, toolchain code.ToolchainInfo) {
+	ds.mutex.Lock()
+	dirs := watchedDirectories(ds.analyzer)
+	ds.mutex.Unlock()
+
+	if len(dirs) == 0 {
+		ds.watchLogger.Println("no directories to watch")
+		return
+	}
+	ds.watchLogger.Printf("watching %d directories", len(dirs))
+
+	digest, changed := sourceFilesDigest(dirs), false
+	dirDigests := sourceDirectoryDigests(dirs)
+	for {
+		time.Sleep(watchInterval)
+
+		// The watched directories are re-collected in each round,
+		// so that new package directories are also detected.
+		ds.mutex.Lock()
+		dirs = watchedDirectories(ds.analyzer)
+		ds.mutex.Unlock()
+
+		// Wait until the files are stable, for editors
+		// might save several files in a row.
+		if newDigest := sourceFilesDigest(dirs); newDigest != digest {
+			digest, changed = newDigest, true
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		newDirDigests := sourceDirectoryDigests(dirs)
+		var err error
+		analyzer := ds.reanalyzeChangedPackages(dirDigests, newDirDigests)
+		if analyzer == nil {
+			ds.watchLogger.Println("source changes detected, re-analyzing ...")
+			analyzer, err = ds.reanalyze(args, toolchain)
+		}
+
+		// Files might be changed again during re-analyzing.
+		changed = sourceFilesDigest(dirs) != digest
+		if err != nil {
+			ds.watchLogger.Printf("re-analyzing error: %s (still serving the old docs)", err)
+			continue
+		}
+
+		ds.useAnalyzer(analyzer)
+		dirDigests = newDirDigests
+		if !changed {
+			dirs = watchedDirectories(analyzer)
+			digest = sourceFilesDigest(dirs)
+			dirDigests = sourceDirectoryDigests(dirs)
+		}
+	}
+}
+
+// reanalyzeChangedPackages tries to only re-parse the packages in the
+// directories with changed .go files (and the packages depending on them),
+// then analyzes a new analyzer which reuses the parse results of the other
+// packages. The current analyzer keeps serving during the whole process.
+// It returns nil if all packages need to be re-parsed, for example, when some
+// go.mod files are changed or the imports of some packages are changed.
+func (ds *docServer) reanalyzeChangedPackages(oldDigests, newDigests map[string]dirDigest) *code.CodeAnalyzer {
+	var changedDirs = make(map[string]bool, 4)
+	for dir, newDigest := range newDigests {
+		oldDigest, ok := oldDigests[dir]
+		if !ok || oldDigest.goMod != newDigest.goMod {
+			return nil
+		}
+		if oldDigest.goFiles != newDigest.goFiles {
+			changedDirs[dir] = true
+		}
+	}
+	if len(changedDirs) == 0 {
+		return nil
+	}
+
+	ds.mutex.Lock()
+	analyzer := ds.analyzer
+	ds.mutex.Unlock()
+
+	var changedPkgs []*code.Package
+	for i := 0; i < analyzer.NumPackages(); i++ {
+		pkg := analyzer.PackageAt(i)
+		if changedDirs[pkg.Directory] {
+			changedPkgs = append(changedPkgs, pkg)
+			delete(changedDirs, pkg.Directory)
+		}
+	}
+	if len(changedPkgs) == 0 || len(changedDirs) > 0 {
+		return nil // some packages might be added
+	}
+
+	var stopWatch = util.NewStopWatch()
+	cp, err := analyzer.ReparseChangedPackages(changedPkgs)
+	if err != nil {
+		ds.watchLogger.Printf("unable to re-parse the changed packages only: %s", err)
+		return nil
+	}
+
+	ds.watchLogger.Printf("source changes detected, re-parsed %d packages, re-analyzing ...", cp.NumPackages())
+
+	newAnalyzer := analyzer.NewAnalyzerWithChangedPackages(cp)
+	newAnalyzer.AnalyzePackages(nil)
+
+	ds.watchLogger.Printf("re-analyzing done (%s)", stopWatch.Duration(false))
+	return newAnalyzer
+}
+
+func (ds *docServer) reanalyze(args []string, toolchain code.ToolchainInfo) (*code.CodeAnalyzer, error) {
+	var stopWatch = util.NewStopWatch()
+	var analyzer = &code.CodeAnalyzer{}
+	analyzer.EnableCache(cacheDirectory)
+	var repoInfoCache = make(map[string]localRepoInfo, 4)
+	completeModuleInfo := func(m *code.Module) {
+		ds.tryToCompleteModuleInfo(analyzer, m, repoInfoCache)
+	}
+
+	if err := analyzer.ParsePackages(nil, completeModuleInfo, toolchain, args...); err != nil {
+		if loadErr, ok := err.(*code.LoadError); ok {
+			for _, e := range loadErr.Errs {
+				ds.watchLogger.Println(e)
+			}
+		}
+		return nil, err
+	}
+	analyzer.AnalyzePackages(nil)
+
+	ds.watchLogger.Printf("re-analyzing done (%s)", stopWatch.Duration(false))
+	return analyzer, nil
+}
+
+// useAnalyzer swaps in a new analysis result and clears the caches
+// built with the old one.
+func (ds *docServer) useAnalyzer(analyzer *code.CodeAnalyzer) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	ds.analyzer = analyzer
+	ds.resetAnalysisCaches()
+}
+
+// resetAnalysisCaches clears the caches built with the old analysis result.
+// ds.mutex must be locked when calling this method.
+func (ds *docServer) resetAnalysisCaches() {
+	ds.confirmModuleBuildSourceLinkFuncs()
+	if ds.cachedPages != nil {
+		ds.cachedPages = make(map[pageCacheKey][]byte, len(ds.cachedPages))
+	}
+	ds.searchItems = nil
+	ds.nestedFieldPaths = nil
+	ds.callHierarchyBuilder = nil
+
+	atomic.AddInt32(&docsVersion, 1)
+}
+
+// watchedDirectories returns the directories of the packages which might
+// be edited by users, and the directories of their modules (for go.mod files).
+// The directories containing .go files in these modules are also included,
+// for some new packages might be created in them.
+// Standard packages and packages in module cache are not watched.
+func watchedDirectories(analyzer *code.CodeAnalyzer) []string {
+	var dirs = make(map[string]struct{}, analyzer.NumPackages())
+	var moduleDirs = make(map[string]struct{}, 4)
+	for i := 0; i < analyzer.NumPackages(); i++ {
+		pkg := analyzer.PackageAt(i)
+		if analyzer.IsStandardPackage(pkg) || pkg.Directory == "" {
+			continue
+		}
+		m := pkg.Module()
+		if m != nil {
+			if m.ActualVersion() != "" && !analyzer.IsWorkingDirectoryModule(m) {
+				continue
+			}
+			if dir := m.ActualDir(); dir != "" {
+				dirs[dir] = struct{}{}
+				moduleDirs[dir] = struct{}{}
+			}
+		}
+		dirs[pkg.Directory] = struct{}{}
+	}
+	for moduleDir := range moduleDirs {
+		for _, dir := range moduleSourceDirectories(moduleDir) {
+			dirs[dir] = struct{}{}
+		}
+	}
+
+	var list = make([]string, 0, len(dirs))
+	for dir := range dirs {
+		list = append(list, dir)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// moduleSourceDirectories returns the directories containing .go files
+// in the module at moduleDir. Like the go command, it skips the vendor,
+// testdata, hidden and "_" prefixed directories, and nested modules.
+func moduleSourceDirectories(moduleDir string) []string {
+	var dirs = make(map[string]struct{}, 16)
+	filepath.WalkDir(moduleDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // the directory might be removed
+		}
+		if !d.IsDir() {
+			if strings.HasSuffix(path, ".go") {
+				dirs[filepath.Dir(path)] = struct{}{}
+			}
+			return nil
+		}
+		if path == moduleDir {
+			return nil
+		}
+		switch name := d.Name(); {
+		case name == "vendor", name == "testdata", strings.HasPrefix(name, "."), strings.HasPrefix(name, "_"):
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	var list = make([]string, 0, len(dirs))
+	for dir := range dirs {
+		list = append(list, dir)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// sourceFilesDigest returns a digest of the names, sizes and
+// modification times of the .go and go.mod files in dirs.
+func sourceFilesDigest(dirs []string) uint64 {
+	var h = fnv.New64a()
+	for _, dir := range dirs {
+		h.Write([]byte(dir))
+		writeDirectoryDigest(h, h, dir)
+	}
+	return h.Sum64()
+}
+
+// dirDigest records the digests of the .go files and
+// the go.mod file in a directory separately.
+type dirDigest struct {
+	goFiles uint64
+	goMod   uint64
+}
+
+// sourceDirectoryDigests returns the digests of the
+// source files in each of dirs.
+func sourceDirectoryDigests(dirs []string) map[string]dirDigest {
+	var digests = make(map[string]dirDigest, len(dirs))
+	for _, dir := range dirs {
+		var goFiles, goMod = fnv.New64a(), fnv.New64a()
+		writeDirectoryDigest(goFiles, goMod, dir)
+		digests[dir] = dirDigest{goFiles: goFiles.Sum64(), goMod: goMod.Sum64()}
+	}
+	return digests
+}
+
+// writeDirectoryDigest writes the names, sizes and modification times
+// of the .go files and the go.mod file in dir to goFiles and goMod.
+func writeDirectoryDigest(goFiles, goMod io.Writer, dir string) {
+	var buf [16]byte
+	entries, err := os.ReadDir(dir) // sorted by filename
+	if err != nil {
+		goFiles.Write([]byte{0}) // the directory might be removed
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") && name != "go.mod" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		w := goFiles
+		if name == "go.mod" {
+			w = goMod
+		}
+		w.Write([]byte(filepath.Join(dir, name)))
+		binary.LittleEndian.PutUint64(buf[:8], uint64(info.Size()))
+		binary.LittleEndian.PutUint64(buf[8:], uint64(info.ModTime().UnixNano()))
+		w.Write(buf[:])
+	}
+}
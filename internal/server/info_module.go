@@ -281,7 +281,7 @@ func buildSourceLinkFunc_sr_ht(w writer, commit, extraPath, sourcePath, line, en
 //
 //=====================================
 
-func (ds *docServer) tryToCompleteModuleInfo(m *code.Module, localRepoInfos map[string]localRepoInfo) {
+func (ds *docServer) tryToCompleteModuleInfo(analyzer *code.CodeAnalyzer, m *code.Module, localRepoInfos map[string]localRepoInfo) {
 	//if ds.analysisWorkingDirectory == "" {
 	//	ds.analysisWorkingDirectory = util.WorkingDirectory()
 	//}
@@ -289,7 +289,7 @@ func (ds *docServer) tryToCompleteModuleInfo(m *code.Module, localRepoInfos map[
 	// ToDo: handle modules feature off case in which module versions will always blank?
 	//       Or best not to generate any modules in this case.
 	//if m.ActualVersion() == "" && m.Replace.Path == "" { // wd module
-	if m == ds.analyzer.WorkingDirectoryModule() {
+	if analyzer.IsWorkingDirectoryModule(m) { // there might be multiple ones with go.work
 		//if !strings.HasPrefix(ds.initialWorkingDirectory, m.Dir) {
 		//	log.Printf("working directory module dir is not correct:\n\t%s\n\t%s", m.Dir, ds.initialWorkingDirectory)
 		//	return
@@ -311,7 +311,7 @@ func (ds *docServer) tryToCompleteModuleInfo(m *code.Module, localRepoInfos map[
 		if m.ActualDir() == "" { // this happens for modules in project vendor folder
 			func() {
 				pkgDir := m.Pkgs[0].Directory
-				in, relDir := ds.inVendor(pkgDir)
+				in, relDir := ds.inVendor(analyzer, pkgDir)
 				if !in {
 					return
 				}
@@ -495,9 +495,9 @@ func splitByNthSlash(path string, n int) (string, string) {
 const sep = string(filepath.Separator)
 const sepVendorSep = sep + "vendor" + sep
 
-func (ds *docServer) inVendor(pkgDir string) (bool, string) {
+func (ds *docServer) inVendor(analyzer *code.CodeAnalyzer, pkgDir string) (bool, string) {
 	dir := pkgDir
-	wdModule := ds.analyzer.WorkingDirectoryModule()
+	wdModule := analyzer.WorkingDirectoryModule()
 	if wdModule == nil {
 		panic("should not")
 	}
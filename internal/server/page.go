@@ -29,6 +29,9 @@ type PageOutputOptions struct {
 	WdPkgsListingManner    string
 	FooterShowingManner    string
 	Theme                  string
+	WatchSourceChanges     bool
+	RunExamples            bool
+	CacheDirectory         string // blank means the on-disk type-checking cache is disabled
 
 	// ToDo:
 	//ListUnexportedRes   bool
@@ -53,6 +56,9 @@ var (
 	wdPkgsListingManner    = WdPkgsListingManner_general
 	footerShowingManner    = FooterShowingManner_none
 	pageTheme              = "auto"
+	watchSourceChanges     = false // for web serving mode only
+	runExamples            = false // for web serving mode only
+	cacheDirectory         = ""    // blank means the on-disk type-checking cache is disabled
 
 	renderDocLinks     = false
 	unfoldAllInitially = false
@@ -78,6 +84,11 @@ func setPageOutputOptions(options PageOutputOptions, forTesting bool) {
 	wdPkgsListingManner = options.WdPkgsListingManner
 	footerShowingManner = options.FooterShowingManner
 	pageTheme = options.Theme
+	watchSourceChanges = options.WatchSourceChanges && !forTesting
+	runExamples = options.RunExamples && !forTesting
+	if !forTesting {
+		cacheDirectory = options.CacheDirectory
+	}
 
 	verboseLogs = options.VerboseLogs
 }
@@ -109,6 +120,7 @@ const (
 	ResTypeImplementation pageResType = "imp"
 	ResTypeSource         pageResType = "src"
 	ResTypeReference      pageResType = "use"
+	ResTypeCallHierarchy  pageResType = "cal"
 	ResTypeCSS            pageResType = "css"
 	ResTypeJS             pageResType = "jvs"
 	ResTypeSVG            pageResType = "svg"
@@ -128,6 +140,7 @@ func isHTMLPage(res pageResType) bool {
 	case ResTypeImplementation:
 	case ResTypeSource:
 	case ResTypeReference:
+	case ResTypeCallHierarchy:
 	}
 	return true
 }
@@ -232,6 +245,10 @@ func NewHtmlPage(goldsVersion, title string, theme Theme, translation Translatio
 			buildPageHref(currentPageInfo, createPagePathInfo(ResTypeCSS, addVersionToFilename(theme.Name(), goldsVersion)), nil, ""),
 			buildPageHref(currentPageInfo, createPagePathInfo(ResTypeJS, addVersionToFilename("golds", goldsVersion)), nil, ""),
 		)
+
+		if watchSourceChanges && !genDocsMode {
+			writeNewerDocsNotice(&page)
+		}
 	}
 
 	return &page
@@ -433,6 +450,22 @@ func createPagePathInfo3(resType pageResType, scope, sep, resPath, selector stri
 	return pagePathInfo{resType, scope + sep + resPath + "." + selector}
 }
 
+// scope should be an import path.
+// selectorPath is a dot-separated selector list, such as "field.nestedField".
+func createPagePathInfo3b(resType pageResType, scope, sep, resPath, selectorPath string) pagePathInfo {
+	if genDocsMode {
+		scope = hashedScope(scope)
+		resPath = hashedIdentifier(resPath)
+		selectors := strings.Split(selectorPath, ".")
+		for i, sel := range selectors {
+			selectors[i] = hashedIdentifier(sel)
+		}
+		selectorPath = strings.Join(selectors, ".")
+	}
+
+	return pagePathInfo{resType, scope + sep + resPath + "." + selectorPath}
+}
+
 type writer interface {
 	Write([]byte) (int, error)
 	WriteString(string) (int, error)
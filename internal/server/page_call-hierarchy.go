@@ -0,0 +1,651 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+// The levels of callers and callees listed in a call hierarchy page,
+// and the maximum number of the listed calls below the first level.
+const (
+	maxCallHierarchyDepth       = 3
+	maxCallHierarchyNestedCalls = 512
+)
+
+func (ds *docServer) callHierarchyPage(w http.ResponseWriter, r *http.Request, pkgPath, identifier string) {
+	w.Header().Set("Content-Type", "text/html")
+
+	tokens := strings.Split(identifier, ".")
+	if genDocsMode {
+		pkgPath = deHashScope(pkgPath)
+		for i, t := range tokens {
+			tokens[i] = deHashIdentifier(t)
+		}
+	}
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeCallHierarchy,
+		res:     [...]string{pkgPath, identifier},
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		result, err := ds.buildCallHierarchyData(pkgPath, tokens...)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "error: ", err)
+			return
+		}
+
+		data = ds.buildCallHierarchyPage(w, result)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+func (ds *docServer) buildCallHierarchyPage(w http.ResponseWriter, result *CallHierarchyResult) []byte {
+	fn := result.Function
+	title := ds.currentTranslation.Text_CallHierarchy() + ds.currentTranslation.Text_Colon(false) + fn.Package.Path + "." + fn.Identifier()
+	page := NewHtmlPage(goldsVersion, title, ds.currentTheme, ds.currentTranslation, callHierarchyPagePathInfo(fn))
+
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:x-large;">func <b><a href="%s">%s</a>.`,
+		buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, fn.Package.Path), nil, ""),
+		fn.Package.Path,
+	)
+	writeSrouceCodeLineLink(page, fn.Package, fn.Position, fn.Identifier(), "")
+	page.WriteString(`</b></span>`)
+	if buildIdUsesPages {
+		page.WriteString(`<span style="font-size: large;"><i>`)
+		page.WriteString(page.Translation().Text_Parenthesis(false))
+		fmt.Fprintf(page, `<a href="%s">%s</a>`, buildPageHref(page.PathInfo, referencePagePathInfo(fn), nil, ""), page.Translation().Text_ReferenceList())
+		page.WriteString(page.Translation().Text_Parenthesis(true))
+		page.WriteString(`</i></span>`)
+	}
+	page.WriteString("\n\n")
+
+	// The callers and callees are listed in folding blocks which can be
+	// expanded to view their own callers and callees, up to a limited
+	// number of levels and nested calls, to keep pages in reasonable
+	// sizes. Each function also links to its own call hierarchy page.
+	var builder = ds.callHierarchyBuilder
+	var numNestedCalls = 0
+	var foldID = 0
+	var writeCalls func(calls []*CallHierarchyCall, indent string, depth int, callers bool, path []*CallHierarchyFunc)
+	var writeCall = func(call *CallHierarchyCall, indent string, depth int, callers bool, path []*CallHierarchyFunc) {
+		var next []*CallHierarchyCall
+		if call.Func != nil && depth < maxCallHierarchyDepth && numNestedCalls < maxCallHierarchyNestedCalls {
+			var visited bool
+			for _, f := range path {
+				if f == call.Func {
+					visited = true
+					break
+				}
+			}
+			if !visited {
+				if callers {
+					for _, g := range builder.callersOf(call.Func) {
+						next = append(next, g.Callers...)
+					}
+				} else {
+					next = builder.calleesOf(call.Func)
+				}
+				if n := maxCallHierarchyNestedCalls - numNestedCalls; len(next) > n {
+					next = next[:n]
+				}
+				numNestedCalls += len(next)
+			}
+		}
+
+		var writeTitle = func() {
+			if call.Func == nil {
+				page.WriteString("<i>")
+				page.WriteString(page.Translation().Text_PackageLevelDeclarations())
+				page.WriteString("</i>")
+			} else {
+				ds.writeCallHierarchyFuncLink(page, call.Func, result.Function.Package)
+			}
+			if call.Via != nil {
+				page.WriteString(" <i>")
+				page.WriteString(page.Translation().Text_Parenthesis(false))
+				page.WriteString(page.Translation().Text_DynamicCallVia())
+				page.WriteByte(' ')
+				ds.writeCallHierarchyFuncLink(page, call.Via, result.Function.Package)
+				page.WriteString(page.Translation().Text_Parenthesis(true))
+				page.WriteString("</i>")
+			}
+			if len(call.Sites) > 0 {
+				page.WriteString(" <i>")
+				page.WriteString(page.Translation().Text_Parenthesis(false))
+				page.WriteString(page.Translation().Text_NumCalls(len(call.Sites)))
+				page.WriteString(page.Translation().Text_Parenthesis(true))
+				page.WriteString("</i>")
+			}
+		}
+		var writeContent = func() {
+			for _, site := range call.Sites {
+				page.WriteString("\n")
+				page.WriteString(indent)
+				page.WriteString("\t\t")
+				writeSrouceCodeLineLink(page, call.Pkg, site, fmt.Sprintf("%s#L%d", site.Filename[strings.LastIndexAny(site.Filename, `/\`)+1:], site.Line), "")
+			}
+			if len(next) > 0 {
+				writeCalls(next, indent+"\t", depth+1, callers, append(path[:len(path):len(path)], call.Func))
+			}
+		}
+
+		page.WriteString("\n")
+		page.WriteString(indent)
+		page.WriteString("\t")
+		foldID++
+		writeFoldingBlock(page, fmt.Sprintf("call-%d", foldID), "calls", "items", false, writeTitle, writeContent)
+	}
+	writeCalls = func(calls []*CallHierarchyCall, indent string, depth int, callers bool, path []*CallHierarchyFunc) {
+		for _, call := range calls {
+			writeCall(call, indent, depth, callers, path)
+		}
+	}
+
+	var numCallers int
+	for _, g := range result.Callers {
+		numCallers += len(g.Callers)
+	}
+	page.WriteString(`<span class="title">`)
+	page.WriteString(page.Translation().Text_Callers(numCallers))
+	page.WriteString(`</span>`)
+	page.WriteString("\n")
+	for _, g := range result.Callers {
+		page.WriteString("\n\t")
+		if g.Pkg == fn.Package {
+			page.WriteString(g.Pkg.Path)
+			page.WriteString(page.Translation().Text_CurrentPackage())
+		} else {
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, g.Pkg.Path), page, g.Pkg.Path)
+		}
+		writeCalls(g.Callers, "\t", 1, true, []*CallHierarchyFunc{fn})
+		page.WriteString("\n")
+	}
+
+	page.WriteString("\n")
+	page.WriteString(`<span class="title">`)
+	page.WriteString(page.Translation().Text_Callees(len(result.Callees)))
+	page.WriteString(`</span>`)
+	page.WriteString("\n")
+	writeCalls(result.Callees, "", 1, false, []*CallHierarchyFunc{fn})
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+func (ds *docServer) writeCallHierarchyFuncLink(page *htmlPage, fn *CallHierarchyFunc, currentPkg *code.Package) {
+	text := fn.Identifier()
+	if fn.Package != currentPkg {
+		text = fn.Package.PPkg.Name + "." + text
+	}
+	if sourceReadingStyle == SourceReadingStyle_rich && (collectUnexporteds || fn.Exported()) {
+		buildPageHref(page.PathInfo, callHierarchyPagePathInfo(fn), page, text)
+	} else {
+		writeSrouceCodeLineLink(page, fn.Package, fn.Position, text, "")
+	}
+}
+
+func callHierarchyPagePathInfo(fn *CallHierarchyFunc) pagePathInfo {
+	if fn.RecvTypeName != "" {
+		return createPagePathInfo3(ResTypeCallHierarchy, fn.Package.Path, "..", fn.RecvTypeName, fn.Func.Name())
+	}
+	return createPagePathInfo2(ResTypeCallHierarchy, fn.Package.Path, "..", fn.Func.Name())
+}
+
+func referencePagePathInfo(fn *CallHierarchyFunc) pagePathInfo {
+	if fn.RecvTypeName != "" {
+		return createPagePathInfo3(ResTypeReference, fn.Package.Path, "..", fn.RecvTypeName, fn.Func.Name())
+	}
+	return createPagePathInfo2(ResTypeReference, fn.Package.Path, "..", fn.Func.Name())
+}
+
+type CallHierarchyResult struct {
+	Function *CallHierarchyFunc
+	Callers  []*CallerGroup
+	Callees  []*CallHierarchyCall
+}
+
+// CallHierarchyFunc is a package-level function, a concrete method
+// or an interface method.
+type CallHierarchyFunc struct {
+	Package      *code.Package
+	Func         *types.Func
+	RecvTypeName string        // blank for package-level functions
+	Decl         *ast.FuncDecl // nil for interface methods
+	Position     token.Position
+}
+
+// Identifier returns the function name or TypeName.MethodName.
+func (fn *CallHierarchyFunc) Identifier() string {
+	if fn.RecvTypeName != "" {
+		return fn.RecvTypeName + "." + fn.Func.Name()
+	}
+	return fn.Func.Name()
+}
+
+// Exported returns whether or not both the function and
+// its receiver type name (if it is a method) are exported.
+func (fn *CallHierarchyFunc) Exported() bool {
+	return fn.Func.Exported() && (fn.RecvTypeName == "" || token.IsExported(fn.RecvTypeName))
+}
+
+// CallerGroup lists the callers in a package.
+type CallerGroup struct {
+	Pkg     *code.Package
+	Callers []*CallHierarchyCall
+}
+
+// CallHierarchyCall is a caller or a callee, with the call sites.
+type CallHierarchyCall struct {
+	Func  *CallHierarchyFunc // nil for calls in package-level declarations
+	Pkg   *code.Package      // the package containing the call sites
+	Sites []token.Position
+
+	// For dynamic calls, the interface method through which the call is made.
+	Via *CallHierarchyFunc
+}
+
+func (ds *docServer) buildCallHierarchyData(pkgPath string, tokens ...string) (*CallHierarchyResult, error) {
+	pkg := ds.analyzer.PackageByPath(pkgPath)
+	if pkg == nil {
+		return nil, fmt.Errorf("package %s is not found", pkgPath)
+	}
+
+	var obj *types.Func
+	switch len(tokens) {
+	case 1:
+		for _, f := range pkg.AllFunctions {
+			if !f.IsMethod() && f.Func != nil && f.Name() == tokens[0] {
+				obj = f.Func
+				break
+			}
+		}
+	case 2:
+		for _, tn := range pkg.AllTypeNames {
+			if tn.Name() == tokens[0] && !tn.IsAlias() {
+				for _, sel := range tn.Denoting.AllMethods {
+					if sel.EmbeddingChain == nil && sel.Name() == tokens[1] {
+						obj, _ = sel.Object().(*types.Func)
+						break
+					}
+				}
+				break
+			}
+		}
+	default:
+		return nil, errors.New("invalid identifier (must be a function or a method).")
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("function %s is not found in package %s", strings.Join(tokens, "."), pkgPath)
+	}
+
+	if ds.callHierarchyBuilder == nil {
+		ds.callHierarchyBuilder = &callHierarchyBuilder{
+			ds:        ds,
+			funcs:     make(map[*types.Func]*CallHierarchyFunc),
+			callers:   make(map[*CallHierarchyFunc][]*CallerGroup),
+			callees:   make(map[*CallHierarchyFunc][]*CallHierarchyCall),
+			callSites: make(map[*ast.File]map[*ast.Ident]*ast.FuncDecl),
+		}
+	}
+	builder := ds.callHierarchyBuilder
+	fn := builder.funcOf(obj)
+	if fn == nil {
+		return nil, fmt.Errorf("function %s is not found in package %s", strings.Join(tokens, "."), pkgPath)
+	}
+
+	return &CallHierarchyResult{
+		Function: fn,
+		Callers:  builder.callersOf(fn),
+		Callees:  builder.calleesOf(fn),
+	}, nil
+}
+
+// callHierarchyBuilder caches the data used to build call hierarchy
+// pages. It is built lazily and discarded with the analysis result.
+type callHierarchyBuilder struct {
+	ds        *docServer
+	funcs     map[*types.Func]*CallHierarchyFunc
+	callers   map[*CallHierarchyFunc][]*CallerGroup
+	callees   map[*CallHierarchyFunc][]*CallHierarchyCall
+	callSites map[*ast.File]map[*ast.Ident]*ast.FuncDecl // see callSitesIn
+}
+
+// funcOf returns nil if obj is not a package-level function or a method
+// of a named type declared in an analyzed package.
+func (b *callHierarchyBuilder) funcOf(obj *types.Func) *CallHierarchyFunc {
+	obj = originFunc(obj)
+	if fn, ok := b.funcs[obj]; ok {
+		return fn
+	}
+
+	var fn *CallHierarchyFunc
+	defer func() {
+		b.funcs[obj] = fn
+	}()
+
+	if obj.Pkg() == nil {
+		return nil
+	}
+	pkg := b.ds.analyzer.PackageByPath(obj.Pkg().Path())
+	if pkg == nil {
+		return nil
+	}
+
+	var recvTypeName string
+	var isInterfaceMethod bool
+	if recv := obj.Type().(*types.Signature).Recv(); recv != nil {
+		rt := recv.Type()
+		if ptr, ok := rt.(*types.Pointer); ok {
+			rt = ptr.Elem()
+		}
+		named, ok := rt.(*types.Named)
+		if !ok {
+			return nil // a method of an unnamed interface type
+		}
+		if tn := named.Obj(); tn.Parent() != tn.Pkg().Scope() {
+			return nil // a method of a local type
+		}
+		recvTypeName = named.Obj().Name()
+		_, isInterfaceMethod = named.Underlying().(*types.Interface)
+	}
+
+	var decl *ast.FuncDecl
+	if !isInterfaceMethod {
+		for _, f := range pkg.AllFunctions {
+			if f.Func == obj {
+				decl = f.AstDecl
+				break
+			}
+		}
+		if decl == nil {
+			return nil
+		}
+	}
+
+	fn = &CallHierarchyFunc{
+		Package:      pkg,
+		Func:         obj,
+		RecvTypeName: recvTypeName,
+		Decl:         decl,
+		Position:     pkg.PPkg.Fset.PositionFor(obj.Pos(), false),
+	}
+	return fn
+}
+
+// implementations returns the concrete methods implementing an interface
+// method, or the interface methods implemented by a concrete method.
+func (b *callHierarchyBuilder) implementations(fn *CallHierarchyFunc) []*CallHierarchyFunc {
+	if fn.RecvTypeName == "" {
+		return nil
+	}
+	if !collectUnexporteds && !token.IsExported(fn.RecvTypeName) {
+		return nil
+	}
+	result, err := b.ds.buildImplementationData(b.ds.analyzer, fn.Package.Path, fn.RecvTypeName)
+	if err != nil {
+		return nil
+	}
+
+	var impls []*CallHierarchyFunc
+	for _, mi := range result.Methods {
+		if mi.Method.Name() != fn.Func.Name() {
+			continue
+		}
+		for _, impl := range mi.Implementations {
+			if impl.Interface == (fn.Decl != nil) {
+				if obj, ok := impl.Method.Object().(*types.Func); ok {
+					if f := b.funcOf(obj); f != nil && f != fn {
+						impls = append(impls, f)
+					}
+				}
+			}
+		}
+	}
+	return impls
+}
+
+// callersOf returns the callers of a function, grouped by packages. For a
+// concrete method, the calls through the interface methods it implements
+// are also included.
+func (b *callHierarchyBuilder) callersOf(fn *CallHierarchyFunc) []*CallerGroup {
+	if groups, ok := b.callers[fn]; ok {
+		return groups
+	}
+
+	type callerKey struct {
+		pkg    *code.Package
+		caller *CallHierarchyFunc
+		via    *CallHierarchyFunc
+	}
+	var calls = make(map[callerKey]*CallHierarchyCall)
+	var collect = func(callee, via *CallHierarchyFunc) {
+		for _, id := range b.ds.analyzer.ObjectReferences(callee.Func) {
+			fileInfo := id.FileInfo
+			if fileInfo.AstFile == nil {
+				continue
+			}
+			decl, isCall := b.callSitesIn(fileInfo.AstFile)[id.AstIdent]
+			if !isCall {
+				continue
+			}
+			var caller *CallHierarchyFunc
+			if decl != nil {
+				if obj, ok := fileInfo.Pkg.PPkg.TypesInfo.Defs[decl.Name].(*types.Func); ok {
+					caller = b.funcOf(obj)
+				}
+			}
+			key := callerKey{fileInfo.Pkg, caller, via}
+			call := calls[key]
+			if call == nil {
+				call = &CallHierarchyCall{Func: caller, Pkg: fileInfo.Pkg, Via: via}
+				calls[key] = call
+			}
+			call.Sites = append(call.Sites, fileInfo.Pkg.PPkg.Fset.PositionFor(id.AstIdent.NamePos, false))
+		}
+	}
+
+	collect(fn, nil)
+	if fn.Decl != nil {
+		for _, m := range b.implementations(fn) {
+			collect(m, m)
+		}
+	}
+
+	var groups = make(map[*code.Package]*CallerGroup)
+	var result []*CallerGroup
+	for _, call := range calls {
+		g := groups[call.Pkg]
+		if g == nil {
+			g = &CallerGroup{Pkg: call.Pkg}
+			groups[call.Pkg] = g
+			result = append(result, g)
+		}
+		g.Callers = append(g.Callers, call)
+	}
+	for _, g := range result {
+		for _, call := range g.Callers {
+			sort.Slice(call.Sites, func(i, j int) bool {
+				a, b := call.Sites[i], call.Sites[j]
+				if a.Filename != b.Filename {
+					return a.Filename < b.Filename
+				}
+				return a.Offset < b.Offset
+			})
+		}
+		sortCallHierarchyCalls(g.Callers)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].Pkg, result[j].Pkg
+		if (a == fn.Package) != (b == fn.Package) {
+			return a == fn.Package
+		}
+		return a.Path < b.Path
+	})
+	b.callers[fn] = result
+	return result
+}
+
+// calleesOf returns the functions called in the body of a function. A call
+// to an interface method is followed by the concrete methods implementing
+// the interface method, as dynamic calls.
+func (b *callHierarchyBuilder) calleesOf(fn *CallHierarchyFunc) []*CallHierarchyCall {
+	if dynamics, ok := b.callees[fn]; ok {
+		return dynamics
+	}
+	if fn.Decl == nil || fn.Decl.Body == nil {
+		b.callees[fn] = nil
+		return nil
+	}
+
+	var info = fn.Package.PPkg.TypesInfo
+	var calls = make(map[*CallHierarchyFunc]*CallHierarchyCall)
+	var result []*CallHierarchyCall
+	ast.Inspect(fn.Decl.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		id := callExprFuncIdent(call)
+		if id == nil {
+			return true
+		}
+		obj, ok := info.Uses[id].(*types.Func)
+		if !ok {
+			return true
+		}
+		callee := b.funcOf(obj)
+		if callee == nil {
+			return true
+		}
+		c := calls[callee]
+		if c == nil {
+			c = &CallHierarchyCall{Func: callee, Pkg: fn.Package}
+			calls[callee] = c
+			result = append(result, c)
+		}
+		c.Sites = append(c.Sites, fn.Package.PPkg.Fset.PositionFor(id.NamePos, false))
+		return true
+	})
+
+	sortCallHierarchyCalls(result)
+
+	var dynamics []*CallHierarchyCall
+	for _, c := range result {
+		dynamics = append(dynamics, c)
+		if c.Func.Decl != nil {
+			continue
+		}
+		for _, impl := range b.implementations(c.Func) {
+			dynamics = append(dynamics, &CallHierarchyCall{Func: impl, Pkg: fn.Package, Via: c.Func})
+		}
+	}
+	b.callees[fn] = dynamics
+	return dynamics
+}
+
+func sortCallHierarchyCalls(calls []*CallHierarchyCall) {
+	sort.Slice(calls, func(i, j int) bool {
+		a, b := calls[i], calls[j]
+		if (a.Func == nil) != (b.Func == nil) {
+			return a.Func == nil
+		}
+		if a.Func != nil && a.Func != b.Func {
+			if a.Func.Package != b.Func.Package {
+				return a.Func.Package.Path < b.Func.Package.Path
+			}
+			if a.Func.Identifier() != b.Func.Identifier() {
+				return a.Func.Identifier() < b.Func.Identifier()
+			}
+			// Packages may declare several init functions.
+			if a.Func.Position.Filename != b.Func.Position.Filename {
+				return a.Func.Position.Filename < b.Func.Position.Filename
+			}
+			return a.Func.Position.Offset < b.Func.Position.Offset
+		}
+		if (a.Via == nil) != (b.Via == nil) {
+			return a.Via == nil
+		}
+		if a.Via != nil && a.Via != b.Via {
+			if a.Via.Package != b.Via.Package {
+				return a.Via.Package.Path < b.Via.Package.Path
+			}
+			return a.Via.Identifier() < b.Via.Identifier()
+		}
+		return false
+	})
+}
+
+// callSitesIn returns the identifiers denoting the called functions of
+// the calls in a file, each mapped to the function declaration enclosing
+// the call. The mapped declaration is nil if the call is in a package-level
+// non-function declaration. The result for each file is built only once.
+func (b *callHierarchyBuilder) callSitesIn(file *ast.File) map[*ast.Ident]*ast.FuncDecl {
+	if sites, ok := b.callSites[file]; ok {
+		return sites
+	}
+
+	var sites = make(map[*ast.Ident]*ast.FuncDecl)
+	for _, d := range file.Decls {
+		decl, _ := d.(*ast.FuncDecl)
+		ast.Inspect(d, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if id := callExprFuncIdent(call); id != nil {
+					sites[id] = decl
+				}
+			}
+			return true
+		})
+	}
+	b.callSites[file] = sites
+	return sites
+}
+
+// callExprFuncIdent returns the identifier denoting the called function,
+// for calls like f(), pkg.f(), x.m() and f[T]().
+func callExprFuncIdent(call *ast.CallExpr) *ast.Ident {
+	fun := unparen(call.Fun)
+	switch e := fun.(type) {
+	case *ast.IndexExpr:
+		fun = e.X
+	case *astIndexListExpr:
+		fun = e.X
+	}
+	switch e := unparen(fun).(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
@@ -0,0 +1,350 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) couplingPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "coupling",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildCouplingPage(w)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+// PackageCoupling holds the coupling metrics of a package.
+type PackageCoupling struct {
+	Package *code.Package
+
+	Afferent    int     // Ca, the number of packages importing the package
+	Efferent    int     // Ce, the number of packages imported by the package
+	Instability float64 // Ce / (Ca + Ce), 0 for isolated packages
+}
+
+func buildPackageCoupling(pkg *code.Package) *PackageCoupling {
+	pc := &PackageCoupling{
+		Package:  pkg,
+		Afferent: len(pkg.DepedBys),
+		Efferent: len(pkg.Deps),
+	}
+	if n := pc.Afferent + pc.Efferent; n > 0 {
+		pc.Instability = float64(pc.Efferent) / float64(n)
+	}
+	return pc
+}
+
+// buildModuleCouplings groups the package coupling metrics by modules.
+// The packages not belonging to any modules are put in the last group.
+func (ds *docServer) buildModuleCouplings() (modules []*code.Module, couplings [][]*PackageCoupling) {
+	byModule := make(map[*code.Module][]*PackageCoupling)
+	for i, n := 0, ds.analyzer.NumPackages(); i < n; i++ {
+		pkg := ds.analyzer.PackageAt(i)
+		byModule[pkg.Module()] = append(byModule[pkg.Module()], buildPackageCoupling(pkg))
+	}
+
+	add := func(m *code.Module) {
+		pcs := byModule[m]
+		if len(pcs) == 0 {
+			return
+		}
+		sort.Slice(pcs, func(a, b int) bool {
+			return pcs[a].Package.Path < pcs[b].Package.Path
+		})
+		modules = append(modules, m)
+		couplings = append(couplings, pcs)
+	}
+	ds.analyzer.IterateModule(add)
+	add(nil)
+	return
+}
+
+func (ds *docServer) buildCouplingPage(w http.ResponseWriter) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_PackageCoupling(), ds.currentTheme, ds.currentTranslation, createPagePathInfo(ResTypeNone, "coupling"))
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span>
+`,
+		page.Translation().Text_PackageCoupling(),
+	)
+
+	page.WriteString(page.Translation().Text_CouplingMetricsLegend())
+	fmt.Fprintf(page, "\n\tJSON: ")
+	buildPageHref(page.PathInfo, createPagePathInfo(ResTypeAPI, "coupling"), page, "api:coupling")
+	page.WriteString("\n")
+
+	modules, couplings := ds.buildModuleCouplings()
+	for i, m := range modules {
+		page.WriteString("\n")
+		if m != nil {
+			page.WriteString(`<span class="title">module `)
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypeModule, modulePagePath(m)), page, modulePagePath(m))
+			page.WriteString(`</span> (`)
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypeModule, modulePagePath(m)), page, "DSM", "dsm")
+			page.WriteString(")\n")
+		}
+		page.WriteString("\t<i>   Ca    Ce     I  Height  Depth</i>\n")
+		for _, pc := range couplings[i] {
+			fmt.Fprintf(page, "\t%5d %5d  %.2f  %6d %6d  ", pc.Afferent, pc.Efferent, pc.Instability, pc.Package.DepHeight, pc.Package.DepDepth)
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypeDependency, pc.Package.Path), page, pc.Package.Path)
+			page.WriteString("\n")
+		}
+	}
+	page.WriteString("</code></pre>")
+
+	return page.Done(w)
+}
+
+// ModuleDSM is the dependency structure matrix of the packages in a module.
+// A row imports the columns marked in the row.
+//
+// The packages are layered by their DepHeight values (the lengths of
+// their longest import chains) and ordered by layers from high to low.
+// A package is always higher than the packages it imports, so all
+// imports are above the diagonal, except the ones in import cycles.
+// Import cycles are rejected by the Go toolchain, but they might still
+// be found in packages with errors.
+//
+// The layering rule is based on directories: a package is built on the
+// package in its parent directory, so it is in an upper layer. Importing
+// a package in a descendant directory is a layering violation, except
+// the descendant is under an "internal" directory (see isLayeringViolation).
+type ModuleDSM struct {
+	Module   *code.Module
+	Packages []*code.Package
+	Cells    []DSMCell
+}
+
+// DSMCell is a marked cell in a dependency structure matrix.
+type DSMCell struct {
+	From, To int    // row and column indexes
+	Kind     string // "", "violation" or "cycle"
+}
+
+func (ds *docServer) buildModuleDSM(m *code.Module) *ModuleDSM {
+	dsm := &ModuleDSM{Module: m}
+	for _, pkg := range m.Pkgs {
+		if !pkg.IsFake() {
+			dsm.Packages = append(dsm.Packages, pkg)
+		}
+	}
+	pkgs := dsm.Packages
+	sort.Slice(pkgs, func(a, b int) bool {
+		if pkgs[a].DepHeight != pkgs[b].DepHeight {
+			return pkgs[a].DepHeight > pkgs[b].DepHeight
+		}
+		return pkgs[a].Path < pkgs[b].Path
+	})
+
+	indexes := make(map[*code.Package]int, len(pkgs))
+	for i, pkg := range pkgs {
+		indexes[pkg] = i
+	}
+
+	// Find the strongly connected components (Tarjan's algorithm).
+	components := make([]int, len(pkgs))
+	componentSizes := make([]int, 0, len(pkgs))
+	orders := make([]int, len(pkgs))
+	lows := make([]int, len(pkgs))
+	onStack := make([]bool, len(pkgs))
+	stack := make([]int, 0, len(pkgs))
+	order := 0
+	var connect func(v int)
+	connect = func(v int) {
+		order++
+		orders[v], lows[v] = order, order
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, dep := range pkgs[v].Deps {
+			w, ok := indexes[dep]
+			if !ok {
+				continue
+			}
+			if orders[w] == 0 {
+				connect(w)
+				if lows[w] < lows[v] {
+					lows[v] = lows[w]
+				}
+			} else if onStack[w] && orders[w] < lows[v] {
+				lows[v] = orders[w]
+			}
+		}
+		if lows[v] == orders[v] {
+			c, size := len(componentSizes), 0
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				components[w] = c
+				size++
+				if w == v {
+					break
+				}
+			}
+			componentSizes = append(componentSizes, size)
+		}
+	}
+	for v := range pkgs {
+		if orders[v] == 0 {
+			connect(v)
+		}
+	}
+
+	for i, pkg := range pkgs {
+		for _, dep := range pkg.Deps {
+			j, ok := indexes[dep]
+			if !ok {
+				continue
+			}
+			cell := DSMCell{From: i, To: j}
+			if components[i] == components[j] && componentSizes[components[i]] > 1 {
+				cell.Kind = "cycle"
+			} else if isLayeringViolation(pkg.Path, dep.Path) {
+				cell.Kind = "violation"
+			}
+			dsm.Cells = append(dsm.Cells, cell)
+		}
+	}
+	sort.Slice(dsm.Cells, func(a, b int) bool {
+		if dsm.Cells[a].From != dsm.Cells[b].From {
+			return dsm.Cells[a].From < dsm.Cells[b].From
+		}
+		return dsm.Cells[a].To < dsm.Cells[b].To
+	})
+
+	return dsm
+}
+
+// isLayeringViolation reports whether an import from the package at path
+// from to the package at path to breaks the directory-based layer order.
+// The internal packages of a package are its implementation details,
+// so they are allowed to be imported.
+func isLayeringViolation(from, to string) bool {
+	if !strings.HasPrefix(to, from+"/") {
+		return false
+	}
+	for _, elem := range strings.Split(to[len(from)+1:], "/") {
+		if elem == "internal" {
+			return false
+		}
+	}
+	return true
+}
+
+// SVG renders the matrix. The row labels are linked to the package dependency pages.
+// The layers are separated by thick lines.
+func (dsm *ModuleDSM) SVG(pathInfo pagePathInfo) []byte {
+	const charW, cellSize, marginH, marginV, headerH = 7.2, 16, 8, 8, 28
+
+	n := len(dsm.Packages)
+	numberW := len(fmt.Sprint(n)) + 1
+	labelW := 0
+	for _, pkg := range dsm.Packages {
+		if len(pkg.Path) > labelW {
+			labelW = len(pkg.Path)
+		}
+	}
+	matrixX := marginH + float64(numberW+labelW+1)*charW
+	matrixY := float64(marginV + headerH)
+	matrixW := float64(n * cellSize)
+	svgW := matrixX + matrixW + marginH
+	svgH := matrixY + matrixW + marginV
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024*16))
+	fmt.Fprintf(buf, `<svg width="%.0f" height="%.0f" xmlns="http://www.w3.org/2000/svg">
+<title>%s</title>
+<style>
+text {font-family: "Courier New", Courier, monospace; font-size: 12px;}
+text.index {font-size: 9px;}
+line {stroke: #ccc; stroke-width: 1;}
+line.layer {stroke: #777; stroke-width: 2;}
+rect.diagonal {fill: #ddd;}
+rect.import {fill: #555;}
+rect.violation {fill: #e90;}
+rect.cycle {fill: #d22;}
+a:hover text {fill: #079;}
+</style>
+<rect fill="#fff" width="%.0f" height="%.0f"/>
+`,
+		svgW, svgH, html.EscapeString(modulePagePath(dsm.Module)), svgW, svgH,
+	)
+
+	for i, pkg := range dsm.Packages {
+		y := matrixY + float64(i*cellSize)
+		fmt.Fprintf(buf, `<a href="%s" target="_top"><text x="%d" y="%.1f" fill="#000">%*d %s</text></a>
+`,
+			html.EscapeString(buildPageHref(pathInfo, createPagePathInfo1(ResTypeDependency, pkg.Path), nil, "")),
+			marginH, y+cellSize-4, numberW-1, i+1, html.EscapeString(pkg.Path),
+		)
+		fmt.Fprintf(buf, `<text class="index" text-anchor="middle" x="%.1f" y="%.1f" fill="#000">%d</text>
+`,
+			matrixX+float64(i*cellSize)+cellSize/2, matrixY-6, i+1,
+		)
+		fmt.Fprintf(buf, `<rect class="diagonal" x="%.1f" y="%.1f" width="%d" height="%d"/>
+`,
+			matrixX+float64(i*cellSize), y, cellSize, cellSize,
+		)
+	}
+
+	for i := 0; i <= n; i++ {
+		class := ""
+		if i > 0 && i < n && dsm.Packages[i].DepHeight != dsm.Packages[i-1].DepHeight {
+			class = ` class="layer"`
+		}
+		d := float64(i * cellSize)
+		fmt.Fprintf(buf, `<line%s x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/><line%s x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>
+`,
+			class, matrixX, matrixY+d, matrixX+matrixW, matrixY+d,
+			class, matrixX+d, matrixY, matrixX+d, matrixY+matrixW,
+		)
+	}
+
+	for _, cell := range dsm.Cells {
+		class := cell.Kind
+		if class == "" {
+			class = "import"
+		}
+		fmt.Fprintf(buf, `<rect class="%s" x="%.1f" y="%.1f" width="%d" height="%d"><title>%s → %s</title></rect>
+`,
+			class,
+			matrixX+float64(cell.To*cellSize)+2, matrixY+float64(cell.From*cellSize)+2, cellSize-4, cellSize-4,
+			html.EscapeString(dsm.Packages[cell.From].Path), html.EscapeString(dsm.Packages[cell.To].Path),
+		)
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+// writeModuleDSM writes the dependency structure matrix section of a module page.
+// Like writeDependencyGraph, it should be called after the main <pre> block is closed.
+func (ds *docServer) writeModuleDSM(page *htmlPage, modulePagePath string) {
+	fmt.Fprint(page, "\n", `<pre><code><span class="title" id="dsm">`, page.Translation().Text_DependencyStructureMatrix(), `</span>`)
+	page.WriteString(page.Translation().Text_DependencyStructureMatrixLegend())
+	page.WriteString("</code></pre>\n")
+	fmt.Fprintf(page, `<div class="dependency-graph"><object type="image/svg+xml" data="%s"></object></div>
+`,
+		buildPageHref(page.PathInfo, createDependencyGraphPathInfo(dependencyGraphKind_DSM, dependencyGraphFormat_SVG, modulePagePath), nil, ""),
+	)
+}
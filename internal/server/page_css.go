@@ -89,22 +89,32 @@ div.alphabet .importedbys {display: none;}
 div.alphabet .codelines {display: none;}
 div.alphabet .depdepth {display: none;}
 div.alphabet .depheight {display: none;}
+div.alphabet .uses {display: none;}
 div.importedbys .importedbys {display: inline;}
 div.importedbys .codelines {display: none;}
 div.importedbys .depdepth {display: none;}
 div.importedbys .depheight {display: none;}
+div.importedbys .uses {display: none;}
 div.depdepth .depdepth {display: inline;}
 div.depdepth .codelines {display: none;}
 div.depdepth .importedbys {display: none;}
 div.depdepth .depheight {display: none;}
+div.depdepth .uses {display: none;}
 div.depheight .depheight {display: inline;}
 div.depheight .codelines {display: none;}
 div.depheight .importedbys {display: none;}
 div.depheight .depdepth {display: none;}
+div.depheight .uses {display: none;}
 div.codelines .codelines {display: inline;}
 div.codelines .depheight {display: none;}
 div.codelines .importedbys {display: none;}
 div.codelines .depdepth {display: none;}
+div.codelines .uses {display: none;}
+div.uses .uses {display: inline;}
+div.uses .codelines {display: none;}
+div.uses .importedbys {display: none;}
+div.uses .depdepth {display: none;}
+div.uses .depheight {display: none;}
 
 /* package details page */
 
@@ -127,6 +137,17 @@ input.showhide:checked ~ span.hidden {display: inline;}
 input.showhide:checked ~ div.hidden {display: block;}
 input.showhide2:checked ~ span.hidden {display: inline;}
 
+input.deprecated-showhide {display: none;}
+input.deprecated-showhide:checked + i .show-inline {display: none;}
+input.deprecated-showhide:checked + i .hide-inline {display: inline;}
+input.deprecated-showhide:not(:checked) ~ div .deprecated {display: none;}
+input.deprecated-showhide ~ div div.deprecated:target {display: block;}
+
+/* dependency graph */
+
+div.dependency-graph {overflow: auto; max-height: 80vh;}
+div.dependency-graph object {display: block;}
+
 /* code page */
 
 pre.line-numbers {
@@ -0,0 +1,573 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+// Dependency graphs are served as svg resources. Their resource paths are
+//
+//	deps[.format]/package-import-path
+//	mod-deps[.format]/module-page-path
+//	dsm/module-page-path
+//
+// where the optional format is "dot" or "graphml".
+// The "dsm" ones are dependency structure matrices (see ModuleDSM).
+// In server mode, the "depth" and "module" query parameters
+// limit the graph depth and filter the graph nodes by module.
+const (
+	dependencyGraphKind_Package = "deps"
+	dependencyGraphKind_Module  = "mod-deps"
+	dependencyGraphKind_DSM     = "dsm"
+
+	dependencyGraphFormat_SVG     = "svg"
+	dependencyGraphFormat_DOT     = "dot"
+	dependencyGraphFormat_GraphML = "graphml"
+)
+
+// The default depths used when the "depth" query parameter is absent
+// (always absent in docs generation mode). 0 means unlimited.
+func defaultDependencyGraphDepth(kind string) int {
+	if kind == dependencyGraphKind_Package {
+		return 3
+	}
+	return 0
+}
+
+func createDependencyGraphPathInfo(kind, format, target string) pagePathInfo {
+	if format != dependencyGraphFormat_SVG {
+		kind += "." + format
+	}
+	return createPagePathInfo1(ResTypeSVG, kind+"/"+target)
+}
+
+// parseDependencyGraphResPath parses svg resource paths
+// in the form described above. ok is false for other svg resources.
+func parseDependencyGraphResPath(resPath string) (kind, format, target string, ok bool) {
+	i := strings.IndexByte(resPath, '/')
+	if i < 0 {
+		return
+	}
+	kind, target = resPath[:i], resPath[i+1:]
+	format = dependencyGraphFormat_SVG
+	if k := strings.IndexByte(kind, '.'); k >= 0 {
+		kind, format = kind[:k], kind[k+1:]
+	}
+	switch kind {
+	default:
+		return
+	case dependencyGraphKind_Package, dependencyGraphKind_Module:
+	case dependencyGraphKind_DSM:
+		if format != dependencyGraphFormat_SVG {
+			return
+		}
+	}
+	switch format {
+	default:
+		return
+	case dependencyGraphFormat_SVG, dependencyGraphFormat_DOT, dependencyGraphFormat_GraphML:
+	}
+	return kind, format, target, target != ""
+}
+
+// dependencyGraphFileExt returns the generated file extension
+// of a dependency graph data file, or "" for other resources.
+func dependencyGraphFileExt(pathInfo pagePathInfo) string {
+	if pathInfo.resType != ResTypeSVG {
+		return ""
+	}
+	if _, format, _, ok := parseDependencyGraphResPath(pathInfo.resPath); ok && format != dependencyGraphFormat_SVG {
+		return "." + format
+	}
+	return ""
+}
+
+type dependencyGraphOptions struct {
+	Depth  int
+	Module string // module page path, blank means no filtering
+}
+
+func (ds *docServer) dependencyGraphFile(w http.ResponseWriter, r *http.Request, kind, format, target string) {
+	switch format {
+	case dependencyGraphFormat_SVG:
+		w.Header().Set("Content-Type", "image/svg+xml")
+	case dependencyGraphFormat_DOT:
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+	case dependencyGraphFormat_GraphML:
+		w.Header().Set("Content-Type", "application/graphml+xml")
+	}
+
+	if genDocsMode {
+		target = deHashScope(target)
+	}
+
+	options := dependencyGraphOptions{
+		Depth:  defaultDependencyGraphDepth(kind),
+		Module: r.FormValue("module"),
+	}
+	if depth := r.FormValue("depth"); depth != "" {
+		if d, err := strconv.Atoi(depth); err == nil && d >= 0 {
+			options.Depth = d
+		}
+	}
+
+	pathInfo := createDependencyGraphPathInfo(kind, format, target)
+	pageKey := pageCacheKey{
+		resType: ResTypeSVG,
+		res:     pathInfo.resPath,
+		options: options,
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		switch kind {
+		case dependencyGraphKind_DSM:
+			m := ds.analyzer.ModuleByPath(target)
+			if m == nil {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "Module (%s) not found", target)
+				return
+			}
+			data = ds.buildModuleDSM(m).SVG(pathInfo)
+		default:
+			var graph *dependencyGraph
+			if kind == dependencyGraphKind_Package {
+				graph = ds.buildPackageDependencyGraph(pathInfo, target, options)
+			} else {
+				graph = ds.buildModuleDependencyGraph(pathInfo, target, options)
+			}
+			if graph == nil {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "Dependency graph target (%s) not found", target)
+				return
+			}
+
+			switch format {
+			case dependencyGraphFormat_SVG:
+				data = graph.SVG()
+			case dependencyGraphFormat_DOT:
+				data = graph.DOT()
+			case dependencyGraphFormat_GraphML:
+				data = graph.GraphML()
+			}
+		}
+		ds.cachePage(pageKey, data)
+
+		// For docs generation.
+		page := NewHtmlPage(goldsVersion, "", nil, ds.currentTranslation, pathInfo)
+		page.Write(data)
+		_ = page.Done(w)
+	}
+	w.Write(data)
+}
+
+type dependencyGraph struct {
+	Title string
+	Nodes []*dependencyGraphNode
+	Edges [][2]int // node indexes
+}
+
+type dependencyGraphNode struct {
+	Label  string
+	Module string // module page path
+	Height int32  // nodes with larger heights are drawn above
+	Href   string // relative to the graph file
+	Class  string // "root", "local" or "other"
+}
+
+// buildPackageDependencyGraph builds the graph of the transitive imports of a package.
+// The nodes are layered by their DepHeight values.
+func (ds *docServer) buildPackageDependencyGraph(pathInfo pagePathInfo, pkgPath string, options dependencyGraphOptions) *dependencyGraph {
+	root := ds.analyzer.PackageByPath(pkgPath)
+	if root == nil {
+		return nil
+	}
+
+	depths := map[*code.Package]int{root: 0}
+	pkgs := []*code.Package{root}
+	for i := 0; i < len(pkgs); i++ {
+		pkg := pkgs[i]
+		if options.Depth > 0 && depths[pkg] >= options.Depth {
+			continue
+		}
+		for _, dep := range pkg.Deps {
+			if _, seen := depths[dep]; !seen {
+				depths[dep] = depths[pkg] + 1
+				pkgs = append(pkgs, dep)
+			}
+		}
+	}
+
+	rootModule := packageModulePagePath(root)
+	graph := &dependencyGraph{Title: pkgPath}
+	indexes := make(map[*code.Package]int, len(pkgs))
+	for _, pkg := range pkgs {
+		module := packageModulePagePath(pkg)
+		if pkg != root && options.Module != "" && module != options.Module {
+			continue
+		}
+		node := &dependencyGraphNode{
+			Label:  pkg.Path,
+			Module: module,
+			Height: pkg.DepHeight,
+			Href:   buildPageHref(pathInfo, createPagePathInfo1(ResTypeDependency, pkg.Path), nil, ""),
+			Class:  "other",
+		}
+		if pkg == root {
+			node.Class = "root"
+		} else if module == rootModule {
+			node.Class = "local"
+		}
+		indexes[pkg] = len(graph.Nodes)
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	for _, pkg := range pkgs {
+		from, ok := indexes[pkg]
+		if !ok || options.Depth > 0 && depths[pkg] >= options.Depth {
+			continue
+		}
+		for _, dep := range pkg.Deps {
+			if to, ok := indexes[dep]; ok {
+				graph.Edges = append(graph.Edges, [2]int{from, to})
+			}
+		}
+	}
+
+	return graph
+}
+
+func packageModulePagePath(pkg *code.Package) string {
+	if m := pkg.Module(); m != nil {
+		return modulePagePath(m)
+	}
+	return ""
+}
+
+// buildModuleDependencyGraph builds the graph of the transitive requirements of a module.
+// Module requirements are derived from the package imports and
+// the module heights are derived from the package DepHeight values.
+func (ds *docServer) buildModuleDependencyGraph(pathInfo pagePathInfo, target string, options dependencyGraphOptions) *dependencyGraph {
+	root := ds.analyzer.ModuleByPath(target)
+	if root == nil {
+		return nil
+	}
+
+	// The packages are sorted by their DepHeight values, so the
+	// heights of the required modules are mostly calculated before
+	// the height of a module. Cyclic module requirements are possible.
+	heights := make(map[*code.Module]int32)
+	requires := make(map[*code.Module][]*code.Module)
+	for i, n := 0, ds.analyzer.NumPackages(); i < n; i++ {
+		pkg := ds.analyzer.PackageAt(i)
+		m := pkg.Module()
+		if m == nil {
+			continue
+		}
+		h := heights[m]
+		if h == 0 {
+			h = 1
+		}
+	NextDep:
+		for _, dep := range pkg.Deps {
+			dm := dep.Module()
+			if dm == nil || dm == m {
+				continue
+			}
+			if heights[dm] >= h {
+				h = heights[dm] + 1
+			}
+			for _, rm := range requires[m] {
+				if rm == dm {
+					continue NextDep
+				}
+			}
+			requires[m] = append(requires[m], dm)
+		}
+		heights[m] = h
+	}
+
+	depths := map[*code.Module]int{root: 0}
+	modules := []*code.Module{root}
+	for i := 0; i < len(modules); i++ {
+		m := modules[i]
+		if options.Depth > 0 && depths[m] >= options.Depth {
+			continue
+		}
+		for _, rm := range requires[m] {
+			if _, seen := depths[rm]; !seen {
+				depths[rm] = depths[m] + 1
+				modules = append(modules, rm)
+			}
+		}
+	}
+
+	graph := &dependencyGraph{Title: target}
+	indexes := make(map[*code.Module]int, len(modules))
+	for _, m := range modules {
+		node := &dependencyGraphNode{
+			Label:  modulePagePath(m),
+			Module: modulePagePath(m),
+			Height: heights[m],
+			Href:   buildPageHref(pathInfo, createPagePathInfo1(ResTypeModule, modulePagePath(m)), nil, ""),
+			Class:  "other",
+		}
+		if m == root {
+			node.Class = "root"
+		}
+		indexes[m] = len(graph.Nodes)
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	for _, m := range modules {
+		if options.Depth > 0 && depths[m] >= options.Depth {
+			continue
+		}
+		for _, rm := range requires[m] {
+			if to, ok := indexes[rm]; ok {
+				graph.Edges = append(graph.Edges, [2]int{indexes[m], to})
+			}
+		}
+	}
+
+	return graph
+}
+
+// layers groups the node indexes by node heights, from the highest to the lowest.
+// The nodes in a layer are sorted by their labels.
+func (g *dependencyGraph) layers() [][]int {
+	byHeight := make(map[int32][]int)
+	heights := make([]int32, 0, 16)
+	for i, node := range g.Nodes {
+		if _, ok := byHeight[node.Height]; !ok {
+			heights = append(heights, node.Height)
+		}
+		byHeight[node.Height] = append(byHeight[node.Height], i)
+	}
+	sort.Slice(heights, func(a, b int) bool {
+		return heights[a] > heights[b]
+	})
+
+	layers := make([][]int, len(heights))
+	for i, h := range heights {
+		layer := byHeight[h]
+		sort.Slice(layer, func(a, b int) bool {
+			return g.Nodes[layer[a]].Label < g.Nodes[layer[b]].Label
+		})
+		layers[i] = layer
+	}
+	return layers
+}
+
+// SVG renders the graph layer by layer. Hovering a node highlights its edges.
+func (g *dependencyGraph) SVG() []byte {
+	const charW, nodeH, nodePaddingH = 7.2, 20, 6
+	const nodeMarginH, layerMarginV, marginH, marginV = 12, 48, 8, 8
+
+	layers := g.layers()
+	xs := make([]float64, len(g.Nodes))
+	ys := make([]float64, len(g.Nodes))
+	ws := make([]float64, len(g.Nodes))
+
+	svgW := 0.0
+	layerWidths := make([]float64, len(layers))
+	for i, layer := range layers {
+		for k, n := range layer {
+			ws[n] = float64(len(g.Nodes[n].Label))*charW + 2*nodePaddingH
+			if k > 0 {
+				layerWidths[i] += nodeMarginH
+			}
+			layerWidths[i] += ws[n]
+		}
+		if layerWidths[i] > svgW {
+			svgW = layerWidths[i]
+		}
+	}
+	for i, layer := range layers {
+		x := marginH + (svgW-layerWidths[i])/2
+		for _, n := range layer {
+			xs[n] = x
+			ys[n] = float64(marginV + i*(nodeH+layerMarginV))
+			x += ws[n] + nodeMarginH
+		}
+	}
+	svgW += 2 * marginH
+	svgH := 2*marginV + len(layers)*nodeH
+	if len(layers) > 1 {
+		svgH += (len(layers) - 1) * layerMarginV
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024*16))
+	fmt.Fprintf(buf, `<svg width="%.0f" height="%d" xmlns="http://www.w3.org/2000/svg">
+<title>%s</title>
+<style>
+text {font-family: "Courier New", Courier, monospace; font-size: 12px;}
+rect {stroke: #777; stroke-width: 1;}
+.root rect {fill: #d0eeff; stroke: #079; stroke-width: 2;}
+.local rect {fill: #eef8ff;}
+.other rect {fill: #fff;}
+a:hover rect {stroke: #079; stroke-width: 2;}
+path {fill: none; stroke: #999; stroke-opacity: 0.6;}
+path.highlighted {stroke: #c33; stroke-opacity: 1; stroke-width: 2;}
+</style>
+<script>
+function highlightEdges(n, on) {
+	var edges = document.querySelectorAll("path.from-" + n + ", path.to-" + n);
+	for (var i = 0; i &lt; edges.length; i++) {
+		edges[i].classList.toggle("highlighted", on);
+	}
+}
+</script>
+<rect fill="#fff" stroke="none" width="%.0f" height="%d"/>
+`,
+		svgW, svgH, html.EscapeString(g.Title), svgW, svgH,
+	)
+
+	for _, e := range g.Edges {
+		from, to := e[0], e[1]
+		x1, y1 := xs[from]+ws[from]/2, ys[from]+nodeH
+		x2, y2 := xs[to]+ws[to]/2, ys[to]
+		if y2 <= y1 { // cyclic module requirements
+			y1, y2 = ys[from], ys[to]+nodeH
+		}
+		midY := (y1 + y2) / 2
+		fmt.Fprintf(buf, `<path class="from-%d to-%d" d="M%.1f %.1f C%.1f %.1f %.1f %.1f %.1f %.1f"/>
+`,
+			from, to, x1, y1, x1, midY, x2, midY, x2, y2,
+		)
+	}
+
+	for n, node := range g.Nodes {
+		title := node.Label
+		if node.Module != "" && node.Module != node.Label {
+			title += " (" + node.Module + ")"
+		}
+		fmt.Fprintf(buf, `<a class="%s" href="%s" target="_top" onmouseover="highlightEdges(%[3]d, true)" onmouseout="highlightEdges(%[3]d, false)"><title>%s</title><rect x="%.1f" y="%.1f" width="%.1f" height="%d" rx="3"/><text x="%.1f" y="%.1f" fill="#000">%s</text></a>
+`,
+			node.Class, html.EscapeString(node.Href), n, html.EscapeString(title),
+			xs[n], ys[n], ws[n], nodeH,
+			xs[n]+nodePaddingH, ys[n]+nodeH-6, html.EscapeString(node.Label),
+		)
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+// DOT renders the graph in the Graphviz DOT language.
+// The nodes of a layer are placed in a same rank.
+func (g *dependencyGraph) DOT() []byte {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024*16))
+	fmt.Fprintf(buf, "digraph %s {\n", quote(g.Title))
+	buf.WriteString("\tnode [shape=box, fontname=\"Courier\"];\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(buf, "\t%s [module=%s];\n", quote(node.Label), quote(node.Module))
+	}
+	for _, layer := range g.layers() {
+		if len(layer) < 2 {
+			continue
+		}
+		buf.WriteString("\t{rank=same;")
+		for _, n := range layer {
+			buf.WriteByte(' ')
+			buf.WriteString(quote(g.Nodes[n].Label))
+			buf.WriteByte(';')
+		}
+		buf.WriteString("}\n")
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(buf, "\t%s -> %s;\n", quote(g.Nodes[e[0]].Label), quote(g.Nodes[e[1]].Label))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// GraphML renders the graph in the GraphML format.
+func (g *dependencyGraph) GraphML() []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, 1024*16))
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+	<key id="label" for="node" attr.name="label" attr.type="string"/>
+	<key id="module" for="node" attr.name="module" attr.type="string"/>
+	<key id="height" for="node" attr.name="height" attr.type="int"/>
+`)
+	fmt.Fprintf(buf, "\t<graph id=%q edgedefault=\"directed\">\n", html.EscapeString(g.Title))
+	for n, node := range g.Nodes {
+		title := node.Label
+		if node.Module != "" && node.Module != node.Label {
+			title += " (" + node.Module + ")"
+		}
+		fmt.Fprintf(buf, "\t\t<node id=\"n%d\"><data key=\"label\">%s</data><data key=\"module\">%s</data><data key=\"height\">%d</data></node>\n",
+			n, html.EscapeString(node.Label), html.EscapeString(node.Module), node.Height,
+		)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(buf, "\t\t<edge source=\"n%d\" target=\"n%d\"/>\n", e[0], e[1])
+	}
+	buf.WriteString("\t</graph>\n</graphml>\n")
+	return buf.Bytes()
+}
+
+// writeDependencyGraph writes a dependency graph section,
+// including the graph and the links to download the graph data.
+// It should be called after the main <pre> block of a page is closed.
+// The depth and module selectors are only available in server mode.
+func (ds *docServer) writeDependencyGraph(page *htmlPage, kind, target string, modules []string) {
+	fmt.Fprint(page, "\n", `<pre><code><span class="title" id="dependency-graph">`, page.Translation().Text_DependencyGraph(), `</span>`)
+
+	svgHref := buildPageHref(page.PathInfo, createDependencyGraphPathInfo(kind, dependencyGraphFormat_SVG, target), nil, "")
+
+	if !genDocsMode {
+		fmt.Fprintf(page, "\n\t%s: ", page.Translation().Text_DependencyGraphOption("depth"))
+		page.WriteString(`<select id="graph-depth">`)
+		for _, d := range []int{1, 2, 3, 4, 5, 0} {
+			selected := ""
+			if d == defaultDependencyGraphDepth(kind) {
+				selected = " selected"
+			}
+			text := strconv.Itoa(d)
+			if d == 0 {
+				text = page.Translation().Text_DependencyGraphOption("all")
+			}
+			fmt.Fprintf(page, `<option value="%d"%s>%s</option>`, d, selected, text)
+		}
+		page.WriteString(`</select>`)
+		if len(modules) > 1 {
+			fmt.Fprintf(page, "  %s: ", page.Translation().Text_DependencyGraphOption("module"))
+			page.WriteString(`<select id="graph-module">`)
+			fmt.Fprintf(page, `<option value="">%s</option>`, page.Translation().Text_DependencyGraphOption("all"))
+			for _, m := range modules {
+				fmt.Fprintf(page, `<option value="%[1]s">%[1]s</option>`, html.EscapeString(m))
+			}
+			page.WriteString(`</select>`)
+		}
+	}
+
+	fmt.Fprintf(page, "\n\t%s: ", page.Translation().Text_DependencyGraphOption("download"))
+	name := path.Base(target) + "-" + kind
+	for i, f := range []struct{ format, text string }{
+		{dependencyGraphFormat_DOT, "DOT"},
+		{dependencyGraphFormat_GraphML, "GraphML"},
+	} {
+		if i > 0 {
+			page.WriteString(", ")
+		}
+		fmt.Fprintf(page, `<a class="graph-data" href="%s" data-href="%[1]s" download="%s.%s">%s</a>`,
+			buildPageHref(page.PathInfo, createDependencyGraphPathInfo(kind, f.format, target), nil, ""),
+			name, f.format, f.text,
+		)
+	}
+
+	fmt.Fprintf(page, "\n</code></pre>\n<div class=\"dependency-graph\"><object id=\"graph-object\" type=\"image/svg+xml\" data=\"%s\" data-href=\"%[1]s\"></object></div>\n", svgHref)
+}
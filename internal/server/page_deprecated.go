@@ -0,0 +1,313 @@
+package server
+
+import (
+	"fmt"
+	"go/types"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) deprecatedUsesPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "deprecated",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildDeprecatedUsesPage(w, ds.buildDeprecatedUses())
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+// DeprecatedUses holds the deprecated packages imported by and the
+// deprecated identifiers used in the working directory packages.
+type DeprecatedUses struct {
+	Packages    []*DeprecatedPackageImports
+	Identifiers []*DeprecatedObjectUses
+}
+
+// DeprecatedPackageImports holds the working directory packages
+// importing a deprecated package.
+type DeprecatedPackageImports struct {
+	Package    *code.Package
+	ImportedBy []*code.Package
+}
+
+// DeprecatedObjectUses holds the uses of a deprecated object
+// in the working directory packages.
+type DeprecatedObjectUses struct {
+	Package    *code.Package // the package declaring the object
+	Object     types.Object
+	Identifier string // might be a selector path, like "Type.Method"
+	Uses       []code.Identifier
+}
+
+func (ds *docServer) isWorkingDirectoryPackage(pkg *code.Package) bool {
+	return ds.analyzer.IsWorkingDirectoryModule(pkg.Module())
+}
+
+// ds.mutex should be locked before calling this method.
+func (ds *docServer) buildDeprecatedUses() *DeprecatedUses {
+	var result DeprecatedUses
+
+	for i, n := 0, ds.analyzer.NumPackages(); i < n; i++ {
+		pkg := ds.analyzer.PackageAt(i)
+		if !pkg.Deprecated {
+			continue
+		}
+		var importedBy []*code.Package
+		for _, dep := range pkg.DepedBys {
+			if ds.isWorkingDirectoryPackage(dep) {
+				importedBy = append(importedBy, dep)
+			}
+		}
+		if len(importedBy) > 0 {
+			sort.Slice(importedBy, func(a, b int) bool {
+				return importedBy[a].Path < importedBy[b].Path
+			})
+			result.Packages = append(result.Packages, &DeprecatedPackageImports{
+				Package:    pkg,
+				ImportedBy: importedBy,
+			})
+		}
+	}
+	sort.Slice(result.Packages, func(a, b int) bool {
+		return result.Packages[a].Package.Path < result.Packages[b].Package.Path
+	})
+
+	for _, obj := range ds.analyzer.DeprecatedObjects() {
+		if obj.Pkg() == nil {
+			continue
+		}
+		var uses []code.Identifier
+		for _, id := range ds.analyzer.ObjectReferences(obj) {
+			if id.FileInfo.Pkg.PPkg.Types != obj.Pkg() && ds.isWorkingDirectoryPackage(id.FileInfo.Pkg) {
+				uses = append(uses, id)
+			}
+		}
+		if len(uses) == 0 {
+			continue
+		}
+		pkg := ds.analyzer.PackageByPath(obj.Pkg().Path())
+		if pkg == nil {
+			continue
+		}
+		identifier := ds.objectIdentifierPath(pkg, obj)
+		if identifier == "" {
+			continue
+		}
+		sortIdentifiersByPosition(uses)
+		result.Identifiers = append(result.Identifiers, &DeprecatedObjectUses{
+			Package:    pkg,
+			Object:     obj,
+			Identifier: identifier,
+			Uses:       uses,
+		})
+	}
+	sort.Slice(result.Identifiers, func(a, b int) bool {
+		idA, idB := result.Identifiers[a], result.Identifiers[b]
+		if idA.Package.Path != idB.Package.Path {
+			return idA.Package.Path < idB.Package.Path
+		}
+		return idA.Identifier < idB.Identifier
+	})
+
+	return &result
+}
+
+// objectIdentifierPath returns the identifier path used in the reference
+// page path of the specified object, such as "Name", "Type.Method",
+// "Type.field" and "Type.field.nestedField". Blank is returned if the
+// object is not declared at package level or as a selector of
+// a package-level type or variable.
+//
+// ds.mutex should be locked before calling this method.
+func (ds *docServer) objectIdentifierPath(pkg *code.Package, obj types.Object) string {
+	if obj.Parent() == obj.Pkg().Scope() {
+		return obj.Name()
+	}
+
+	switch o := obj.(type) {
+	case *types.Func:
+		sig, ok := o.Type().(*types.Signature)
+		if !ok || sig.Recv() == nil {
+			return ""
+		}
+		t := sig.Recv().Type()
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if named, ok := t.(*types.Named); ok {
+			return named.Obj().Name() + "." + o.Name()
+		}
+	case *types.Var:
+		if !o.IsField() {
+			return ""
+		}
+		if path := ds.nestedFieldPath(pkg, o); path != "" {
+			return path
+		}
+		for _, tn := range pkg.AllTypeNames {
+			if tn.TypeName.IsAlias() {
+				continue
+			}
+			st, ok := tn.TypeName.Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			for i := 0; i < st.NumFields(); i++ {
+				if st.Field(i) == o {
+					return tn.Name() + "." + o.Name()
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func (ds *docServer) buildDeprecatedUsesPage(w http.ResponseWriter, result *DeprecatedUses) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_DeprecatedUses(), ds.currentTheme, ds.currentTranslation, createPagePathInfo(ResTypeNone, "deprecated"))
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span>
+`,
+		page.Translation().Text_DeprecatedUses(),
+	)
+
+	page.WriteString(page.Translation().Text_DeprecatedUsesLegend())
+
+	page.WriteString("\n")
+	page.WriteString(`<span class="title">`)
+	page.WriteString(page.Translation().Text_DeprecatedUsesSection("packages", len(result.Packages)))
+	page.WriteString("</span>\n")
+	for _, dp := range result.Packages {
+		page.WriteString("\n\t")
+		buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, dp.Package.Path), page, dp.Package.Path)
+		page.WriteString("\n")
+		for _, by := range dp.ImportedBy {
+			page.WriteString("\t\t")
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypeDependency, by.Path), page, by.Path)
+			page.WriteString("\n")
+		}
+	}
+
+	page.WriteString("\n")
+	page.WriteString(`<span class="title">`)
+	page.WriteString(page.Translation().Text_DeprecatedUsesSection("identifiers", len(result.Identifiers)))
+	page.WriteString("</span>\n")
+	for _, du := range result.Identifiers {
+		page.WriteString("\n\t")
+		buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, du.Package.Path), page, du.Package.Path)
+		page.WriteByte('.')
+		writeIdentifierPathReferenceLink(page, du.Package, du.Identifier)
+		page.WriteString(`<i>`)
+		page.WriteString(page.Translation().Text_Parenthesis(false))
+		page.WriteString(page.Translation().Text_ObjectUses(len(du.Uses)))
+		page.WriteString(page.Translation().Text_Parenthesis(true))
+		page.WriteString(`</i>`)
+		page.WriteString("\n")
+		writeIdentifierUseSites(page, du.Uses)
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+// writeDeprecatedMark writes a mark for a deprecated package or identifier.
+func writeDeprecatedMark(page *htmlPage) {
+	page.WriteString(`<i class="deprecated-mark">`)
+	page.WriteString(page.Translation().Text_Parenthesis(false))
+	page.WriteString(page.Translation().Text_Deprecated())
+	page.WriteString(page.Translation().Text_Parenthesis(true))
+	page.WriteString(`</i>`)
+}
+
+// writeDeprecatedMarkForObject writes a deprecation mark
+// if the specified object is deprecated.
+func (ds *docServer) writeDeprecatedMarkForObject(page *htmlPage, obj types.Object) {
+	if ds.analyzer.IsObjectDeprecated(obj) {
+		writeDeprecatedMark(page)
+	}
+}
+
+// writeDeprecatedItemsToggle writes a checkbox to show/hide the items
+// (with the "deprecated" class) in the following sibling div elements.
+// The items are hidden initially.
+func writeDeprecatedItemsToggle(page *htmlPage, id string, num int, kind string) {
+	showLabel := page.Translation().Text_DeprecatedItemsHeader(true, num, kind)
+	hideLabel := page.Translation().Text_DeprecatedItemsHeader(false, num, kind)
+
+	fmt.Fprintf(page, `<input type='checkbox' class="deprecated-showhide" id="%[1]s"><i><label for="%[1]s" class="show-inline">%[2]s</label><label for="%[1]s" class="hide-inline">%[3]s</label></i>`,
+		id, showLabel, hideLabel)
+}
+
+// numDeprecatedUses returns the number of the deprecated packages and
+// identifiers used in the working directory packages.
+//
+// ds.mutex should be locked before calling this method.
+func (ds *docServer) numDeprecatedUses() int {
+	result := ds.buildDeprecatedUses()
+	return len(result.Packages) + len(result.Identifiers)
+}
+
+// sortIdentifiersByPosition sorts identifiers by their package paths,
+// file names and offsets.
+func sortIdentifiersByPosition(ids []code.Identifier) {
+	sort.Slice(ids, func(a, b int) bool {
+		if pathA, pathB := ids[a].FileInfo.Pkg.Path, ids[b].FileInfo.Pkg.Path; pathA != pathB {
+			return pathA < pathB
+		}
+		posA := ids[a].FileInfo.Pkg.PPkg.Fset.PositionFor(ids[a].AstIdent.Pos(), false)
+		posB := ids[b].FileInfo.Pkg.PPkg.Fset.PositionFor(ids[b].AstIdent.Pos(), false)
+		if posA.Filename != posB.Filename {
+			return posA.Filename < posB.Filename
+		}
+		return posA.Offset < posB.Offset
+	})
+}
+
+// writeIdentifierPathReferenceLink writes the link to the reference page
+// of an identifier path (returned by objectIdentifierPath).
+func writeIdentifierPathReferenceLink(page *htmlPage, pkg *code.Package, identifier string) {
+	tokens := strings.Split(identifier, ".")
+	if !buildIdUsesPages {
+		page.WriteString(identifier)
+	} else if len(tokens) == 1 {
+		buildPageHref(page.PathInfo, createPagePathInfo2(ResTypeReference, pkg.Path, "..", identifier), page, identifier)
+	} else {
+		buildPageHref(page.PathInfo, createPagePathInfo3b(ResTypeReference, pkg.Path, "..", tokens[0], strings.Join(tokens[1:], ".")), page, identifier)
+	}
+}
+
+// writeIdentifierUseSites writes the links to the source lines
+// of the specified (sorted) identifiers, one per line.
+func writeIdentifierUseSites(page *htmlPage, ids []code.Identifier) {
+	var lastPkg *code.Package
+	for _, id := range ids {
+		usePkg := id.FileInfo.Pkg
+		pos := usePkg.PPkg.Fset.PositionFor(id.AstIdent.Pos(), false)
+		page.WriteString("\t\t")
+		if usePkg == lastPkg {
+			writeSrouceCodeLineLink(page, usePkg, pos, usePkg.Path, "path-duplicate")
+		} else {
+			writeSrouceCodeLineLink(page, usePkg, pos, usePkg.Path, "")
+			lastPkg = usePkg
+		}
+		writeSrouceCodeLineLink(page, usePkg, pos, fmt.Sprintf("/%s#L%d", id.FileInfo.AstBareFileName(), pos.Line), "")
+		page.WriteString("\n")
+	}
+}
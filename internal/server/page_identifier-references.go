@@ -101,7 +101,7 @@ func (ds *docServer) buildReferencesPage(w http.ResponseWriter, result *Referenc
 	page := NewHtmlPage(goldsVersion, title, ds.currentTheme, ds.currentTranslation, createPagePathInfo2(ResTypeReference, result.Package.Path, "..", result.Identifier))
 
 	var prefix string
-	if result.Selector == nil {
+	if result.Selector == nil && len(result.NestedFields) == 0 {
 		switch result.Resource.(type) {
 		case *code.Variable:
 			prefix = "var "
@@ -130,12 +130,19 @@ func (ds *docServer) buildReferencesPage(w http.ResponseWriter, result *Referenc
 			ds.writeMethodForListing(page, result.Package, result.Selector, nil, false, true)
 		}
 	}
-	page.WriteString(`</b></span>`)
+	for _, field := range result.NestedFields {
+		page.WriteByte('.')
+		pos := result.Package.PPkg.Fset.PositionFor(field.Pos(), false)
+		writeSrouceCodeLineLink(page, ds.analyzer.PackageByPath(field.Pkg().Path()), pos, field.Name(), "")
+	}
+	page.WriteString(`</b>`)
+	ds.writeDeprecatedMarkForObject(page, result.Object)
+	page.WriteString(`</span>`)
 
-	if result.Selector != nil {
+	if result.Selector != nil || len(result.NestedFields) > 0 {
 		page.WriteString(`<span style="font-size: large;"><i>`)
 		page.WriteString(page.Translation().Text_Parenthesis(false))
-		if result.Selector.Field != nil {
+		if result.Selector == nil || result.Selector.Field != nil {
 			page.WriteString(page.Translation().Text_ObjectKind("field"))
 		} else {
 			page.WriteString(page.Translation().Text_ObjectKind("method"))
@@ -164,9 +171,26 @@ func (ds *docServer) buildReferencesPage(w http.ResponseWriter, result *Referenc
 				page.WriteString(page.Translation().Text_Comma())
 				fmt.Fprintf(page, `<a href="%s">%s</a>`, link, page.Translation().Text_ViewMethodImplementations())
 			}
+
+			if sourceReadingStyle == SourceReadingStyle_rich && result.Selector.EmbeddingChain == nil {
+				if collectUnexporteds || result.Resource.Exported() && token.IsExported(methodName) {
+					page.WriteString(page.Translation().Text_Comma())
+					link = buildPageHref(page.PathInfo, createPagePathInfo3(ResTypeCallHierarchy, result.Package.Path, "..", result.Resource.Name(), methodName), nil, "")
+					fmt.Fprintf(page, `<a href="%s">%s</a>`, link, page.Translation().Text_ViewCallHierarchy())
+				}
+			}
 		}
 		page.WriteString(page.Translation().Text_Parenthesis(true))
 		page.WriteString(`</i></span>`)
+	} else if f, ok := result.Resource.(*code.Function); ok && f.Func != nil && sourceReadingStyle == SourceReadingStyle_rich {
+		if collectUnexporteds || f.Exported() {
+			page.WriteString(`<span style="font-size: large;"><i>`)
+			page.WriteString(page.Translation().Text_Parenthesis(false))
+			link := buildPageHref(page.PathInfo, createPagePathInfo2(ResTypeCallHierarchy, result.Package.Path, "..", f.Name()), nil, "")
+			fmt.Fprintf(page, `<a href="%s">%s</a>`, link, page.Translation().Text_ViewCallHierarchy())
+			page.WriteString(page.Translation().Text_Parenthesis(true))
+			page.WriteString(`</i></span>`)
+		}
 	}
 
 	page.WriteString("\n\n")
@@ -289,12 +313,14 @@ func (ds *docServer) buildReferencesPage(w http.ResponseWriter, result *Referenc
 //}
 
 type ReferencesResult struct {
-	Package    *code.Package
-	Identifier string
-	Resource   code.Resource
-	Selector   *code.Selector // non-nil for fields and methods
-	References []*ObjectReferences
-	UsesCount  int
+	Package      *code.Package
+	Identifier   string
+	Resource     code.Resource
+	Selector     *code.Selector // non-nil for fields and methods
+	NestedFields []*types.Var   // fields of unnamed struct types
+	Object       types.Object   // the referenced object
+	References   []*ObjectReferences
+	UsesCount    int
 }
 
 type ObjectReferences struct {
@@ -317,14 +343,11 @@ func (ds *docServer) buildReferencesData(pkgPath string, tokens ...string) (*Ref
 	//}
 	//
 	//tokens := strings.Split(identifier, ".")
-	if len(tokens) > 2 {
-		return nil, errors.New("invalid identifier (must be a pure identifer or a selector).")
-	}
-
 	var identifier string
 	var res code.Resource
 	var sel *code.Selector
 	var obj types.Object
+	var nestedFields []*types.Var
 	if len(tokens) == 1 {
 		if tokens[0] == "" {
 			return nil, errors.New("identifier is not specified")
@@ -369,14 +392,18 @@ func (ds *docServer) buildReferencesData(pkgPath string, tokens ...string) (*Ref
 		// to list these references.
 
 		return nil, fmt.Errorf("type %s is not found in package %s", tokens[0], pkgPath)
-	} else { // len(tokens) == 2
+	} else { // len(tokens) >= 2
 		//if !collectUnexporteds && !isBuiltin && !token.IsExported(tokens[0]) {
 		//	panic("should not go here (use): " + pkgPath + ".." + tokens[0])
 		//}
 		//if !collectUnexporteds && !token.IsExported(tokens[1]) {
 		//	panic("should not go here (use): " + pkgPath + ".." + tokens[0] + "." + tokens[1])
 		//}
-		identifier = tokens[0] + "." + tokens[1]
+		identifier = strings.Join(tokens, ".")
+
+		// The fields of unnamed struct types are denoted by field paths,
+		// such as "Type.field.nestedField" and "variable.field.nestedField".
+		var nestedFieldNames []string
 
 		for _, tn := range pkg.AllTypeNames {
 			if tn.Name() == tokens[0] {
@@ -387,10 +414,12 @@ func (ds *docServer) buildReferencesData(pkgPath string, tokens ...string) (*Ref
 						goto SelFound
 					}
 				}
-				for _, method := range t.AllMethods {
-					if method.Name() == tokens[1] {
-						sel = method
-						goto SelFound
+				if len(tokens) == 2 {
+					for _, method := range t.AllMethods {
+						if method.Name() == tokens[1] {
+							sel = method
+							goto SelFound
+						}
 					}
 				}
 				return nil, fmt.Errorf("selector %s is not found for type %s in package %s", tokens[1], tokens[0], pkgPath)
@@ -398,10 +427,29 @@ func (ds *docServer) buildReferencesData(pkgPath string, tokens ...string) (*Ref
 			SelFound:
 
 				res, obj = tn, sel.Object()
-				goto ResFound
+				nestedFieldNames = tokens[2:]
+				goto NestedFields
 			}
 		}
-		return nil, fmt.Errorf("type %s is not found in package %s", tokens[0], pkgPath)
+		for _, v := range pkg.AllVariables {
+			if v.Name() == tokens[0] {
+				res, obj = v, v.Var
+				nestedFieldNames = tokens[1:]
+				goto NestedFields
+			}
+		}
+		return nil, fmt.Errorf("type or variable %s is not found in package %s", tokens[0], pkgPath)
+
+	NestedFields:
+
+		for _, name := range nestedFieldNames {
+			field := lookupNestedField(obj, name)
+			if field == nil {
+				return nil, fmt.Errorf("field %s is not found in %s of package %s", name, identifier, pkgPath)
+			}
+			obj = field
+			nestedFields = append(nestedFields, field)
+		}
 	}
 
 ResFound:
@@ -503,11 +551,137 @@ ResFound:
 	}
 
 	return &ReferencesResult{
-		Package:    pkg,
-		Identifier: identifier,
-		Resource:   res,
-		Selector:   sel,
-		References: refs,
-		UsesCount:  usesCount,
+		Package:      pkg,
+		Identifier:   identifier,
+		Resource:     res,
+		Selector:     sel,
+		NestedFields: nestedFields,
+		Object:       obj,
+		References:   refs,
+		UsesCount:    usesCount,
 	}, nil
 }
+
+// nestedStruct returns the unnamed struct type denoted by t,
+// with the pointer, slice, array, map and channel wrappers stripped.
+// Nil is returned if there is no such a struct type.
+func nestedStruct(t types.Type) *types.Struct {
+	for {
+		switch tt := t.(type) {
+		case *types.Struct:
+			return tt
+		case *types.Pointer:
+			t = tt.Elem()
+		case *types.Slice:
+			t = tt.Elem()
+		case *types.Array:
+			t = tt.Elem()
+		case *types.Map:
+			t = tt.Elem()
+		case *types.Chan:
+			t = tt.Elem()
+		default:
+			return nil
+		}
+	}
+}
+
+// collectNestedFieldPaths collects the paths (like "Type.field.nestedField"
+// and "variable.field.nestedField") of the fields of the unnamed struct types
+// in the package-level type and variable declarations of the given files.
+// The fields directly declared in named struct types are not collected.
+func collectNestedFieldPaths(files []*ast.File, info *types.Info, paths map[*types.Var]string) {
+	var collect func(path string, st *types.Struct)
+	collect = func(path string, st *types.Struct) {
+		for i := 0; i < st.NumFields(); i++ {
+			f := st.Field(i)
+			if f.Name() == "_" || f.Embedded() {
+				continue
+			}
+			fieldPath := path + "." + f.Name()
+			paths[f] = fieldPath
+			if nested := nestedStruct(f.Type()); nested != nil {
+				collect(fieldPath, nested)
+			}
+		}
+	}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.Name == "_" {
+						continue
+					}
+					tn, ok := info.Defs[spec.Name].(*types.TypeName)
+					// The type of unsafe.IntegerType is not set.
+					if !ok || tn.IsAlias() || tn.Type() == nil {
+						continue
+					}
+					st, ok := tn.Type().Underlying().(*types.Struct)
+					if !ok {
+						continue
+					}
+					for i := 0; i < st.NumFields(); i++ {
+						if f := st.Field(i); !f.Embedded() {
+							if nested := nestedStruct(f.Type()); nested != nil {
+								collect(tn.Name()+"."+f.Name(), nested)
+							}
+						}
+					}
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						if name.Name == "_" {
+							continue
+						}
+						if obj, ok := info.Defs[name].(*types.Var); ok {
+							if nested := nestedStruct(obj.Type()); nested != nil {
+								collect(obj.Name(), nested)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// nestedFieldPath returns the path of a field of an unnamed struct type
+// declared in a package-level declaration of the given package.
+// ds.mutex should be locked before calling this method.
+func (ds *docServer) nestedFieldPath(pkg *code.Package, field *types.Var) string {
+	if ds.nestedFieldPaths == nil {
+		ds.nestedFieldPaths = make(map[*code.Package]map[*types.Var]string)
+	}
+	paths, ok := ds.nestedFieldPaths[pkg]
+	if !ok {
+		paths = make(map[*types.Var]string)
+		collectNestedFieldPaths(pkg.PPkg.Syntax, pkg.PPkg.TypesInfo, paths)
+		ds.nestedFieldPaths[pkg] = paths
+	}
+	return paths[field]
+}
+
+// lookupNestedField looks up a direct field of the unnamed struct type
+// (nestedly) denoted by the type of the field or variable obj.
+func lookupNestedField(obj types.Object, fieldName string) *types.Var {
+	v, ok := obj.(*types.Var)
+	if !ok {
+		return nil
+	}
+	st := nestedStruct(v.Type())
+	if st == nil {
+		return nil
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if f := st.Field(i); f.Name() == fieldName {
+			return f
+		}
+	}
+	return nil
+}
@@ -6,13 +6,18 @@ import (
 
 func (ds *docServer) javascriptFile(w http.ResponseWriter, r *http.Request, filename string) {
 	w.Header().Set("Content-Type", "application/javascript")
-	if !genDocsMode {
-		w.Write(jsFile)
+	if genDocsMode {
+		filename = deHashFilename(filename)
+	}
+
+	if filename == "search-index" {
+		ds.searchIndexFile(w, r)
 		return
 	}
 
-	if genDocsMode {
-		filename = deHashFilename(filename) // not used now
+	if !genDocsMode {
+		w.Write(jsFile)
+		return
 	}
 
 	ds.mutex.Lock()
@@ -50,6 +55,8 @@ function onPageLoad() {
 		e.stopPropagation();
 	});
 
+	watchNewerDocs();
+
 	if (document.getElementById("overview") != null) {
 		initOverviewPage();
 		return
@@ -71,9 +78,15 @@ function onPageLoad() {
 	if (document.getElementById("package-details") != null) {
 		initPackageDetailsPage();
 	}
+
+	if (document.getElementById("graph-object") != null) {
+		initDependencyGraph();
+	}
 }
 
 function initOverviewPage() {
+	initSearchBox();
+
 	document.addEventListener("keydown", function(e){
 		if (e.ctrlKey || e.altKey || e.shiftKey) {
 			return;
@@ -96,6 +109,7 @@ function initOverviewPage() {
 	var pkgsByImportedby = new Array(nodesPkg.length);
 	var pkgsByCodeLines = new Array(nodesPkg.length);
 	var pkgsByDepDepth = new Array(nodesPkg.length);
+	var pkgsByUses = new Array(nodesPkg.length);
 	//var pkgsByDepHeight = new Array(nodesPkg.length);
 	for (var i = 0; i < nodesPkg.length; i++) {
 		var n = nodesPkg[i];
@@ -106,11 +120,13 @@ function initOverviewPage() {
 			codelines: parseInt(n.dataset.loc),
 			depdepth: parseInt(n.dataset.depdepth),
 			depheight: parseInt(n.dataset.depheight),
+			uses: parseInt(n.dataset.uses),
 		};
 		pkgsByAlphabet[i] = t;
 		pkgsByImportedby[i] = t;
 		pkgsByCodeLines[i] = t;
 		pkgsByDepDepth[i] = t;
+		pkgsByUses[i] = t;
 	}
 	pkgsByImportedby.sort(function(a, b) {
 		if (a.importedbys == b.importedbys) {
@@ -142,6 +158,15 @@ function initOverviewPage() {
 		}
 		return 1;
 	});
+	pkgsByUses.sort(function(a, b) {
+		if (a.uses == b.uses) {
+			if (a.node.id < b.node.id) {
+				return -1;
+			}
+			return 1;
+		}
+		return b.uses - a.uses;
+	});
 
 	var showSortByImportBysButton = true;
 	var showSortByCodeLinesButton = true;
@@ -198,6 +223,7 @@ function initOverviewPage() {
 	var sortByImportedbys = content.querySelector("#btn-importedbys");
 	var sortByCodeLines = content.querySelector("#btn-codelines");
 	var sortByDepdepth = content.querySelector("#btn-depdepth");
+	var sortByUses = content.querySelector("#btn-uses");
 
 	sortByAlphabet.classList.add("chosen");
 	var currentSortBy = "alphabet";
@@ -282,10 +308,149 @@ function initOverviewPage() {
 		currentButton = sortByDepdepth;
 		currentButton.classList.add("chosen");
 	});
+	sortByUses.addEventListener('click', function(event) {
+		if (currentSortBy == "uses") {
+			return;
+		}
+
+		pkgContainer.innerHTML = "";
+		pkgsByUses.forEach(function (x, i) {
+			pkgContainer.appendChild(x.node);
+			var o = (i+pkgStartOrderId).toString();
+			x.order.innerText = SPACES.substr(0, maxDigitCount-o.length) + o;
+		});
+
+		pkgContainer.classList.remove(currentSortBy);
+		currentSortBy = "uses";
+		pkgContainer.classList.add(currentSortBy);
+
+		currentButton.classList.remove("chosen");
+		currentButton = sortByUses;
+		currentButton.classList.add("chosen");
+	});
+}
+
+// The search box is only available in docs generation mode.
+// The searchIndex variable is declared in the search index file.
+function initSearchBox() {
+	var box = document.getElementById("search-box");
+	if (box == null || typeof searchIndex == "undefined") {
+		return;
+	}
+	document.getElementById("search-box-container").style.display = "block";
+
+	const MaxNumResults = 100;
+	var results = document.getElementById("search-results");
+
+	var matchScore = function(name, query, lowerQuery) {
+		if (name == query) {
+			return 5;
+		}
+		var lowerName = name.toLowerCase();
+		if (lowerName == lowerQuery) {
+			return 4;
+		}
+		if (name.indexOf(query) == 0) {
+			return 3;
+		}
+		if (lowerName.indexOf(lowerQuery) == 0) {
+			return 2;
+		}
+		if (lowerName.indexOf(lowerQuery) >= 0) {
+			return 1;
+		}
+		return 0;
+	};
+
+	var matchQualifier = function(pkgPath, typeName, lowerQualifier) {
+		if (typeName != null && typeName.toLowerCase() == lowerQualifier) {
+			return true;
+		}
+		var lowerPath = pkgPath.toLowerCase();
+		if (lowerPath == lowerQualifier) {
+			return true;
+		}
+		var i = lowerPath.length - lowerQualifier.length - 1;
+		return i >= 0 && lowerPath.substr(i) == "/" + lowerQualifier;
+	};
+
+	var appendLine = function(kind, text, href, pkgPath) {
+		results.appendChild(document.createTextNode("\n\t" + kind + " "));
+		var a = document.createElement("a");
+		a.href = href;
+		a.innerText = text;
+		results.appendChild(a);
+		if (pkgPath != null) {
+			results.appendChild(document.createTextNode(" "));
+			var i = document.createElement("i");
+			i.innerText = pkgPath;
+			results.appendChild(i);
+		}
+	};
+
+	var search = function() {
+		results.innerHTML = "";
+
+		var query = box.value.trim();
+		var qualifier = "";
+		var k = query.lastIndexOf(".");
+		if (k >= 0) {
+			qualifier = query.substr(0, k).toLowerCase();
+			query = query.substr(k+1);
+		}
+		if (query == "") {
+			return;
+		}
+		var lowerQuery = query.toLowerCase();
+
+		var matches = [];
+		if (qualifier == "") {
+			searchIndex.p.forEach(function(p) {
+				var path = p[0];
+				var score = matchScore(path.substr(path.lastIndexOf("/")+1), query, lowerQuery);
+				if (score > 0) {
+					matches.push({score: score + 1, kind: "package", text: path, href: p[1]});
+				}
+			});
+		}
+		searchIndex.i.forEach(function(item) {
+			var score = matchScore(item[1], query, lowerQuery);
+			if (score == 0) {
+				return;
+			}
+			var p = searchIndex.p[item[2]];
+			var typeName = item.length > 3 ? item[3] : null;
+			if (qualifier != "" && !matchQualifier(p[0], typeName, qualifier)) {
+				return;
+			}
+			var text = typeName == null ? item[1] : typeName + "." + item[1];
+			var href = typeName == null ? p[1] + "#name-" + item[1] : item[4];
+			matches.push({score: score, kind: item[0], text: text, href: href, pkgPath: p[0]});
+		});
+		matches.sort(function(a, b) {
+			return b.score - a.score;
+		});
+
+		matches.slice(0, MaxNumResults).forEach(function(m) {
+			appendLine(m.kind, m.text, m.href, m.pkgPath);
+		});
+		if (matches.length > MaxNumResults) {
+			results.appendChild(document.createTextNode("\n\t..."));
+		}
+	};
+
+	var timer = null;
+	box.addEventListener("input", function(event) {
+		if (timer != null) {
+			clearTimeout(timer);
+		}
+		timer = setTimeout(search, 200);
+	});
 }
 
 function initPackageDetailsPage() {
 	autoExpandForPackageDetailsPageByPageAnchor();
+	initExampleRunners();
 
 	var toggleCheckboxes = function(cbs) {
 		var numCheckeds = 0;
@@ -358,43 +523,58 @@ function initPackageDetailsPage() {
 		toggleCheckboxes(cbsAll);
 	});
 
-	var buttons = document.getElementById("exported-types-buttons");
-	var container = document.getElementById("exported-types");
-	var nodesTypeRes = container.querySelectorAll(".type-res");
-	var typesByAlphabet = new Array(nodesTypeRes.length);
-	var typesByPopularity = new Array(nodesTypeRes.length);
-	for (var i = 0; i < nodesTypeRes.length; i++) {
-		var n = nodesTypeRes[i];
-		var t = {node: n, popularity: parseInt(n.dataset.popularity)};
-		typesByAlphabet[i] = t;
-		typesByPopularity[i] = t;
-	}
-	typesByPopularity.sort(function(a, b) {
-		if (a.popularity == b.popularity) {
-			if (a.node.id < b.node.id) {
-				return -1;
-			}
-			return 1;
-		}
-		return b.popularity - a.popularity;
-	});
+	enableResourceSorting("types", "type-res", ["popularity", "uses"]);
+	enableResourceSorting("functions", "value-res", ["uses"]);
+	enableResourceSorting("variables", "value-res", ["uses"]);
+	enableResourceSorting("constants", "value-res", ["uses"]);
+}
 
-	//var printArray = function(a, title) {
-	//	console.log("==================== ", title);
-	//	for (var i = 0; i < a.length; i++) {
-	//		console.log(i, ": ", a[i].popularity, ", ", a[i].node.id);
-	//	}
-	//}
-	//printArray(typesByAlphabet, "typesByAlphabet");
-	//printArray(typesByPopularity, "typesByPopularity");
+// The sorting buttons of a resource list are only shown when
+// at least one sorting result differs from the alphabet order.
+function enableResourceSorting(name, resClass, sortKeys) {
+	var buttons = document.getElementById("exported-" + name + "-buttons");
+	var container = document.getElementById("exported-" + name);
+	if (buttons == null || container == null) {
+		return;
+	}
+	var nodesRes = container.querySelectorAll("." + resClass);
+	var resByAlphabet = new Array(nodesRes.length);
+	for (var i = 0; i < nodesRes.length; i++) {
+		resByAlphabet[i] = {node: nodesRes[i]};
+	}
 
+	var sortings = {alphabet: resByAlphabet};
 	var showSortingButtons = false;
-	for (var i = 0; i < nodesTypeRes.length; i++) {
-		if (typesByAlphabet[i].node.id != typesByPopularity[i].node.id) {
-			showSortingButtons = true;
-			break;
+	sortKeys.forEach(function (key) {
+		var sorted = resByAlphabet.map(function (x) {
+			return {node: x.node, value: parseInt(x.node.dataset[key])};
+		});
+		sorted.sort(function(a, b) {
+			if (a.value == b.value) {
+				if (a.node.id < b.node.id) {
+					return -1;
+				}
+				return 1;
+			}
+			return b.value - a.value;
+		});
+
+		//var printArray = function(a, title) {
+		//	console.log("==================== ", title);
+		//	for (var i = 0; i < a.length; i++) {
+		//		console.log(i, ": ", a[i].value, ", ", a[i].node.id);
+		//	}
+		//}
+		//printArray(sorted, key);
+
+		for (var i = 0; i < sorted.length; i++) {
+			if (resByAlphabet[i].node.id != sorted[i].node.id) {
+				showSortingButtons = true;
+				break;
+			}
 		}
-	}
+		sortings[key] = sorted;
+	});
 	if (!showSortingButtons) {
 		return;
 	}
@@ -403,34 +583,120 @@ function initPackageDetailsPage() {
 	buttons.style.display = "block";
 
 	var currentSortBy = "alphabet";
-	var sortByAlphabet = buttons.querySelector("#sort-types-by-alphabet");
-	var sortByPopularity = buttons.querySelector("#sort-types-by-popularity");
-	sortByAlphabet.classList.add("chosen");
-	sortByAlphabet.addEventListener('click', function(event) {
-		if (currentSortBy == "alphabet") {
-			return;
-		}
+	var currentButton = buttons.querySelector("#sort-" + name + "-by-alphabet");
+	currentButton.classList.add("chosen");
+	Object.keys(sortings).forEach(function (key) {
+		var button = buttons.querySelector("#sort-" + name + "-by-" + key);
+		button.addEventListener('click', function(event) {
+			if (currentSortBy == key) {
+				return;
+			}
 
-		typesByAlphabet.forEach(function (x) {
-			container.appendChild(x.node);
-		});
+			sortings[key].forEach(function (x) {
+				container.appendChild(x.node);
+			});
 
-		currentSortBy = "alphabet";
-		sortByAlphabet.classList.add("chosen");
-		sortByPopularity.classList.remove("chosen");
+			currentSortBy = key;
+			currentButton.classList.remove("chosen");
+			currentButton = button;
+			currentButton.classList.add("chosen");
+		});
 	});
-	sortByPopularity.addEventListener('click', function(event) {
-		if (currentSortBy == "popularity") {
-			return;
-		}
+}
+
+function initDependencyGraph() {
+	var object = document.getElementById("graph-object");
+	var depth = document.getElementById("graph-depth");
+	var module = document.getElementById("graph-module");
+	var links = document.querySelectorAll("a.graph-data");
 
-		typesByPopularity.forEach(function (x) {
-			container.appendChild(x.node);
+	var update = function() {
+		var params = new URLSearchParams();
+		if (depth != null) {
+			params.set("depth", depth.value);
+		}
+		if (module != null && module.value != "") {
+			params.set("module", module.value);
+		}
+		var query = "?" + params.toString();
+		object.data = object.getAttribute("data-href") + query;
+		links.forEach(function (a) {
+			a.href = a.getAttribute("data-href") + query;
 		});
+	};
 
-		currentSortBy = "popularity";
-		sortByPopularity.classList.add("chosen");
-		sortByAlphabet.classList.remove("chosen");
+	if (depth != null) {
+		depth.addEventListener("change", update);
+	}
+	if (module != null) {
+		module.addEventListener("change", update);
+	}
+}
+
+function watchNewerDocs() {
+	var notice = document.getElementById("newer-docs-notice");
+	if (notice == null) {
+		return;
+	}
+	var version = notice.getAttribute("data-docs-version");
+	var timer = window.setInterval(function () {
+		var xhr = new XMLHttpRequest();
+		xhr.open("GET", "/api:docs-version");
+		xhr.onreadystatechange = function () {
+			if (xhr.readyState != 4 || xhr.status != 200) {
+				return;
+			}
+			var data = JSON.parse(xhr.response);
+			if (String(data.docsVersion) != version) {
+				notice.classList.remove("hidden");
+				window.clearInterval(timer);
+			}
+		};
+		xhr.send(null);
+	}, 3000);
+}
+
+function initExampleRunners() {
+	var runners = document.querySelectorAll(".example-runner");
+	runners.forEach(function (runner) {
+		runner.style.display = "inline";
+		var button = runner.querySelector("label");
+		var output = runner.querySelector(".example-output");
+		var indent = runner.getAttribute("data-indent");
+		var running = false;
+
+		var show = function(text) {
+			output.textContent = text.replace(/\n$/, "").split("\n").map(function (line) {
+				return "\n" + indent + line;
+			}).join("");
+		};
+
+		button.addEventListener("click", function () {
+			if (running) {
+				return;
+			}
+			running = true;
+			button.classList.add("chosen");
+			output.textContent = "";
+
+			var params = new URLSearchParams();
+			params.set("package", runner.getAttribute("data-package"));
+			params.set("example", runner.getAttribute("data-example"));
+			var xhr = new XMLHttpRequest();
+			xhr.open("POST", "/api:run-example?" + params.toString());
+			xhr.onprogress = function () {
+				show(xhr.responseText);
+			};
+			xhr.onreadystatechange = function () {
+				if (xhr.readyState != 4) {
+					return;
+				}
+				show(xhr.responseText);
+				running = false;
+				button.classList.remove("chosen");
+			};
+			xhr.send(null);
+		});
 	});
 }
 
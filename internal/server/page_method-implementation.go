@@ -79,7 +79,9 @@ func (ds *docServer) buildImplementationPage(w http.ResponseWriter, result *Meth
 	page.WriteString("<b>")
 	//writeSrouceCodeLineLink(page, result.TypeName.Package(), result.TypeName.Position(), result.TypeName.Name(), "")
 	ds.writeResourceIndexHTML(page, result.TypeName.Package(), result.TypeName, false, false, false)
-	page.WriteString(`</b></span><span style="font-size:large;">`)
+	page.WriteString(`</b>`)
+	ds.writeDeprecatedMarkForObject(page, result.TypeName.TypeName)
+	page.WriteString(`</span><span style="font-size:large;">`)
 	writeKindText(page, result.TypeName.Denoting.TT)
 	page.WriteString("</span>\n")
 
@@ -118,6 +120,7 @@ func (ds *docServer) buildImplementationPage(w http.ResponseWriter, result *Meth
 		} else {
 			ds.writeMethodForListing(page, result.Package, method.Method, nil, false, false)
 		}
+		ds.writeDeprecatedMarkForObject(page, method.Method.Object())
 		for _, imp := range method.Implementations {
 			page.WriteString("\n\t\t")
 			if result.IsInterface {
@@ -131,10 +134,27 @@ func (ds *docServer) buildImplementationPage(w http.ResponseWriter, result *Meth
 			page.WriteString("<b>")
 			ds.writeMethodForListing(page, result.Package, imp.Method, nil, false, true)
 			page.WriteString("</b>")
+			ds.writeDeprecatedMarkForObject(page, imp.Method.Object())
+			ds.writeImplementationNotes(page, imp.Receiver, result.Package, result.TypeName, result.IsInterface)
 		}
 		page.WriteString("</div>")
 	}
 
+	if len(result.SatisfiedBys) > 0 {
+		fmt.Fprintf(page, `
+<span class="title">%s<span class="title-stat">%s<i>%s</i>%s</span></span>
+`,
+			page.Translation().Text_SatisfiedBy(),
+			page.Translation().Text_Parenthesis(false),
+			page.Translation().Text_PackageLevelResourceSimpleStat(true, len(result.SatisfiedBys), len(result.SatisfiedBys), false),
+			page.Translation().Text_Parenthesis(true),
+		)
+		for _, by := range result.SatisfiedBys {
+			page.WriteString("\n\t")
+			ds.writeTypeForListing(page, by, result.Package, "", DotMStyle_NotShow, result.TypeName)
+		}
+	}
+
 	page.WriteString("</code></pre>")
 	return page.Done(w)
 }
@@ -149,6 +169,9 @@ type MethodImplementationResult struct {
 	Methods []MethodImplementations
 
 	NonImplementingMethodCount int32
+
+	// For constraint-only interface types.
+	SatisfiedBys []*TypeForListing // exported ones only
 }
 
 type MethodImplementations struct {
@@ -201,7 +224,9 @@ func (ds *docServer) buildImplementationData(analyzer *code.CodeAnalyzer, pkgPat
 	if typeInfo == nil {
 		return nil, errors.New("typename not found")
 	}
-	if len(typeInfo.AllMethods) == 0 {
+	// Types satisfying a constraint-only interface type.
+	satisfiedBys := buildTypeSatisfiedByList(analyzer, pkg, typeInfo)
+	if len(typeInfo.AllMethods) == 0 && len(satisfiedBys) == 0 {
 		return nil, fmt.Errorf("%s.%s has no methods", pkgPath, typeName)
 	}
 
@@ -211,7 +236,11 @@ func (ds *docServer) buildImplementationData(analyzer *code.CodeAnalyzer, pkgPat
 	methodImplementations := make([]MethodImplementations, 0, len(typeInfo.AllMethods))
 	methodSelectors, _ := buildTypeMethodsList(typeInfo, true)
 	if isInterface {
-		if len(typeInfo.ImplementedBys) == 0 {
+		impBys, _ := buildTypeImplementedByList(analyzer, pkg, typeInfo, true, typeNameRes)
+		// Constraint-only interface types are never implemented, and the
+		// generic ones are not checked for satisfactions. Their methods
+		// are still listed, for source pages link to them.
+		if len(typeInfo.ImplementedBys) == 0 && len(impBys) == 0 && len(satisfiedBys) == 0 && !typeInfo.IsConstraint() {
 			return nil, fmt.Errorf("no types implement %s.%s", pkgPath, typeName)
 		}
 
@@ -219,8 +248,7 @@ func (ds *docServer) buildImplementationData(analyzer *code.CodeAnalyzer, pkgPat
 			if !collectUnexporteds && !token.IsExported(sel.Name()) {
 				continue
 			}
-			impls := make([]MethodInfo, 0, len(typeInfo.ImplementedBys))
-			impBys, _ := buildTypeImplementedByList(analyzer, pkg, typeInfo, true, typeNameRes)
+			impls := make([]MethodInfo, 0, len(impBys))
 			selNameIsUnexported := !token.IsExported(sel.Name())
 			for _, impBy := range impBys {
 				if !collectUnexporteds && impBy.BaseType.TypeName.Package().Path != "builtin" && !impBy.BaseType.TypeName.Exported() {
@@ -255,7 +283,8 @@ func (ds *docServer) buildImplementationData(analyzer *code.CodeAnalyzer, pkgPat
 			})
 		}
 	} else {
-		if len(typeInfo.Implements) == 0 {
+		imps, _ := buildTypeImplementsList(analyzer, pkg, typeInfo, true, typeInfo.TypeName)
+		if len(typeInfo.Implements) == 0 && len(imps) == 0 {
 			return nil, fmt.Errorf("%s.%s doesn't implement any interface types with at least one method", pkgPath, typeName)
 		}
 
@@ -263,8 +292,7 @@ func (ds *docServer) buildImplementationData(analyzer *code.CodeAnalyzer, pkgPat
 			if !collectUnexporteds && !token.IsExported(sel.Name()) {
 				continue
 			}
-			impls := make([]MethodInfo, 0, len(typeInfo.Implements))
-			imps, _ := buildTypeImplementsList(analyzer, pkg, typeInfo, true, typeInfo.TypeName)
+			impls := make([]MethodInfo, 0, len(imps))
 			selNameIsUnexported := !token.IsExported(sel.Name())
 			for _, imp := range imps {
 				if !collectUnexporteds && imp.BaseType.TypeName.Package().Path != "builtin" && !imp.BaseType.TypeName.Exported() {
@@ -313,5 +341,7 @@ func (ds *docServer) buildImplementationData(analyzer *code.CodeAnalyzer, pkgPat
 		NonImplementingMethodCount: nonImplementingMethodCount,
 
 		Methods: methodImplementations,
+
+		SatisfiedBys: satisfiedBys,
 	}, nil
 }
@@ -3,25 +3,209 @@ package server
 import (
 	"fmt"
 	"net/http"
-)
+	"strings"
 
-func (ds *docServer) modulePage(w http.ResponseWriter, r *http.Request, rootVersion string) {
-	// w.WriteHeader(http.StatusTooEarly)
+	"go101.org/golds/code"
+)
 
+func (ds *docServer) modulePage(w http.ResponseWriter, r *http.Request, modulePath string) {
 	w.Header().Set("Content-Type", "text/html")
-	fmt.Fprint(w, "Module page is not implemented yet")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
 
 	if genDocsMode {
-		//pkgPath = deHashScope(pkgPath)
+		modulePath = deHashScope(modulePath)
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeModule,
+		res:     modulePath,
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		details := ds.buildModuleDetailsData(modulePath)
+		if details == nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "Module (%s) not found", modulePath)
+			return
+		}
+
+		data = ds.buildModulePage(w, details)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type ModuleDetails struct {
+	Module *code.Module
+
+	PagePath    string // "std" for the std module
+	IsStandard  bool
+	NumPackages int
+}
+
+// The std module path is blank, so "std" is used in its page path.
+func modulePagePath(m *code.Module) string {
+	if m.Path == "" {
+		return "std"
+	}
+	return m.Path
+}
+
+// ds should be locked before calling this method.
+func (ds *docServer) buildModuleDetailsData(modulePath string) *ModuleDetails {
+	m := ds.analyzer.ModuleByPath(modulePath)
+	if m == nil {
+		return nil
 	}
+
+	details := &ModuleDetails{
+		Module:     m,
+		PagePath:   modulePagePath(m),
+		IsStandard: m.Path == "",
+	}
+	for _, pkg := range m.Pkgs {
+		if !pkg.IsFake() {
+			details.NumPackages++
+		}
+	}
+
+	return details
 }
 
-/*
+func (ds *docServer) buildModulePage(w http.ResponseWriter, details *ModuleDetails) []byte {
+	m := details.Module
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_Module(details.PagePath), ds.currentTheme, ds.currentTranslation, createPagePathInfo1(ResTypeModule, details.PagePath))
+
+	fmt.Fprintf(page, `
+<pre id="module-details"><code><span style="font-size:xx-large;">module <b>%s</b></span>
+`,
+		details.PagePath,
+	)
 
-module page
-* packages
-* requires
-* requiredBy
-* project link
+	writeTitle := func(item string, num int) {
+		page.WriteString("\n")
+		fmt.Fprint(page, `<span class="title">`, page.Translation().Text_ModuleInfoTitle(item))
+		if num >= 0 {
+			fmt.Fprint(page, " (", num, ")")
+		}
+		page.WriteString("</span>")
+	}
 
-*/
+	if m.Version != "" {
+		writeTitle("version", -1)
+		page.WriteString("\n\t")
+		page.WriteString(m.Version)
+		page.WriteString("\n")
+	}
+
+	if m.Replace.Path != "" || m.Replace.Dir != "" {
+		writeTitle("replacement", -1)
+		if m.Replace.Path != "" {
+			page.WriteString("\n\t")
+			page.WriteString(m.Replace.Path)
+			if m.Replace.Version != "" {
+				page.WriteByte(' ')
+				page.WriteString(m.Replace.Version)
+			}
+		}
+		if m.Replace.Dir != "" && m.Replace.Dir != m.Replace.Path {
+			page.WriteString("\n\t")
+			page.AsHTMLEscapeWriter().WriteString(m.Replace.Dir)
+		}
+		page.WriteString("\n")
+	}
+
+	if m.GoVersion != "" {
+		writeTitle("goversion", -1)
+		page.WriteString("\n\t")
+		page.WriteString(m.GoVersion)
+		page.WriteString("\n")
+	}
+
+	if m.RepositoryURL != "" {
+		writeTitle("repository", -1)
+		page.WriteString("\n\t")
+		fmt.Fprintf(page, `<a href="%[1]s" target="_blank">%[1]s</a>`, m.RepositoryURL)
+		if m.RepositoryCommit != "" {
+			page.WriteString("\n")
+			writeTitle("commit", -1)
+			page.WriteString("\n\t")
+			page.WriteString(m.RepositoryCommit)
+		}
+		page.WriteString("\n")
+	}
+
+	writeTitle("packages", details.NumPackages)
+	ds.writeModulePackageHierarchy(page, m)
+	page.WriteString("\n")
+
+	writeModules := func(item string, modules []*code.Module) {
+		if len(modules) == 0 {
+			return
+		}
+		writeTitle(item, len(modules))
+		for _, rm := range modules {
+			page.WriteString("\n\t")
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypeModule, modulePagePath(rm)), page, modulePagePath(rm))
+			if v := rm.ActualVersion(); v != "" {
+				page.WriteByte(' ')
+				page.WriteString(v)
+			}
+		}
+		page.WriteString("\n")
+	}
+	writeModules("requires", m.Requires)
+	writeModules("requiredbys", m.RequiredBys)
+
+	page.WriteString("</code></pre>")
+
+	if details.NumPackages > 0 {
+		ds.writeDependencyGraph(page, dependencyGraphKind_Module, details.PagePath, nil)
+		ds.writeModuleDSM(page, details.PagePath)
+	}
+
+	return page.Done(w)
+}
+
+func (ds *docServer) writeModulePackageHierarchy(page *htmlPage, m *code.Module) {
+	var writePackage func(pkg *code.Package, depth int)
+	writePackage = func(pkg *code.Package, depth int) {
+		name := pkg.Path
+		if parent := pkg.ParentPackage(); parent != nil && parent.Path != "" {
+			name = pkg.Path[len(parent.Path)+1:]
+		}
+
+		page.WriteString("\n\t")
+		page.WriteString(strings.Repeat("  ", depth))
+		if pkg.IsFake() {
+			page.WriteString(name)
+			page.WriteByte('/')
+		} else {
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, pkg.Path), page, name)
+		}
+
+		for _, child := range pkg.ChildPackages() {
+			writePackage(child, depth+1)
+		}
+	}
+
+	root := m.RootPackage()
+	if root == nil {
+		return
+	}
+	if root.Path == "" { // std module
+		for _, child := range root.ChildPackages() {
+			writePackage(child, 0)
+		}
+	} else {
+		writePackage(root, 0)
+	}
+}
@@ -66,12 +66,22 @@ func (ds *docServer) buildOverviewPage(w http.ResponseWriter, overview *Overview
 
 	if !genDocsMode {
 		ds.writeUpdateGoldBlock(page)
+		writeSearchForm(page, "")
+	} else {
+		writeSearchIndexBox(page)
 	}
 
 	if showStatistics {
 		ds.writeSimpleStatsBlock(page, &overview.Stats)
 	}
 
+	if n := ds.numDeprecatedUses(); n > 0 {
+		writeReportLinkBlock(page, "deprecated", page.Translation().Text_DeprecatedUses(), n)
+	}
+	if n := ds.numNewerStdAPIUses(); n > 0 {
+		writeReportLinkBlock(page, "newer-std-apis", page.Translation().Text_NewerStdAPIUses(), n)
+	}
+
 	page.WriteString("<pre><code>")
 
 	page.WriteString(`<span class="title">`)
@@ -96,6 +106,10 @@ func (ds *docServer) buildOverviewPage(w http.ResponseWriter, overview *Overview
 	page.WriteString(`<label id="btn-depdepth" class="button">`)
 	page.WriteString(page.Translation().Text_SortByItem("depdepth"))
 	page.WriteString(`</label></span>`)
+	page.WriteString(`<span id="uses"> | `)
+	page.WriteString(`<label id="btn-uses" class="button">`)
+	page.WriteString(page.Translation().Text_SortByItem("uses"))
+	page.WriteString(`</label></span>`)
 	page.WriteString(`</span>`)
 	page.WriteString(page.Translation().Text_Parenthesis(true))
 	page.WriteString("</span>")
@@ -133,6 +147,9 @@ func (ds *docServer) writePackagesForListing(page *htmlPage, packages []*Package
 			if hidden {
 				extraClass = " hidden"
 			}
+			if pkg.Package != nil && pkg.Package.Deprecated {
+				extraClass += " deprecated"
+			}
 			main := ""
 			if pkg.Name == "main" {
 				main = ` data-main="1"`
@@ -144,7 +161,7 @@ func (ds *docServer) writePackagesForListing(page *htmlPage, packages []*Package
 			}
 			fmt.Fprintf(page, `<div class="anchor pkg alphabet%s" id="pkg-%s"`, extraClass, pkg.Path)
 			if writeDataAttrs {
-				fmt.Fprintf(page, ` data-module="%s" data-loc="%d" data-importedbys="%d" data-depheight="%d" data-depdepth="%d"%s`, pkg.Module, pkg.LOC, pkg.NumImportedBys, pkg.DepHeight, pkg.DepDepth, main)
+				fmt.Fprintf(page, ` data-module="%s" data-loc="%d" data-importedbys="%d" data-depheight="%d" data-depdepth="%d" data-uses="%d"%s`, pkg.Module, pkg.LOC, pkg.NumImportedBys, pkg.DepHeight, pkg.DepDepth, pkg.NumUses, main)
 			}
 			page.WriteString(`>`)
 			defer page.WriteString(`</div>`)
@@ -194,6 +211,10 @@ func (ds *docServer) writePackagesForListing(page *htmlPage, packages []*Package
 			}
 		}
 
+		if pkg.Package != nil && pkg.Package.Deprecated {
+			writeDeprecatedMark(page)
+		}
+
 		if writeDataAttrs {
 			if pkg.Path != "builtin" {
 				func() {
@@ -207,6 +228,7 @@ func (ds *docServer) writePackagesForListing(page *htmlPage, packages []*Package
 			fmt.Fprintf(page, `<i class="codelines"> (%d)</i>`, pkg.LOC)
 			fmt.Fprintf(page, `<i class="depheight"> (%d)</i>`, pkg.DepHeight)
 			fmt.Fprintf(page, `<i class="depdepth"> (%d)</i>`, pkg.DepDepth)
+			fmt.Fprintf(page, `<i class="uses"> (%d)</i>`, pkg.NumUses)
 		}
 
 		const PackageSpace = "Package "
@@ -238,6 +260,18 @@ func (ds *docServer) writePackagesForListing(page *htmlPage, packages []*Package
 
 	page.WriteString(`<input type='checkbox' id="toggle-summary">`)
 
+	numDeprecateds := 0
+	for _, pkg := range packages {
+		if pkg.Package != nil && pkg.Package.Deprecated {
+			numDeprecateds++
+		}
+	}
+	if numDeprecateds > 0 {
+		page.WriteString("\n")
+		page.WriteString(SPACES[:len(SPACES)-maxDigitCount])
+		writeDeprecatedItemsToggle(page, "deprecated-packages-showhide", numDeprecateds, "packages")
+	}
+
 	switch wdPkgsListingManner {
 	case WdPkgsListingManner_promoted, WdPkgsListingManner_solo:
 		showOthers := wdPkgsListingManner != WdPkgsListingManner_solo
@@ -294,6 +328,20 @@ func (ds *docServer) writeUpdateGoldBlock(page *htmlPage) {
 	)
 }
 
+// writeReportLinkBlock writes a link to a report page, such as
+// the deprecated uses page, with the number of the reported items.
+func writeReportLinkBlock(page *htmlPage, path, title string, num int) {
+	fmt.Fprintf(page, `
+<pre><code><span class="title"><a href="%s">%s</a></span><span class="title-stat"><i>%s%d%s</i></span></code></pre>
+`,
+		buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, path), nil, ""),
+		title,
+		page.Translation().Text_Parenthesis(false),
+		num,
+		page.Translation().Text_Parenthesis(true),
+	)
+}
+
 func (ds *docServer) writeSimpleStatsBlock(page *htmlPage, stats *code.Stats) {
 	text := page.Translation().Text_SimpleStats(stats)
 	text = strings.Replace(text, "\n", "\n\t", -1)
@@ -331,6 +379,7 @@ type PackageForListing struct {
 	DepHeight      int32
 	DepDepth       int32 // The value mains how close to main pacakges.
 	LOC            int32
+	NumUses        int32 // by other packages
 
 	//IsStandard         bool
 	InWorkingDirectory bool
@@ -340,6 +389,9 @@ func (ds *docServer) buildOverviewData() *Overview {
 	numPkgs := ds.analyzer.NumPackages()
 	var pkgs = make([]PackageForListing, numPkgs)
 	var result = make([]*PackageForListing, numPkgs)
+	// All the modules in a go.work workspace are viewed as working directory modules.
+	multipleWDModules := len(ds.analyzer.WorkingDirectoryModules()) > 1
+	useCounts := ds.analyzer.PackageExternalUseCounts()
 	for i := range result {
 		pkg := &pkgs[i]
 		result[i] = pkg
@@ -361,11 +413,13 @@ func (ds *docServer) buildOverviewData() *Overview {
 		pkg.DepHeight = p.DepHeight
 		pkg.DepDepth = p.DepDepth
 		pkg.NumImportedBys = int32(len(p.DepedBys))
+		pkg.NumUses = int32(useCounts[p.Path])
 		if pkg.Name == "builtin" {
 			pkg.NumImportedBys = int32(numPkgs) - 1
 		}
 
-		pkg.InWorkingDirectory = strings.HasPrefix(p.Directory, ds.initialWorkingDirectory)
+		pkg.InWorkingDirectory = strings.HasPrefix(p.Directory, ds.initialWorkingDirectory) ||
+			multipleWDModules && ds.analyzer.IsWorkingDirectoryModule(p.Module())
 	}
 
 	// ToDo: might be problematic sometimes. Should sort token by token.
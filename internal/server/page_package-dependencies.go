@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+
+	"go101.org/golds/code"
 )
 
 func (ds *docServer) packageDependenciesPage(w http.ResponseWriter, r *http.Request, pkgPath string) {
@@ -63,6 +65,14 @@ type PackageDependencyInfo struct {
 
 	Imports     []*PackageForListing
 	ImportedBys []*PackageForListing
+
+	// The shortest import chains from the main packages (or from the
+	// working-directory packages if no main packages import the package)
+	// to the package. Each chain starts with its root and ends with the package.
+	ImportChains [][]*code.Package
+
+	// The modules of the transitive imports, for filtering the dependency graph.
+	GraphModules []string
 }
 
 func (ds *docServer) buildPackageDependenciesData(pkgPath string) *PackageDependencyInfo {
@@ -112,9 +122,73 @@ func (ds *docServer) buildPackageDependenciesData(pkgPath string) *PackageDepend
 	ImprovePackagesForListing(result.Imports)
 	ImprovePackagesForListing(result.ImportedBys)
 
+	result.ImportChains = ds.buildImportChains(pkg)
+
+	seen := map[*code.Package]bool{pkg: true}
+	modules := map[string]bool{}
+	deps := []*code.Package{pkg}
+	for i := 0; i < len(deps); i++ {
+		modules[packageModulePagePath(deps[i])] = true
+		for _, dep := range deps[i].Deps {
+			if !seen[dep] {
+				seen[dep] = true
+				deps = append(deps, dep)
+			}
+		}
+	}
+	for m := range modules {
+		if m != "" {
+			result.GraphModules = append(result.GraphModules, m)
+		}
+	}
+	sort.Strings(result.GraphModules)
+
 	return result
 }
 
+// buildImportChains works like "go mod why" but for packages.
+// The chains are found by a breadth-first search through the DepedBys lists.
+func (ds *docServer) buildImportChains(pkg *code.Package) [][]*code.Package {
+	next := map[*code.Package]*code.Package{pkg: nil}
+	importers := []*code.Package{pkg}
+	for i := 0; i < len(importers); i++ {
+		p := importers[i]
+		for _, by := range p.DepedBys {
+			if _, seen := next[by]; !seen {
+				next[by] = p
+				importers = append(importers, by)
+			}
+		}
+	}
+
+	var mains, wdPkgs []*code.Package
+	for _, p := range importers[1:] {
+		if p.PPkg.Name == "main" {
+			mains = append(mains, p)
+		} else if ds.analyzer.IsWorkingDirectoryModule(p.Module()) {
+			wdPkgs = append(wdPkgs, p)
+		}
+	}
+	roots := mains
+	if len(roots) == 0 {
+		roots = wdPkgs
+	}
+
+	chains := make([][]*code.Package, len(roots))
+	for i, root := range roots {
+		for p := root; p != nil; p = next[p] {
+			chains[i] = append(chains[i], p)
+		}
+	}
+	sort.Slice(chains, func(a, b int) bool {
+		if len(chains[a]) != len(chains[b]) {
+			return len(chains[a]) < len(chains[b])
+		}
+		return chains[a][0].Path < chains[b][0].Path
+	})
+	return chains
+}
+
 func (ds *docServer) buildPackageDependenciesPage(w http.ResponseWriter, depInfo *PackageDependencyInfo) []byte {
 	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_DependencyRelations(depInfo.ImportPath), ds.currentTheme, ds.currentTranslation, createPagePathInfo1(ResTypeDependency, depInfo.ImportPath))
 
@@ -144,5 +218,29 @@ func (ds *docServer) buildPackageDependenciesPage(w http.ResponseWriter, depInfo
 		ds.writePackagesForListing(page, depInfo.ImportedBys, false)
 	}
 
+	if len(depInfo.ImportChains) > 0 {
+		fmt.Fprint(page, "\n", `<span class="title" id="import-chains">`, page.Translation().Text_ImportChains(), " (", len(depInfo.ImportChains), `)</span>`)
+		for _, chain := range depInfo.ImportChains {
+			page.WriteString("\n\t")
+			for i, pkg := range chain {
+				if i > 0 {
+					page.WriteString(" → ")
+				}
+				if pkg.Path == depInfo.ImportPath {
+					page.WriteString(pkg.Path)
+				} else {
+					buildPageHref(page.PathInfo, createPagePathInfo1(ResTypeDependency, pkg.Path), page, pkg.Path)
+				}
+			}
+		}
+		page.WriteString("\n")
+	}
+
+	page.WriteString("</code></pre>")
+
+	if len(depInfo.Imports) > 0 {
+		ds.writeDependencyGraph(page, dependencyGraphKind_Package, depInfo.ImportPath, depInfo.GraphModules)
+	}
+
 	return page.Done(w)
 }
@@ -7,6 +7,7 @@ import (
 	"go/format"
 	"go/token"
 	"go/types"
+	"html"
 	"log"
 	"net/http"
 	"path/filepath"
@@ -74,13 +75,35 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 	//}
 	fmt.Fprintf(page, `
 <span class="title">%s</span>
-	<a href="%s#pkg-%s">%s</a>%s`,
+	<a href="%s#pkg-%s">%s</a>`,
 		page.Translation().Text_ImportPath(),
 		buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, ""), nil, ""),
 		pkg.ImportPath,
 		pkg.ImportPath,
-		page.Translation().Text_PackageDocsLinksOnOtherWebsites(godevLink, pkg.IsStandard),
 	)
+	if pkg.Package.Deprecated {
+		writeDeprecatedMark(page)
+	}
+	if pkg.IsStandard {
+		if version := ds.analyzer.StdAPIVersion(pkg.ImportPath, ""); version != "" {
+			writeStdAPIVersion(page, version)
+		}
+	}
+	page.WriteString(page.Translation().Text_PackageDocsLinksOnOtherWebsites(godevLink, pkg.IsStandard))
+
+	if m := pkg.Package.Module(); m != nil {
+		fmt.Fprintf(page, `
+
+<span class="title">%s</span>
+	`,
+			page.Translation().Text_BelongingModule(),
+		)
+		buildPageHref(page.PathInfo, createPagePathInfo1(ResTypeModule, modulePagePath(m)), page, modulePagePath(m))
+		if v := m.ActualVersion(); v != "" {
+			page.WriteByte(' ')
+			page.WriteString(v)
+		}
+	}
 
 	isBuiltin := pkg.ImportPath == "builtin"
 	if !isBuiltin {
@@ -95,6 +118,12 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 	}
 	page.WriteString("\n")
 
+	if numDeprecateds := ds.numDeprecatedItems(pkg); numDeprecateds > 0 {
+		page.WriteString("\n\t")
+		writeDeprecatedItemsToggle(page, "deprecated-showhide", numDeprecateds, "identifiers")
+		page.WriteString("\n")
+	}
+
 	var isMainPackage = pkg.Package.PPkg.Name == "main"
 
 	const classHiddenItem = "hidden"
@@ -155,7 +184,7 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 					},
 					func() {
 						page.WriteString("\n")
-						ds.renderDocComment(page, pkg.Package, "\t\t", info.DocText)
+						ds.renderDocComment(page, pkg.Package, "", "\t\t", info.DocText)
 
 						if i < len(pkg.Files)-1 {
 							page.WriteString("\n")
@@ -230,26 +259,12 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 				fid := fmt.Sprintf("example-%d", i)
 				writeFoldingBlock(page, fid, "content", "items", false,
 					func() {
-						page.AsHTMLEscapeWriter().WriteString(ex.Name)
+						page.AsHTMLEscapeWriter().WriteString("Example" + ex.Name)
 					},
 					func() {
 						page.WriteString("\n")
-
-						// ToDo: need syntax hightlight writer.
-						//       It is best to merge the example code with main code
-						//       so that the exapmle code can be rendered as normal source code.
-						if ex.Play != nil {
-							format.Node(util.NewIndentWriter(
-								page.AsHTMLEscapeWriter(),
-								[]byte{'\t', '\t'}), pkg.ExampleFileSet, ex.Play)
-						} else {
-							format.Node(util.NewIndentWriter(
-								page.AsHTMLEscapeWriter(),
-								[]byte{'\t', ' ', ' '}), pkg.ExampleFileSet, ex.Code)
-						}
-						//if i < len(pkg.Examples)-1 {
-						//	page.WriteString("\n")
-						//}
+						writeExampleRunner(page, pkg.Package, ex, "\t\t")
+						writeExampleCode(page, pkg.ExampleFileSet, ex, "\t\t")
 					},
 				)
 			}
@@ -257,6 +272,44 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		}()
 	}
 
+	if markers := pkg.Package.NoteMarkers(); len(markers) > 0 {
+		func() {
+			page.WriteString("\n")
+			page.WriteString(`<div id="notes">`)
+			defer page.WriteString("</div>")
+			fmt.Fprint(page, `<span class="title">`, page.Translation().Text_Notes(), `</span>`)
+
+			page.WriteString("\n")
+
+			for _, marker := range markers {
+				notes := pkg.Package.Notes[marker]
+				fmt.Fprintf(page, "\n\t<b>%s</b><i>", marker)
+				page.WriteString(page.Translation().Text_Parenthesis(false))
+				page.WriteString(page.Translation().Text_NumNotes(len(notes)))
+				page.WriteString(page.Translation().Text_Parenthesis(true))
+				page.WriteString("</i>\n")
+				for _, note := range notes {
+					page.WriteString("\t\t")
+					writeSrouceCodeLineLink(page, pkg.Package, pkg.Package.NotePosition(note), note.UID, "")
+					page.WriteString("\n")
+					ds.renderDocComment(page, pkg.Package, note.UID, "\t\t\t", note.Body)
+					page.WriteString("\n")
+				}
+			}
+		}()
+	}
+
+	var writeUseCount = func(obj types.Object) {
+		if obj == nil {
+			return
+		}
+		page.WriteString(`<i class="use-count">`)
+		page.WriteString(page.Translation().Text_Parenthesis(false))
+		page.WriteString(page.Translation().Text_ObjectUses(ds.analyzer.ObjectUseCount(obj)))
+		page.WriteString(page.Translation().Text_Parenthesis(true))
+		page.WriteString(`</i>`)
+	}
+
 	//var writePackageLevelValues = func(title, name string, values []code.ValueResource, numExporteds int) {
 	var writePackageLevelValues = func(title, name string, values []ResourceWithPosition, numExporteds int) {
 
@@ -279,9 +332,23 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 
 			page.WriteString("\n\n")
 
+			fmt.Fprintf(page, `<div id="exported-%s-buttons" class="js-on">`, name)
+			page.WriteString("\t/* ")
+			page.WriteString(page.Translation().Text_SortBy(""))
+			page.WriteString(page.Translation().Text_Colon(false))
+			fmt.Fprintf(page, `<label id="sort-%s-by-alphabet" class="button">`, name)
+			page.WriteString(page.Translation().Text_SortByItem("alphabet"))
+			page.WriteString(`</label>`)
+			page.WriteString(" | ")
+			fmt.Fprintf(page, `<label id="sort-%s-by-uses" class="button">`, name)
+			page.WriteString(page.Translation().Text_SortByItem("uses"))
+			page.WriteString(`</label>`)
+			page.WriteString(" */</div>")
+
 			for i, vwp := range values {
 				v := vwp.Value
 				if i == numExporteds {
+					page.WriteString("</div><div>")
 					page.WriteString("\t")
 					writeUnexportedResourcesHeader(page,
 						name, !isMainPackage, len(values)-numExporteds)
@@ -293,28 +360,47 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 				if unexported { // !v.Exported() {
 					extraClass = " " + classHiddenItem
 				}
+				if ds.analyzer.IsObjectDeprecated(vwp.Object) {
+					extraClass += " deprecated"
+				}
 
-				fmt.Fprintf(page, `<div class="anchor value-res%s" id="name-%s">`, extraClass, v.Name())
+				fmt.Fprintf(page, `<div class="anchor value-res%s" id="name-%s" data-uses="%d">`, extraClass, v.Name(), vwp.NumUses)
 				if unexported {
 					page.WriteString("<i>")
 				}
 				page.WriteString("\t")
 
 				var writeFuncTypeParameters func()
+				var instantiations []code.Instantiation
 				//>> 1.18
 				if fv, ok := v.(*code.Function); ok {
 					writeFuncTypeParameters = ds.writeTypeParameterListCallbackForFunction(page, pkg.Package, fv)
+					if writeFuncTypeParameters != nil {
+						instantiations = ds.analyzer.ObjectInstantiations(fv.Func)
+					}
 				}
 				//<<
 
-				if doc := v.Documentation(); doc == "" && writeFuncTypeParameters == nil {
+				examples := pkg.Package.ExamplesOf(v.Name())
+
+				if doc := v.Documentation(); doc == "" && writeFuncTypeParameters == nil && len(examples) == 0 {
 					page.WriteString(`<span class="nodocs">`)
 					ds.writeResourceIndexHTML(page, pkg.Package, v, true, true, true)
 					page.WriteString(`</span>`)
+					if !unexported {
+						writeUseCount(vwp.Object)
+					}
+					ds.writeDeprecatedMarkForObject(page, vwp.Object)
+					ds.writeStdAPIVersionForObject(page, vwp.Object)
 				} else {
 					writeFoldingBlock(page, v.Name(), "content", "docs", false,
 						func() {
 							ds.writeResourceIndexHTML(page, pkg.Package, v, true, true, true)
+							if !unexported {
+								writeUseCount(vwp.Object)
+							}
+							ds.writeDeprecatedMarkForObject(page, vwp.Object)
+							ds.writeStdAPIVersionForObject(page, vwp.Object)
 						},
 						func() {
 							if writeFuncTypeParameters != nil {
@@ -324,7 +410,18 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 
 							if doc != "" {
 								page.WriteString("\n")
-								ds.renderDocComment(page, pkg.Package, "\t\t", doc)
+								ds.renderDocComment(page, pkg.Package, v.Name(), "\t\t", doc)
+								page.WriteString("\n")
+							}
+
+							if len(instantiations) > 0 {
+								page.WriteString("\n\t\t")
+								ds.writeInstantiations(page, pkg.Package, v.Name(), instantiations)
+								page.WriteString("\n")
+							}
+
+							if len(examples) > 0 {
+								writeExamples(page, pkg.Package, pkg.ExampleFileSet, v.Name(), "\t\t", examples)
 								page.WriteString("\n")
 							}
 
@@ -348,14 +445,22 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		}()
 	}
 
-	var writeItemWrapper = func(exported bool) (f func()) {
+	var writeItemWrapper = func(exported, deprecated bool) (f func()) {
+		extraClass := ""
+		if deprecated {
+			extraClass = " deprecated"
+		}
 		if exported {
-			page.WriteString(`<span>`)
+			if deprecated {
+				page.WriteString(`<span class="deprecated">`)
+			} else {
+				page.WriteString(`<span>`)
+			}
 			f = func() {
 				page.WriteString(`</span>`)
 			}
 		} else {
-			fmt.Fprintf(page, `<span class="%s"><i>`, classHiddenItem)
+			fmt.Fprintf(page, `<span class="%s%s"><i>`, classHiddenItem, extraClass)
 			f = func() {
 				page.WriteString(`</i></span>`)
 			}
@@ -410,6 +515,10 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 	page.WriteString(`<label id="sort-types-by-popularity" class="button">`)
 	page.WriteString(page.Translation().Text_SortByItem("popularity"))
 	page.WriteString(`</label>`)
+	page.WriteString(" | ")
+	page.WriteString(`<label id="sort-types-by-uses" class="button">`)
+	page.WriteString(page.Translation().Text_SortByItem("uses"))
+	page.WriteString(`</label>`)
 	page.WriteString(" */</div>")
 
 	for i, tdwp := range pkg.TypeNames {
@@ -425,21 +534,36 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		if !typeIsExported {
 			extraClass = " " + classHiddenItem
 		}
-		fmt.Fprintf(page, `<div class="anchor type-res%s" id="name-%s" data-popularity="%d">`, extraClass, td.TypeName.Name(), td.Popularity)
+		if ds.analyzer.IsObjectDeprecated(td.TypeName.TypeName) {
+			extraClass += " deprecated"
+		}
+		fmt.Fprintf(page, `<div class="anchor type-res%s" id="name-%s" data-popularity="%d" data-uses="%d">`, extraClass, td.TypeName.Name(), td.Popularity, td.NumUses)
 		page.WriteString("\t")
 
 		//>> 1.18
 		var writeTypeTypeParameters = ds.writeTypeParameterListCallbackForTypeName(page, pkg.Package, td.TypeName)
 		//<<
 
-		if doc := td.TypeName.Documentation(); doc == "" && writeTypeTypeParameters == nil && td.AllListsAreBlank {
+		examples := pkg.Package.ExamplesOf(td.TypeName.Name())
+
+		if doc := td.TypeName.Documentation(); doc == "" && writeTypeTypeParameters == nil && td.AllListsAreBlank && len(examples) == 0 {
 			page.WriteString(`<span class="nodocs">`)
 			ds.writeResourceIndexHTML(page, pkg.Package, td.TypeName, true, true, false)
 			page.WriteString(`</span>`)
+			if typeIsExported {
+				writeUseCount(td.TypeName.TypeName)
+			}
+			ds.writeDeprecatedMarkForObject(page, td.TypeName.TypeName)
+			ds.writeStdAPIVersionForObject(page, td.TypeName.TypeName)
 		} else {
 			writeFoldingBlock(page, td.TypeName.Name(), "content", "docs", false,
 				func() {
 					ds.writeResourceIndexHTML(page, pkg.Package, td.TypeName, true, true, false)
+					if typeIsExported {
+						writeUseCount(td.TypeName.TypeName)
+					}
+					ds.writeDeprecatedMarkForObject(page, td.TypeName.TypeName)
+					ds.writeStdAPIVersionForObject(page, td.TypeName.TypeName)
 				},
 				func() {
 					if writeTypeTypeParameters != nil {
@@ -451,7 +575,14 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 
 					if doc != "" {
 						page.WriteString("\n")
-						ds.renderDocComment(page, pkg.Package, "\t\t", doc)
+						ds.renderDocComment(page, pkg.Package, td.TypeName.Name(), "\t\t", doc)
+					}
+
+					if len(examples) > 0 {
+						if doc != "" {
+							page.WriteString("\n")
+						}
+						writeExamples(page, pkg.Package, pkg.ExampleFileSet, td.TypeName.Name(), "\t\t", examples)
 					}
 
 					// ToDo: for alias, if its denoting type is an exported named type, then stop here.
@@ -477,25 +608,35 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 										continue
 									}
 									func() {
-										defer writeItemWrapper(exported)()
+										defer writeItemWrapper(exported, ds.analyzer.IsObjectDeprecated(fld.Object()))()
 
 										if fldDoc, fldComment := fld.Field.Documentation(), fld.Field.Comment(); fldDoc == "" && fldComment == "" {
 											page.WriteString(`<span class="nodocs">`)
 											ds.writeFieldForListing(page, pkg.Package, fld, td.TypeName)
 											page.WriteString(`</span>`)
+											if exported {
+												writeUseCount(fld.Object())
+											}
+											ds.writeDeprecatedMarkForObject(page, fld.Object())
+											ds.writeStdAPIVersionForObject(page, fld.Object())
 										} else {
 											writeFoldingBlock(page, td.TypeName.Name(), "field-"+fld.Name(), "docs", false,
 												func() {
 													ds.writeFieldForListing(page, pkg.Package, fld, td.TypeName)
+													if exported {
+														writeUseCount(fld.Object())
+													}
+													ds.writeDeprecatedMarkForObject(page, fld.Object())
+													ds.writeStdAPIVersionForObject(page, fld.Object())
 												},
 												func() {
 													if fldDoc != "" {
 														page.WriteString("\n")
-														ds.renderDocComment(page, pkg.Package, "\t\t\t\t", fldDoc)
+														ds.renderDocComment(page, pkg.Package, td.TypeName.Name()+"."+fld.Name(), "\t\t\t\t", fldDoc)
 													}
 													if fldComment != "" {
 														page.WriteString("\n")
-														ds.renderDocComment(page, pkg.Package, "\t\t\t\t// ", fldComment)
+														ds.renderDocComment(page, pkg.Package, td.TypeName.Name()+"."+fld.Name(), "\t\t\t\t// ", fldComment)
 													}
 													page.WriteString("\n")
 												})
@@ -532,25 +673,43 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 										continue
 									}
 									func() {
-										defer writeItemWrapper(exported)()
+										defer writeItemWrapper(exported, ds.analyzer.IsObjectDeprecated(mthd.Object()))()
 
-										if mthdDoc, mthdComment := mthd.Method.Documentation(), mthd.Method.Comment(); mthdDoc == "" && mthdComment == "" {
+										mthdExamples := pkg.Package.ExamplesOf(td.TypeName.Name() + "." + mthd.Name())
+
+										if mthdDoc, mthdComment := mthd.Method.Documentation(), mthd.Method.Comment(); mthdDoc == "" && mthdComment == "" && len(mthdExamples) == 0 {
 											page.WriteString(`<span class="nodocs">`)
 											ds.writeMethodForListing(page, pkg.Package, mthd, td.TypeName, true, false)
 											page.WriteString(`</span>`)
+											if exported {
+												writeUseCount(mthd.Object())
+											}
+											ds.writeDeprecatedMarkForObject(page, mthd.Object())
+											ds.writeStdAPIVersionForObject(page, mthd.Object())
 										} else {
 											writeFoldingBlock(page, td.TypeName.Name(), "method-"+mthd.Name(), "docs", false,
 												func() {
 													ds.writeMethodForListing(page, pkg.Package, mthd, td.TypeName, true, false)
+													if exported {
+														writeUseCount(mthd.Object())
+													}
+													ds.writeDeprecatedMarkForObject(page, mthd.Object())
+													ds.writeStdAPIVersionForObject(page, mthd.Object())
 												},
 												func() {
 													if mthdDoc != "" {
 														page.WriteString("\n")
-														ds.renderDocComment(page, pkg.Package, "\t\t\t\t", mthdDoc)
+														ds.renderDocComment(page, pkg.Package, td.TypeName.Name()+"."+mthd.Name(), "\t\t\t\t", mthdDoc)
 													}
 													if mthdComment != "" {
 														page.WriteString("\n")
-														ds.renderDocComment(page, pkg.Package, "\t\t\t\t// ", mthdComment)
+														ds.renderDocComment(page, pkg.Package, td.TypeName.Name()+"."+mthd.Name(), "\t\t\t\t// ", mthdComment)
+													}
+													if len(mthdExamples) > 0 {
+														if mthdDoc != "" || mthdComment != "" {
+															page.WriteString("\n")
+														}
+														writeExamples(page, pkg.Package, pkg.ExampleFileSet, td.TypeName.Name(), "\t\t\t\t", mthdExamples)
 													}
 													page.WriteString("\n")
 												},
@@ -588,13 +747,14 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 										continue
 									}
 									func() {
-										defer writeItemWrapper(exported)()
+										defer writeItemWrapper(exported, false)()
 
 										ds.writeTypeForListing(page, by, pkg.Package, "", DotMStyle_NotShow, td.TypeName)
 										//if _, ok := by.TypeName.Denoting.TT.Underlying().(*types.Interface); ok {
 										if _, ok := by.BaseType.TT.Underlying().(*types.Interface); ok {
 											page.WriteString(" <i>(interface)</i>")
 										}
+										ds.writeImplementationNotes(page, by, pkg.Package, td.TypeName, true)
 									}()
 								}
 
@@ -609,6 +769,27 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 							},
 						)
 					}
+					if count := len(td.SatisfiedBys); count > 0 {
+						hasLists = true
+						page.WriteString("\n\t\t")
+						writeFoldingBlock(page, td.TypeName.Name(), "satisfiedby", "items", false,
+							func() {
+								writeItemHeader(
+									page.Translation().Text_SatisfiedBy(),
+									page.Translation().Text_PackageLevelResourceSimpleStat(true, count, count, false),
+								)
+							},
+							func() {
+								for _, by := range td.SatisfiedBys {
+									func() {
+										defer writeItemWrapper(true, false)()
+
+										ds.writeTypeForListing(page, by, pkg.Package, "", DotMStyle_NotShow, td.TypeName)
+									}()
+								}
+							},
+						)
+					}
 					if count, numExporteds := len(td.Implements), int(td.NumExportedImpls); count > 0 {
 						hasLists = true
 						page.WriteString("\n\t\t")
@@ -627,9 +808,10 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 										continue
 									}
 									func() {
-										defer writeItemWrapper(exported)()
+										defer writeItemWrapper(exported, false)()
 
 										ds.writeTypeForListing(page, impl, pkg.Package, td.TypeName.Name(), DotMStyle_NotShow, td.TypeName)
+										ds.writeImplementationNotes(page, impl, pkg.Package, td.TypeName, false)
 									}()
 								}
 
@@ -662,7 +844,7 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 										continue
 									}
 									func() {
-										defer writeItemWrapper(exported)()
+										defer writeItemWrapper(exported, false)()
 
 										ds.writeValueForListing(page, v, pkg.Package, td.TypeName)
 									}()
@@ -697,7 +879,7 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 										continue
 									}
 									func() {
-										defer writeItemWrapper(exported)()
+										defer writeItemWrapper(exported, false)()
 
 										ds.writeValueForListing(page, v, pkg.Package, td.TypeName)
 									}()
@@ -732,7 +914,7 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 										continue
 									}
 									func() {
-										defer writeItemWrapper(exported)()
+										defer writeItemWrapper(exported, false)()
 
 										ds.writeValueForListing(page, v, pkg.Package, td.TypeName)
 									}()
@@ -749,6 +931,11 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 							},
 						)
 					}
+					if len(td.Instantiations) > 0 {
+						hasLists = true
+						page.WriteString("\n\t\t")
+						ds.writeInstantiations(page, pkg.Package, td.TypeName.Name(), td.Instantiations)
+					}
 					page.WriteByte('\n')
 					if hasLists {
 						page.WriteByte('\n')
@@ -812,6 +999,36 @@ Done:
 	return page.Done(w)
 }
 
+// numDeprecatedItems returns the number of the deprecated identifiers
+// listed in the package details page, including fields and methods.
+func (ds *docServer) numDeprecatedItems(pkg *PackageDetails) int {
+	n := 0
+	for _, tdwp := range pkg.TypeNames {
+		td := tdwp.Type
+		if ds.analyzer.IsObjectDeprecated(td.TypeName.TypeName) {
+			n++
+		}
+		for _, fld := range td.Fields {
+			if ds.analyzer.IsObjectDeprecated(fld.Object()) {
+				n++
+			}
+		}
+		for _, mthd := range td.Methods {
+			if ds.analyzer.IsObjectDeprecated(mthd.Object()) {
+				n++
+			}
+		}
+	}
+	for _, values := range [][]ResourceWithPosition{pkg.Functions, pkg.Variables, pkg.Constants} {
+		for _, vwp := range values {
+			if ds.analyzer.IsObjectDeprecated(vwp.Object) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
 type ResourceWithPosition struct {
 	Position  token.Position
 	FileIndex int32 // -1 means owner file not found
@@ -823,6 +1040,9 @@ type ResourceWithPosition struct {
 	// 2. too many type assertions
 	Type  *TypeDetails       // for PackageDetails.TypeNames only. Alway nil for FileInfo.
 	Value code.ValueResource // also for TypeNames in FileInfo
+
+	Object  types.Object
+	NumUses int32
 }
 
 type FileInfo struct {
@@ -866,7 +1086,7 @@ type PackageDetails struct {
 
 	// ToDo: use go/doc
 	//IntroductionCode template.HTML
-	Examples       []*doc.Example
+	Examples       []*doc.Example // package-level ones, see Package.ExamplesOf for others
 	ExampleFileSet *token.FileSet
 }
 
@@ -874,6 +1094,7 @@ type TypeDetails struct {
 	TypeName         *code.TypeName
 	AllListsAreBlank bool
 	Popularity       int
+	NumUses          int32
 
 	Aliases []*TypeForListing // excluding self if self is an alias.
 
@@ -891,6 +1112,9 @@ type TypeDetails struct {
 	NumExportedImpedBys int32
 	NumExportedImpls    int32
 
+	// For constraint-only interface types only.
+	SatisfiedBys []*TypeForListing // exported ones only
+
 	// ToDo: Including functions/methods, but not variables now?
 
 	AsInputsOf              []*ValueForListing
@@ -905,6 +1129,9 @@ type TypeDetails struct {
 
 	Values            []*ValueForListing
 	NumExportedValues int32
+
+	// For generic types only.
+	Instantiations []code.Instantiation
 }
 
 type ValueForListing struct {
@@ -923,6 +1150,11 @@ type TypeForListing struct {
 	IsPointer    bool
 	InCurrentPkg bool
 	CommonPath   string // relative to the current package
+
+	// The instantiation of the owner generic type through which
+	// an implementation relation is established.
+	Via                 *code.TypeInfo
+	ViaNameWithTypeArgs string
 }
 
 type SelectorForListing struct {
@@ -932,18 +1164,26 @@ type SelectorForListing struct {
 	numDuplicatedMiddlesWithLast int
 }
 
-// ToDo: adjust the coefficients
 func (td *TypeDetails) calculatePopularity() {
-	numValues := len(td.Values)
+	td.Popularity = calculateTypePopularity(len(td.Values), len(td.Methods),
+		len(td.Implements), len(td.ImplementedBys), len(td.AsInputsOf), len(td.AsOutputsOf), int(td.NumUses))
+}
+
+// ToDo: adjust the coefficients
+func calculateTypePopularity(numValues, numMethods, numImpls, numImpedBys, numAsInputsOfs, numAsOutputsOfs, numUses int) int {
 	if numValues > 3 {
 		numValues = 3
 	}
-	td.Popularity = numValues*5 +
-		len(td.Methods)*50 +
-		len(td.Implements)*50 +
-		len(td.ImplementedBys)*150 +
-		len(td.AsInputsOf)*35 +
-		len(td.AsOutputsOf)*75
+	if numUses > 300 {
+		numUses = 300
+	}
+	return numValues*5 +
+		numMethods*50 +
+		numImpls*50 +
+		numImpedBys*150 +
+		numAsInputsOfs*35 +
+		numAsOutputsOfs*75 +
+		numUses*2
 }
 
 // ds should be locked before calling this method.
@@ -1020,8 +1260,11 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, alsoCo
 			findex = int32(i)
 		}
 		rwp := ResourceWithPosition{Position: pos, FileIndex: findex, Offset: off}
+		if rwp.Object = resourceObject(res); rwp.Object != nil {
+			rwp.NumUses = int32(analyzer.ObjectUseCount(rwp.Object))
+		}
 		if tn, ok := res.(*code.TypeName); ok {
-			rwp.Type = &TypeDetails{TypeName: tn}
+			rwp.Type = &TypeDetails{TypeName: tn, NumUses: rwp.NumUses}
 		} else {
 			rwp.Value = res.(code.ValueResource)
 		}
@@ -1155,6 +1398,7 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, alsoCo
 		td.ImplementedBys, td.NumExportedImpedBys = buildTypeImplementedByList(analyzer, pkg, denoting, alsoCollectNonExporteds, tn)
 		//td.Implements = make([]code.Implementation, 0, len(denoting.Implements))
 		td.Implements, td.NumExportedImpls = buildTypeImplementsList(analyzer, pkg, denoting, alsoCollectNonExporteds, tn)
+		td.SatisfiedBys = buildTypeSatisfiedByList(analyzer, pkg, denoting)
 
 		if isBuiltin {
 			continue
@@ -1172,6 +1416,10 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, alsoCo
 			values = append(values, t.AsTypesOf...)
 		}
 		td.Values, td.NumExportedValues = buildValueList(values, pkg, alsoCollectNonExporteds)
+
+		if len(tn.TypeParams) > 0 {
+			td.Instantiations = analyzer.ObjectInstantiations(tn.TypeName)
+		}
 	}
 
 	for _, tdwp := range typeResources {
@@ -1183,9 +1431,11 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, alsoCo
 				len(td.Methods) == 0 &&
 				len(td.ImplementedBys) == 0 &&
 				len(td.Implements) == 0 &&
+				len(td.SatisfiedBys) == 0 &&
 				len(td.Values) == 0 &&
 				len(td.AsInputsOf) == 0 &&
-				len(td.AsOutputsOf) == 0
+				len(td.AsOutputsOf) == 0 &&
+				len(td.Instantiations) == 0
 	}
 
 	// default sort-by
@@ -1225,12 +1475,38 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, alsoCo
 	pkgDetails.Constants = constants
 	pkgDetails.TypeNames = typeResources
 
-	pkgDetails.Examples = pkg.Examples
+	pkgDetails.Examples = pkg.ExamplesOf("")
 	pkgDetails.ExampleFileSet = analyzer.ExampleFileSet()
 
 	return pkgDetails
 }
 
+// resourceObject returns the types.Object of a package-level resource.
+func resourceObject(res code.Resource) types.Object {
+	switch r := res.(type) {
+	case *code.TypeName:
+		if r.TypeName != nil {
+			return r.TypeName
+		}
+	case *code.Function:
+		if r.Func != nil {
+			return r.Func
+		}
+		if r.Builtin != nil {
+			return r.Builtin
+		}
+	case *code.Variable:
+		if r.Var != nil {
+			return r.Var
+		}
+	case *code.Constant:
+		if r.Const != nil {
+			return r.Const
+		}
+	}
+	return nil
+}
+
 func buildTypeFieldList(denoting *code.TypeInfo, alsoCollectNonExporteds bool) ([]*SelectorForListing, int32) {
 	numExporteds, fields := int32(0), make([]*code.Selector, 0, len(denoting.AllFields))
 	for _, fld := range denoting.AllFields {
@@ -1374,9 +1650,56 @@ func buildTypeImplementedByList(analyzer *code.CodeAnalyzer, pkg *code.Package,
 			}
 		}
 	}
+
+	// The types implementing the instantiations of a generic interface type.
+	if denoting.TypeName != nil && denoting.TypeName.Denoting == denoting {
+		var listed map[*code.TypeInfo]bool
+		for _, ins := range analyzer.InstantiatedTypes(denoting.TypeName) {
+			if listed == nil {
+				listed = make(map[*code.TypeInfo]bool, len(implementedBys))
+				for _, by := range implementedBys {
+					listed[by.BaseType] = true
+				}
+			}
+			for _, impledBy := range ins.ImplementedBys {
+				nt, isPointer := analyzer.RetrieveNamedType(impledBy)
+				if nt == nil || nt.TypeName == exceptTypeName || listed[nt] {
+					continue
+				}
+
+				if e := nt.TypeName.Exported(); alsoCollectNonExporteds || e {
+					implementedBys = append(implementedBys, TypeForListing{
+						BaseType:  nt,
+						IsPointer: isPointer,
+						Via:       ins,
+					})
+					if e {
+						numExporteds++
+					}
+				}
+			}
+		}
+	}
+
 	return sortTypeList(implementedBys, pkg), numExporteds
 }
 
+// buildTypeSatisfiedByList only lists the exported types satisfying
+// a constraint, for too many unexported types might satisfy it.
+func buildTypeSatisfiedByList(analyzer *code.CodeAnalyzer, pkg *code.Package, denoting *code.TypeInfo) []*TypeForListing {
+	bys := analyzer.SatisfiedBys(denoting)
+	satisfiedBys := make([]TypeForListing, 0, len(bys))
+	for _, by := range bys {
+		if by.TypeName.Exported() {
+			satisfiedBys = append(satisfiedBys, TypeForListing{
+				BaseType: by,
+			})
+		}
+	}
+
+	return sortTypeList(satisfiedBys, pkg)
+}
+
 func buildTypeImplementsList(analyzer *code.CodeAnalyzer, pkg *code.Package, denoting *code.TypeInfo, alsoCollectNonExporteds bool, exceptTypeName *code.TypeName) ([]*TypeForListing, int32) {
 	//implements = make([]code.Implementation, 0, len(denoting.Implements))
 	numExporteds, implements := int32(0), make([]TypeForListing, 0, len(denoting.Implements))
@@ -1404,6 +1727,37 @@ func buildTypeImplementsList(analyzer *code.CodeAnalyzer, pkg *code.Package, den
 			}
 		}
 	}
+
+	// The interfaces implemented by the instantiations of a generic type.
+	if denoting.TypeName != nil && denoting.TypeName.Denoting == denoting {
+		var listed map[*code.TypeInfo]bool
+		for _, ins := range analyzer.InstantiatedTypes(denoting.TypeName) {
+			if listed == nil {
+				listed = make(map[*code.TypeInfo]bool, len(implements))
+				for _, impl := range implements {
+					listed[impl.BaseType] = true
+				}
+			}
+			for _, impl := range analyzer.CleanImplements(ins, false) {
+				itn := impl.Interface.TypeName
+				if itn == exceptTypeName || listed[impl.Interface] {
+					continue
+				}
+				if e := itn.Exported(); alsoCollectNonExporteds || e {
+					_, isPointer := impl.Impler.TT.(*types.Pointer)
+					implements = append(implements, TypeForListing{
+						BaseType:  impl.Interface,
+						IsPointer: isPointer,
+						Via:       ins,
+					})
+					if e {
+						numExporteds++
+					}
+				}
+			}
+		}
+	}
+
 	return sortTypeList(implements, pkg), numExporteds
 }
 
@@ -1422,19 +1776,10 @@ func sortTypeList(typeList []TypeForListing, pkg *code.Package) []*TypeForListin
 			}
 			t.NameWithTypeArgs = t.BaseType.TypeName.Name()
 		} else {
-			var b strings.Builder
-			b.Grow(64)
-			b.WriteString(t.BaseType.TypeName.Name())
-			b.WriteByte('[')
-			for i := range ins.TypeArgs {
-				if i > 0 {
-					b.WriteString(", ")
-				}
-				var typeArg = &ins.TypeArgs[i]
-				writePlainTypeArg(&b, typeArg.Expr, typeArg.Pkg, pkg, true)
-			}
-			b.WriteByte(']')
-			t.NameWithTypeArgs = b.String()
+			t.NameWithTypeArgs = plainNameWithTypeArgs(t.BaseType, pkg)
+		}
+		if t.Via != nil {
+			t.ViaNameWithTypeArgs = plainNameWithTypeArgs(t.Via, pkg)
 		}
 
 		result = append(result, t)
@@ -1454,7 +1799,7 @@ func sortTypeList(typeList []TypeForListing, pkg *code.Package) []*TypeForListin
 		if x, y := result[a].InCurrentPkg, result[b].InCurrentPkg; x || y {
 			if x && y {
 				//return strings.ToLower(result[a].BaseType.TypeName.Name()) < strings.ToLower(result[b].BaseType.TypeName.Name())
-				return compareNamesWithTypeArgs(result[a].NameWithTypeArgs, result[b].NameWithTypeArgs) <= 0
+				return compareTypesForListingByNames(result[a], result[b])
 			}
 			return x
 		}
@@ -1475,7 +1820,7 @@ func sortTypeList(typeList []TypeForListing, pkg *code.Package) []*TypeForListin
 		r := code.ComparePackagePaths_ThreeWay(pathA, pathB, '/')
 		if r == 0 {
 			//return strings.ToLower(result[a].BaseType.TypeName.Name()) < strings.ToLower(result[b].BaseType.TypeName.Name())
-			return compareNamesWithTypeArgs(result[a].NameWithTypeArgs, result[b].NameWithTypeArgs) <= 0
+			return compareTypesForListingByNames(result[a], result[b])
 		}
 		if pathA == "builtin" {
 			return true
@@ -1492,6 +1837,10 @@ func sortTypeList(typeList []TypeForListing, pkg *code.Package) []*TypeForListin
 	old := result
 	result = result[:0]
 	for _, t := range old {
+		if t.Via != nil {
+			result = append(result, t)
+			continue
+		}
 		bt := t.BaseType
 		if lastTypeName != bt.TypeName {
 			if bt.TypeName.Denoting == bt {
@@ -1506,6 +1855,31 @@ func sortTypeList(typeList []TypeForListing, pkg *code.Package) []*TypeForListin
 	return result
 }
 
+func plainNameWithTypeArgs(t *code.TypeInfo, pkg *code.Package) string {
+	var b strings.Builder
+	b.Grow(64)
+	b.WriteString(t.TypeName.Name())
+	if ins := t.Instantiated; ins != nil {
+		b.WriteByte('[')
+		for i := range ins.TypeArgs {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			var typeArg = &ins.TypeArgs[i]
+			writePlainTypeArg(&b, typeArg.Expr, typeArg.Pkg, pkg, true)
+		}
+		b.WriteByte(']')
+	}
+	return b.String()
+}
+
+func compareTypesForListingByNames(a, b *TypeForListing) bool {
+	if r := compareNamesWithTypeArgs(a.NameWithTypeArgs, b.NameWithTypeArgs); r != 0 {
+		return r < 0
+	}
+	return compareNamesWithTypeArgs(a.ViaNameWithTypeArgs, b.ViaNameWithTypeArgs) <= 0
+}
+
 var asciiOrder [256]byte
 
 func init() {
@@ -1776,6 +2150,10 @@ const (
 // writeReceiverLink=false means for method implementation page.
 // exportMethod is for method implementation page only.
 func (ds *docServer) writeTypeForListing(page *htmlPage, t *TypeForListing, pkg *code.Package, implerName string, dotMStyle int, forTypeName *code.TypeName) {
+	if t.Via != nil && implerName != "" {
+		implerName = html.EscapeString(t.ViaNameWithTypeArgs)
+	}
+
 	if implerName == "" {
 	} else if dotMStyle == DotMStyle_NotShow {
 		if t.IsPointer {
@@ -1866,6 +2244,81 @@ func (ds *docServer) writeTypeForListing(page *htmlPage, t *TypeForListing, pkg
 	ds.writeTypeArgumentList(page, pkg, forTypeName, t.BaseType)
 }
 
+// writeInstantiations writes the instantiations of a generic type or function
+// as a folding block. Each instantiation is followed by its sites.
+func (ds *docServer) writeInstantiations(page *htmlPage, docPkg *code.Package, resName string, instantiations []code.Instantiation) {
+	var numSites int
+	for i := range instantiations {
+		numSites += len(instantiations[i].Sites)
+	}
+
+	var qualifier = func(p *types.Package) string {
+		if p.Path() == docPkg.Path {
+			return ""
+		}
+		return p.Path()
+	}
+
+	writeFoldingBlock(page, resName, "instantiations", "items", false,
+		func() {
+			page.WriteString(page.Translation().Text_Instantiations())
+			page.WriteString(page.Translation().Text_Parenthesis(false))
+			page.WriteString("<i>")
+			page.WriteString(page.Translation().Text_InstantiationsStat(len(instantiations), numSites))
+			page.WriteString("</i>")
+			page.WriteString(page.Translation().Text_Parenthesis(true))
+		},
+		func() {
+			for i := range instantiations {
+				inst := &instantiations[i]
+				page.WriteString("<span>\n\t\t\t")
+				page.WriteString(resName)
+				page.Write(leftSquare)
+				for k, targ := range inst.TypeArgs {
+					if k > 0 {
+						page.WriteString(", ")
+					}
+					page.WriteString(html.EscapeString(types.TypeString(targ, qualifier)))
+				}
+				page.Write(rightSquare)
+				page.WriteString(" <i>(")
+				page.WriteString(page.Translation().Text_NumInstantiationSites(len(inst.Sites)))
+				page.WriteString(")</i>")
+				for _, site := range inst.Sites {
+					sitePkg := site.FileInfo.Pkg
+					pos := sitePkg.PPkg.Fset.PositionFor(site.AstIdent.NamePos, false)
+					linkText := fmt.Sprintf("%s#L%d", site.FileInfo.AstBareFileName(), pos.Line)
+					if sitePkg != docPkg {
+						linkText = sitePkg.Path + "/" + linkText
+					}
+					page.WriteString("\n\t\t\t\t")
+					writeSrouceCodeLineLink(page, sitePkg, pos, linkText, "")
+				}
+				page.WriteString("</span>")
+			}
+		},
+	)
+}
+
+// writeImplementationNotes writes the notes for an implementation relation
+// involving generic types: the instantiation through which an implementation
+// relation is established, and the constraints satisfied by the type arguments.
+func (ds *docServer) writeImplementationNotes(page *htmlPage, t *TypeForListing, pkg *code.Package, forTypeName *code.TypeName, isImplementedBy bool) {
+	ins := t.BaseType
+	if isImplementedBy {
+		if t.Via == nil {
+			return
+		}
+		ins = t.Via
+		page.WriteString(" <i>(")
+		page.WriteString(page.Translation().Text_AsInstantiation())
+		page.WriteString(ins.TypeName.Name())
+		ds.writeTypeArgumentList(page, pkg, forTypeName, ins)
+		page.WriteString(")</i>")
+	}
+	ds.writeTypeArgConstraints(page, pkg, forTypeName, ins)
+}
+
 func (ds *docServer) writeTypeArgumentList(page *htmlPage, docPkg *code.Package, forTypeName *code.TypeName, t *code.TypeInfo) {
 	if t.Instantiated == nil {
 		if len(t.TypeName.TypeParams) > 0 {
@@ -2246,7 +2699,7 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, currentPkg *code.Pac
 	if writeComment {
 		if comment := res.Comment(); comment != "" {
 			page.WriteString(" // ")
-			ds.renderDocComment(page, currentPkg, "", comment)
+			ds.renderDocComment(page, currentPkg, res.Name(), "", comment)
 		}
 	}
 
@@ -2740,10 +3193,25 @@ func (ds *docServer) WriteAstFieldList(w *htmlPage, fieldList *ast.FieldList, is
 		fields = append([]*ast.Field{recvParam}, fields...)
 	}
 
+	// The fields of unnamed struct types are linked to their use pages.
+	linkFields := !isParamOrResultList && funcKeywordNeeded && recvParam == nil &&
+		sourceReadingStyle == SourceReadingStyle_rich && buildIdUsesPages && codePkg != nil
+
 	for i, fld := range fields {
 		if len(fld.Names) > 0 {
 			for k, n := range fld.Names {
-				w.Write([]byte(n.Name))
+				var fieldPath string
+				if linkFields {
+					if obj, ok := codePkg.PPkg.TypesInfo.Defs[n].(*types.Var); ok {
+						fieldPath = ds.nestedFieldPath(codePkg, obj)
+					}
+				}
+				if fieldPath != "" {
+					dot := strings.IndexByte(fieldPath, '.')
+					buildPageHref(w.PathInfo, createPagePathInfo3b(ResTypeReference, codePkg.Path, "..", fieldPath[:dot], fieldPath[dot+1:]), w, n.Name)
+				} else {
+					w.Write([]byte(n.Name))
+				}
 				if k+1 < len(fld.Names) {
 					w.Write(comma)
 				}
@@ -3073,6 +3541,43 @@ func writeHiddenItemsHeader(page *htmlPage, resName, itemsCategory string, hideI
 		resName, itemsCategory, checked, showLabel, hideLabel)
 }
 
+// writeExamples writes the specified examples associated with an identifier
+// as folding blocks, each of which is on a new line.
+func writeExamples(page *htmlPage, pkg *code.Package, fset *token.FileSet, resName, indent string, examples []*doc.Example) {
+	for _, ex := range examples {
+		page.WriteString("\n")
+		page.WriteString(indent)
+		writeFoldingBlock(page, resName, "example-"+ex.Name, "items", false,
+			func() {
+				page.WriteString("<i>")
+				page.AsHTMLEscapeWriter().WriteString("Example" + ex.Name)
+				page.WriteString("</i>")
+			},
+			func() {
+				page.WriteString("\n")
+				writeExampleRunner(page, pkg, ex, indent+"\t")
+				writeExampleCode(page, fset, ex, indent+"\t")
+			},
+		)
+	}
+}
+
+// writeExampleCode writes the code of an example. Each line is indented.
+func writeExampleCode(page *htmlPage, fset *token.FileSet, ex *doc.Example, indent string) {
+	// ToDo: need syntax hightlight writer.
+	//       It is best to merge the example code with main code
+	//       so that the exapmle code can be rendered as normal source code.
+	if ex.Play != nil {
+		format.Node(util.NewIndentWriter(
+			page.AsHTMLEscapeWriter(),
+			[]byte(indent)), fset, ex.Play)
+	} else {
+		format.Node(util.NewIndentWriter(
+			page.AsHTMLEscapeWriter(),
+			[]byte(strings.TrimSuffix(indent, "\t")+"  ")), fset, ex.Code)
+	}
+}
+
 func writeFoldingBlock(page *htmlPage, resName, statName, contentKind string, expandInitially bool, writeTitleContent, listStatContent func()) {
 	checked := ""
 	if expandInitially || unfoldAllInitially {
@@ -3155,7 +3660,39 @@ func init() {
 	setRange('_', '_', 2)
 }
 
-func (ds *docServer) renderDocComment(page *htmlPage, currentPkg *code.Package, ident, mdDoc string) {
+// owner is the name of the identifier (or note) the doc belongs to.
+// It is blank for package docs.
+func (ds *docServer) renderDocComment(page *htmlPage, currentPkg *code.Package, owner, ident, mdDoc string) {
+	page.WriteString(`<span class="md-text">`)
+	ds.writeDocComment(page, currentPkg, owner, ident, mdDoc)
+	page.WriteString(`</span>`)
+}
+
+// buildDocLinkResourceURL builds the URL of a link (in doc comments)
+// to a package-level resource.
+func (ds *docServer) buildDocLinkResourceURL(page *htmlPage, pkg *code.Package, res code.Resource) string {
+	if res.Exported() || collectUnexporteds {
+		return buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, pkg.Path), nil, "", "name-", res.Name())
+	}
+
+	return buildSrouceCodeLineLink(page.PathInfo, ds.analyzer, pkg, res.Position())
+}
+
+// buildDocLinkSelectorURL builds the URL of a link (in doc comments)
+// to a field or method.
+func (ds *docServer) buildDocLinkSelectorURL(page *htmlPage, currentPkg *code.Package, sel *code.Selector) string {
+	pkg := sel.Package()
+	if pkg == nil {
+		pkg = currentPkg
+	}
+
+	return buildSrouceCodeLineLink(page.PathInfo, ds.analyzer, pkg, sel.Position())
+}
+
+// docLinkURLMaker returns a function which converts the bracketed texts,
+// like [Name], [pkg], [pkg.Name], [Type.Selector] and [pkg.Type.Selector],
+// in doc comments to link URLs. Nil is returned if doc links are not rendered.
+func (ds *docServer) docLinkURLMaker(page *htmlPage, currentPkg *code.Package) func(string) string {
 	var makeURL func(string) string
 	if renderDocLinks {
 		makeURL = func(bracketedText string) (r string) {
@@ -3198,14 +3735,6 @@ func (ds *docServer) renderDocComment(page *htmlPage, currentPkg *code.Package,
 				return ""
 			}
 
-			buildPkgResLink := func(pkg *code.Package, res code.Resource) string {
-				if res.Exported() || collectUnexporteds {
-					return buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, pkg.Path), nil, "", "name-", res.Name())
-				}
-
-				return buildSrouceCodeLineLink(page.PathInfo, ds.analyzer, pkg, res.Position())
-			}
-
 			if len(tokens) == 1 {
 				if !checkResNameRoughly(tokens[0]) {
 					return ""
@@ -3213,7 +3742,7 @@ func (ds *docServer) renderDocComment(page *htmlPage, currentPkg *code.Package,
 
 				res := currentPkg.SearchResourceByName(tokens[0])
 				if res != nil {
-					return buildPkgResLink(currentPkg, res)
+					return ds.buildDocLinkResourceURL(page, currentPkg, res)
 				}
 
 				var pkg = ds.analyzer.PackageByPath(tokens[0])
@@ -3279,7 +3808,7 @@ func (ds *docServer) renderDocComment(page *htmlPage, currentPkg *code.Package,
 
 			if len(tokens) == 2 {
 				if res != nil {
-					return buildPkgResLink(pkg, res)
+					return ds.buildDocLinkResourceURL(page, pkg, res)
 				}
 
 				tn := currentPkg.TypeNameByName(tokens[0])
@@ -3292,12 +3821,7 @@ func (ds *docServer) renderDocComment(page *htmlPage, currentPkg *code.Package,
 					return ""
 				}
 
-				pkg = sel.Package()
-				if pkg == nil {
-					pkg = currentPkg
-				}
-
-				return buildSrouceCodeLineLink(page.PathInfo, ds.analyzer, pkg, sel.Position())
+				return ds.buildDocLinkSelectorURL(page, currentPkg, sel)
 			}
 
 			// ToDo: more complex patter: StructType.Field.Field, pkg.StructType.Field.Field, ...
@@ -3321,15 +3845,8 @@ func (ds *docServer) renderDocComment(page *htmlPage, currentPkg *code.Package,
 				return ""
 			}
 
-			pkg = sel.Package()
-			if pkg == nil {
-				pkg = currentPkg
-			}
-
-			return buildSrouceCodeLineLink(page.PathInfo, ds.analyzer, pkg, sel.Position())
+			return ds.buildDocLinkSelectorURL(page, currentPkg, sel)
 		}
 	}
-	page.WriteString(`<span class="md-text">`)
-	ds.docRenderer.Render(page, mdDoc, ident, true, makeURL)
-	page.WriteString(`</span>`)
+	return makeURL
 }
@@ -0,0 +1,416 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+const maxNumSearchResults = 100
+
+// searchItem is an entry in the identifier search index.
+type searchItem struct {
+	kind      string // "type", "func", "var", "const", "field", "method"
+	name      string
+	lowerName string
+
+	pkg      *code.Package
+	typeName *code.TypeName // the owner type name for fields and methods
+	selector *code.Selector // for fields and methods only
+
+	exported   bool
+	popularity int
+}
+
+type SearchResult struct {
+	Kind       string
+	Name       string
+	TypeName   string `json:",omitempty"` // for fields and methods only
+	Package    string
+	URL        string
+	Popularity int
+
+	score int
+	item  *searchItem
+}
+
+type SearchResults struct {
+	Query   string
+	Total   int
+	Results []*SearchResult
+}
+
+// /search?q=xxx
+func (ds *docServer) searchPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	// Search results are not cached, for queries are arbitrary.
+	results := ds.buildSearchResults(strings.TrimSpace(r.FormValue("q")))
+	ds.buildSearchPage(w, results)
+}
+
+// api:search?q=xxx
+func (ds *docServer) searchAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		writeAPIError(w, http.StatusTooEarly, errors.New("analyzing is not done yet"))
+		return
+	}
+
+	results := ds.buildSearchResults(strings.TrimSpace(r.FormValue("q")))
+	data, err := json.Marshal(results)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Write(data)
+}
+
+func (ds *docServer) buildSearchPage(w http.ResponseWriter, results *SearchResults) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_Search(), ds.currentTheme, ds.currentTranslation, createPagePathInfo(ResTypeNone, "search"))
+	fmt.Fprintf(page, `
+<pre id="search"><code><span style="font-size:xx-large;">%s</span></code></pre>
+`,
+		page.Translation().Text_Search(),
+	)
+
+	writeSearchForm(page, results.Query)
+
+	if results.Query == "" {
+		return page.Done(w)
+	}
+
+	page.WriteString("<pre><code>")
+	page.WriteString(`<span class="title">`)
+	page.AsHTMLEscapeWriter().WriteString(page.Translation().Text_SearchResults(results.Query, results.Total))
+	page.WriteString("</span>\n")
+
+	for _, sr := range results.Results {
+		page.WriteString("\n\t")
+		switch sr.Kind {
+		case "field", "method":
+			page.WriteString(page.Translation().Text_ObjectKind(sr.Kind))
+		default:
+			page.WriteString(sr.Kind)
+		}
+		page.WriteByte(' ')
+		text := sr.Name
+		if sr.TypeName != "" {
+			text = sr.TypeName + "." + sr.Name
+		}
+		fmt.Fprintf(page, `<a href="%s">%s</a>`, sr.URL, text)
+		page.WriteString(` <i>`)
+		buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, sr.Package), page, sr.Package)
+		page.WriteString(`</i>`)
+	}
+	if n := results.Total - len(results.Results); n > 0 {
+		page.WriteString("\n\n\t...")
+		page.WriteString(page.Translation().Text_SearchResultsOmitted(n))
+	}
+
+	page.WriteString("</code></pre>")
+
+	return page.Done(w)
+}
+
+func writeSearchForm(page *htmlPage, query string) {
+	page.WriteString(`<pre><code><form action="/search" method="get">`)
+	page.WriteString(`<input type="text" name="q" id="search-query" size="36" value="`)
+	page.AsHTMLEscapeWriter().WriteString(query)
+	page.WriteString(`"> <input type="submit" value="`)
+	page.WriteString(page.Translation().Text_Search())
+	page.WriteString(`"></form></code></pre>`)
+}
+
+// The query might be a bare identifier or a qualified one,
+// such as "Buffer", "bytes.Buffer" or "Buffer.WriteString".
+// ds should be locked before calling this method.
+func (ds *docServer) buildSearchResults(query string) *SearchResults {
+	results := &SearchResults{Query: query}
+	if query == "" {
+		return results
+	}
+
+	var qualifier string
+	if i := strings.LastIndexByte(query, '.'); i >= 0 {
+		qualifier, query = strings.ToLower(query[:i]), query[i+1:]
+	}
+	if query == "" {
+		return results
+	}
+	lowerQuery := strings.ToLower(query)
+
+	if ds.searchItems == nil {
+		ds.searchItems = buildSearchIndex(ds.analyzer)
+	}
+
+	var srs []*SearchResult
+	for _, item := range ds.searchItems {
+		score := searchMatchScore(item.name, item.lowerName, query, lowerQuery)
+		if score == 0 {
+			continue
+		}
+		if qualifier != "" && !item.matchQualifier(qualifier) {
+			continue
+		}
+		srs = append(srs, &SearchResult{score: score, item: item})
+	}
+
+	sort.Slice(srs, func(a, b int) bool {
+		sa, sb := srs[a], srs[b]
+		if sa.score != sb.score {
+			return sa.score > sb.score
+		}
+		if ea, eb := sa.item.exported, sb.item.exported; ea != eb {
+			return ea
+		}
+		if sa.item.popularity != sb.item.popularity {
+			return sa.item.popularity > sb.item.popularity
+		}
+		if sa.item.name != sb.item.name {
+			return sa.item.name < sb.item.name
+		}
+		return sa.item.pkg.Path < sb.item.pkg.Path
+	})
+
+	results.Total = len(srs)
+	if len(srs) > maxNumSearchResults {
+		srs = srs[:maxNumSearchResults]
+	}
+
+	currentPageInfo := createPagePathInfo(ResTypeNone, "search")
+	for _, sr := range srs {
+		item := sr.item
+		sr.Kind = item.kind
+		sr.Name = item.name
+		sr.Package = item.pkg.Path
+		sr.Popularity = item.popularity
+		if item.typeName != nil {
+			sr.TypeName = item.typeName.Name()
+		}
+		sr.URL = ds.searchItemURL(currentPageInfo, item)
+	}
+	results.Results = srs
+
+	return results
+}
+
+// searchItemURL returns the URL of a search item. Like the listings
+// on package details pages, selectors are linked to their declarations.
+func (ds *docServer) searchItemURL(currentPageInfo pagePathInfo, item *searchItem) string {
+	if item.selector != nil {
+		pkg := item.selector.Package()
+		if pkg == nil {
+			pkg = item.pkg
+		}
+		return buildSrouceCodeLineLink(currentPageInfo, ds.analyzer, pkg, item.selector.Position())
+	}
+	return buildPageHref(currentPageInfo, createPagePathInfo1(ResTypePackage, item.pkg.Path), nil, "", "name-", item.name)
+}
+
+// Return 0 for not matched.
+func searchMatchScore(name, lowerName, query, lowerQuery string) int {
+	switch {
+	case name == query:
+		return 5
+	case lowerName == lowerQuery:
+		return 4
+	case strings.HasPrefix(name, query):
+		return 3
+	case strings.HasPrefix(lowerName, lowerQuery):
+		return 2
+	case strings.Contains(lowerName, lowerQuery):
+		return 1
+	}
+	return 0
+}
+
+// lowerQualifier is either a type name (for selectors),
+// or a package name or an import path (suffix).
+func (item *searchItem) matchQualifier(lowerQualifier string) bool {
+	if item.typeName != nil && strings.ToLower(item.typeName.Name()) == lowerQualifier {
+		return true
+	}
+	pkgPath := strings.ToLower(item.pkg.Path)
+	if pkgPath == lowerQualifier || strings.HasSuffix(pkgPath, "/"+lowerQualifier) {
+		return true
+	}
+	return strings.ToLower(item.pkg.PPkg.Name) == lowerQualifier
+}
+
+// ToDo: adjust the coefficients
+func buildSearchIndex(analyzer *code.CodeAnalyzer) []*searchItem {
+	var items []*searchItem
+	register := func(kind, name string, pkg *code.Package, tn *code.TypeName, sel *code.Selector, exported bool, popularity int) {
+		if !exported && !collectUnexporteds {
+			return
+		}
+		items = append(items, &searchItem{
+			kind:       kind,
+			name:       name,
+			lowerName:  strings.ToLower(name),
+			pkg:        pkg,
+			typeName:   tn,
+			selector:   sel,
+			exported:   exported,
+			popularity: popularity,
+		})
+	}
+
+	for i, n := 0, analyzer.NumPackages(); i < n; i++ {
+		pkg := analyzer.PackageAt(i)
+		pkgPopularity := len(pkg.DepedBys) * 10
+
+		for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
+			denoting := tn.Denoting
+			typePopularity := 0
+			if denoting != nil {
+				typePopularity = calculateTypePopularity(len(denoting.AsTypesOf), len(denoting.AllMethods),
+					len(denoting.Implements), len(denoting.ImplementedBys), len(denoting.AsInputsOf), len(denoting.AsOutputsOf), analyzer.ObjectUseCount(tn.TypeName))
+			}
+			register("type", tn.Name(), pkg, nil, nil, tn.Exported(), pkgPopularity+typePopularity)
+
+			// Selectors of aliases are listed with their denoting types.
+			// Promoted selectors are listed with their declaring types.
+			if denoting == nil || tn.IsAlias() {
+				continue
+			}
+			for _, sel := range denoting.AllFields {
+				if sel.Depth == 0 {
+					register("field", sel.Name(), pkg, tn, sel, tn.Exported() && token.IsExported(sel.Name()), pkgPopularity+typePopularity/4)
+				}
+			}
+			for _, sel := range denoting.AllMethods {
+				if sel.Depth == 0 {
+					register("method", sel.Name(), pkg, tn, sel, tn.Exported() && token.IsExported(sel.Name()), pkgPopularity+typePopularity/4)
+				}
+			}
+		}
+		for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
+			if !f.IsMethod() {
+				register("func", f.Name(), pkg, nil, nil, f.Exported(), pkgPopularity)
+			}
+		}
+		for _, v := range pkg.PackageAnalyzeResult.AllVariables {
+			register("var", v.Name(), pkg, nil, nil, v.Exported(), pkgPopularity)
+		}
+		for _, c := range pkg.PackageAnalyzeResult.AllConstants {
+			register("const", c.Name(), pkg, nil, nil, c.Exported(), pkgPopularity)
+		}
+	}
+
+	return items
+}
+
+// The prebuilt search index is used by the search box on the overview page
+// in docs generation mode, for server-side search is unavailable in that mode.
+//
+// In the index, package paths and hrefs are listed in the "p" array.
+// Each element in the "i" array is [kind, name, package index] for
+// a package-level resource, or [kind, name, package index, type name, href]
+// for a field or method. Elements in the "i" array are sorted by popularity.
+// All hrefs are relative to the overview page.
+type searchIndexData struct {
+	Packages [][2]string     `json:"p"`
+	Items    [][]interface{} `json:"i"`
+}
+
+// jvs:search-index
+func (ds *docServer) searchIndexFile(w http.ResponseWriter, r *http.Request) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeJS,
+		res:     "search-index",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildSearchIndexFile(w)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+func (ds *docServer) buildSearchIndexFile(w http.ResponseWriter) []byte {
+	if ds.searchItems == nil {
+		ds.searchItems = buildSearchIndex(ds.analyzer)
+	}
+
+	overviewPageInfo := createPagePathInfo(ResTypeNone, "")
+	index := searchIndexData{
+		Packages: make([][2]string, ds.analyzer.NumPackages()),
+	}
+	for i := range index.Packages {
+		pkg := ds.analyzer.PackageAt(i)
+		index.Packages[i] = [2]string{
+			pkg.Path,
+			buildPageHref(overviewPageInfo, createPagePathInfo1(ResTypePackage, pkg.Path), nil, ""),
+		}
+	}
+
+	items := make([]*searchItem, 0, len(ds.searchItems))
+	for _, item := range ds.searchItems {
+		if item.exported {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(a, b int) bool {
+		return items[a].popularity > items[b].popularity
+	})
+	index.Items = make([][]interface{}, len(items))
+	for i, item := range items {
+		if item.typeName != nil {
+			index.Items[i] = []interface{}{item.kind, item.name, item.pkg.Index, item.typeName.Name(), ds.searchItemURL(overviewPageInfo, item)}
+		} else {
+			index.Items[i] = []interface{}{item.kind, item.name, item.pkg.Index}
+		}
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		panic("marshal search index error: " + err.Error())
+	}
+
+	page := NewHtmlPage(goldsVersion, "", nil, ds.currentTranslation, createPagePathInfo(ResTypeJS, "search-index"))
+	page.WriteString("var searchIndex = ")
+	page.Write(data)
+	page.WriteString(";\n")
+	return page.Done(w)
+}
+
+func writeSearchIndexBox(page *htmlPage) {
+	page.WriteString(`<pre id="search-box-container" class="js-on"><code><input type="text" id="search-box" size="36" placeholder="`)
+	page.WriteString(page.Translation().Text_Search())
+	page.WriteString(`"><span id="search-results"></span></code></pre>`)
+	fmt.Fprintf(page, `
+<script src="%s"></script>
+`,
+		buildPageHref(page.PathInfo, createPagePathInfo(ResTypeJS, addVersionToFilename("search-index", goldsVersion)), nil, ""),
+	)
+}
@@ -11,6 +11,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"go101.org/golds/code"
 	"go101.org/golds/internal/util"
@@ -451,6 +452,7 @@ type astVisitor struct {
 	pkg          *code.Package
 	fset         *token.FileSet
 	file         *token.File
+	astFile      *ast.File
 	info         *types.Info
 	content      []byte
 
@@ -494,9 +496,24 @@ type astVisitor struct {
 	topLevelStructTypeNodeDepth int32
 	topLevelStructTypeSpec      *ast.TypeSpec
 
+	// The paths (like "Type.field.nestedField") of the fields
+	// of the unnamed struct types in package-level declarations.
+	// Lazily built.
+	nestedFieldPaths map[*types.Var]string
+
 	pkgPath2RatioID map[string]int32
 }
 
+// nestedFieldPath returns the path of a field of an unnamed struct type
+// declared in a package-level type or variable declaration of the current file.
+func (v *astVisitor) nestedFieldPath(field *types.Var) string {
+	if v.nestedFieldPaths == nil {
+		v.nestedFieldPaths = make(map[*types.Var]string)
+		collectNestedFieldPaths([]*ast.File{v.astFile}, v.info, v.nestedFieldPaths)
+	}
+	return v.nestedFieldPaths[field]
+}
+
 type astFunctionInfo struct {
 	Node         ast.Node
 	Name         *ast.Ident
@@ -1551,7 +1568,7 @@ GoOn:
 						//}
 					}
 				}
-				// ToDo: the above code works for the "bar" and "baz" fields, but not for the "X" field.
+				// The above code works for the "bar" and "baz" fields, but not for the "X" field.
 				//
 				// type Foo struct {
 				// 	bar Type
@@ -1560,9 +1577,12 @@ GoOn:
 				//	}
 				//}
 				//
-				// There are two ways to solve this problem:
-				// 1. create a fake type name "unamed-12345" and use "unamed-12345.X" to denote the X field.
-				// 2. modify reference/use page implementation to support "Foo.baz.X" (not recommended, may have loop problem).
+				// Such fields are denoted by field paths, such as "Foo.baz.X".
+				if fieldPath := v.nestedFieldPath(o); fieldPath != "" && buildIdUsesPages {
+					dot := strings.IndexByte(fieldPath, '.')
+					v.buildLink(start, end, buildPageHref(v.currentPathInfo, createPagePathInfo3b(ResTypeReference, objPkgPath, "..", fieldPath[:dot], fieldPath[dot+1:]), nil, ""), "")
+					return
+				}
 			}
 
 			goto End
@@ -1854,6 +1874,7 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 			pkg:          pkg,
 			fset:         pkg.PPkg.Fset,
 			file:         file,
+			astFile:      fileInfo.AstFile,
 			info:         pkg.PPkg.TypesInfo,
 			content:      content,
 
@@ -88,6 +88,7 @@ func (ds *docServer) buildStatisticsPage(w http.ResponseWriter) []byte {
 		"averageDependencyCountPerPackage": float64(stats.AllPackageDeps) / float64(stats.Packages),
 		"averageSourceFileCountPerPackage": float64(stats.FilesWithGenerateds) / float64(stats.Packages),
 		"averageCodeLineCountPerPackage":   math.Round(float64(stats.CodeLinesWithBlankLines) / float64(stats.Packages)),
+		"couplingPageURL":                  buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, "coupling"), nil, ""),
 
 		//"gosourcefilesByImportsChartURL": buildPageHref(page.PathInfo, createPagePathInfo(ResTypeSVG, "gosourcefiles-by-imports"), nil, ""),
 		//"packagesByDependenciesChartURL": buildPageHref(page.PathInfo, createPagePathInfo(ResTypeSVG, "packages-by-dependencies"), nil, ""),
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"fmt"
+	"go/types"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) newerStdAPIUsesPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "newer-std-apis",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildNewerStdAPIUsesPage(w, ds.buildNewerStdAPIUses())
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+// NewerStdAPIUses holds the std packages and identifiers used in a working
+// directory module but introduced in Go versions newer than the go
+// directive in the go.mod file of the module.
+type NewerStdAPIUses struct {
+	Module      *code.Module
+	Packages    []*StdPackageImports
+	Identifiers []*StdObjectUses
+}
+
+// StdPackageImports holds the packages importing a std package.
+type StdPackageImports struct {
+	Package    *code.Package
+	Version    string
+	ImportedBy []*code.Package
+}
+
+// StdObjectUses holds some uses of a std object.
+type StdObjectUses struct {
+	Package    *code.Package // the package declaring the object
+	Identifier string        // might be a selector path, like "Type.Method"
+	Version    string
+	Uses       []code.Identifier
+}
+
+// stdObjectIdentifierPath returns the std package declaring the specified
+// object and the identifier path of the object in the package.
+// The returned identifier path is blank for non-std objects.
+//
+// ds.mutex should be locked before calling this method.
+func (ds *docServer) stdObjectIdentifierPath(obj types.Object) (*code.Package, string) {
+	if obj == nil || obj.Pkg() == nil {
+		return nil, ""
+	}
+	pkg := ds.analyzer.PackageByPath(obj.Pkg().Path())
+	if pkg == nil || !ds.analyzer.IsStandardPackage(pkg) {
+		return nil, ""
+	}
+	return pkg, ds.objectIdentifierPath(pkg, obj)
+}
+
+// ds.mutex should be locked before calling this method.
+func (ds *docServer) buildNewerStdAPIUses() []*NewerStdAPIUses {
+	var goMinorVersionOfModule = make(map[*code.Module]int64)
+	for _, m := range ds.analyzer.WorkingDirectoryModules() {
+		if m.GoVersion == "" {
+			continue
+		}
+		_, minor, err := ParseGoVersion([]byte("go" + m.GoVersion))
+		if err == nil {
+			goMinorVersionOfModule[m] = minor
+		}
+	}
+	if len(goMinorVersionOfModule) == 0 {
+		return nil
+	}
+
+	var isNewer = func(version string, m *code.Module) bool {
+		if version == "" {
+			return false
+		}
+		_, minor, err := ParseGoVersion([]byte(version))
+		return err == nil && minor > goMinorVersionOfModule[m]
+	}
+
+	var results = make(map[*code.Module]*NewerStdAPIUses, len(goMinorVersionOfModule))
+	var resultOf = func(m *code.Module) *NewerStdAPIUses {
+		r := results[m]
+		if r == nil {
+			r = &NewerStdAPIUses{Module: m}
+			results[m] = r
+		}
+		return r
+	}
+
+	for m := range goMinorVersionOfModule {
+		var imports = make(map[*code.Package]*StdPackageImports)
+		for _, pkg := range m.Pkgs {
+			for _, dep := range pkg.Deps {
+				if !ds.analyzer.IsStandardPackage(dep) {
+					continue
+				}
+				version := ds.analyzer.StdAPIVersion(dep.Path, "")
+				if !isNewer(version, m) {
+					continue
+				}
+				spi := imports[dep]
+				if spi == nil {
+					spi = &StdPackageImports{Package: dep, Version: version}
+					imports[dep] = spi
+					r := resultOf(m)
+					r.Packages = append(r.Packages, spi)
+				}
+				spi.ImportedBy = append(spi.ImportedBy, pkg)
+			}
+		}
+	}
+
+	ds.analyzer.IterateObjectReferences(func(obj types.Object, ids []code.Identifier) {
+		var usedInModules = false
+		for _, id := range ids {
+			if _, ok := goMinorVersionOfModule[id.FileInfo.Pkg.Module()]; ok {
+				usedInModules = true
+				break
+			}
+		}
+		if !usedInModules {
+			return
+		}
+		pkg, identifier := ds.stdObjectIdentifierPath(obj)
+		if identifier == "" {
+			return
+		}
+		version := ds.analyzer.StdAPIVersion(pkg.Path, identifier)
+		if version == "" {
+			return
+		}
+		var uses map[*code.Module]*StdObjectUses
+		for _, id := range ids {
+			m := id.FileInfo.Pkg.Module()
+			if _, ok := goMinorVersionOfModule[m]; !ok || !isNewer(version, m) {
+				continue
+			}
+			if uses == nil {
+				uses = make(map[*code.Module]*StdObjectUses)
+			}
+			sou := uses[m]
+			if sou == nil {
+				sou = &StdObjectUses{Package: pkg, Identifier: identifier, Version: version}
+				uses[m] = sou
+				r := resultOf(m)
+				r.Identifiers = append(r.Identifiers, sou)
+			}
+			sou.Uses = append(sou.Uses, id)
+		}
+	})
+
+	var list = make([]*NewerStdAPIUses, 0, len(results))
+	for _, r := range results {
+		sort.Slice(r.Packages, func(a, b int) bool {
+			return r.Packages[a].Package.Path < r.Packages[b].Package.Path
+		})
+		for _, spi := range r.Packages {
+			sort.Slice(spi.ImportedBy, func(a, b int) bool {
+				return spi.ImportedBy[a].Path < spi.ImportedBy[b].Path
+			})
+		}
+		sort.Slice(r.Identifiers, func(a, b int) bool {
+			idA, idB := r.Identifiers[a], r.Identifiers[b]
+			if idA.Package.Path != idB.Package.Path {
+				return idA.Package.Path < idB.Package.Path
+			}
+			return idA.Identifier < idB.Identifier
+		})
+		for _, sou := range r.Identifiers {
+			sortIdentifiersByPosition(sou.Uses)
+		}
+		list = append(list, r)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].Module.Path < list[b].Module.Path
+	})
+	return list
+}
+
+func (ds *docServer) buildNewerStdAPIUsesPage(w http.ResponseWriter, results []*NewerStdAPIUses) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_NewerStdAPIUses(), ds.currentTheme, ds.currentTranslation, createPagePathInfo(ResTypeNone, "newer-std-apis"))
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span>
+`,
+		page.Translation().Text_NewerStdAPIUses(),
+	)
+
+	page.WriteString(page.Translation().Text_NewerStdAPIUsesLegend())
+
+	for _, r := range results {
+		page.WriteString("\n")
+		page.WriteString(`<span class="title">module `)
+		buildPageHref(page.PathInfo, createPagePathInfo1(ResTypeModule, modulePagePath(r.Module)), page, r.Module.Path)
+		page.WriteString(`</span><span class="title-stat"><i>`)
+		page.WriteString(page.Translation().Text_Parenthesis(false))
+		page.WriteString("go ")
+		page.WriteString(r.Module.GoVersion)
+		page.WriteString(page.Translation().Text_Parenthesis(true))
+		page.WriteString("</i></span>\n")
+
+		for _, spi := range r.Packages {
+			page.WriteString("\n\t")
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, spi.Package.Path), page, spi.Package.Path)
+			writeStdAPIVersion(page, spi.Version)
+			page.WriteString("\n")
+			for _, by := range spi.ImportedBy {
+				page.WriteString("\t\t")
+				buildPageHref(page.PathInfo, createPagePathInfo1(ResTypeDependency, by.Path), page, by.Path)
+				page.WriteString("\n")
+			}
+		}
+
+		for _, sou := range r.Identifiers {
+			page.WriteString("\n\t")
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, sou.Package.Path), page, sou.Package.Path)
+			page.WriteByte('.')
+			writeIdentifierPathReferenceLink(page, sou.Package, sou.Identifier)
+			writeStdAPIVersion(page, sou.Version)
+			page.WriteString(`<i>`)
+			page.WriteString(page.Translation().Text_Parenthesis(false))
+			page.WriteString(page.Translation().Text_ObjectUses(len(sou.Uses)))
+			page.WriteString(page.Translation().Text_Parenthesis(true))
+			page.WriteString(`</i>`)
+			page.WriteString("\n")
+			writeIdentifierUseSites(page, sou.Uses)
+		}
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+// numNewerStdAPIUses returns the number of the std packages and identifiers
+// used in the working directory modules but introduced in newer Go versions.
+//
+// ds.mutex should be locked before calling this method.
+func (ds *docServer) numNewerStdAPIUses() int {
+	n := 0
+	for _, r := range ds.buildNewerStdAPIUses() {
+		n += len(r.Packages) + len(r.Identifiers)
+	}
+	return n
+}
+
+// writeStdAPIVersion writes the Go version introducing a std package or identifier.
+func writeStdAPIVersion(page *htmlPage, version string) {
+	page.WriteString(`<i class="api-version">`)
+	page.WriteString(page.Translation().Text_Parenthesis(false))
+	page.WriteString(page.Translation().Text_AvailableSince(version))
+	page.WriteString(page.Translation().Text_Parenthesis(true))
+	page.WriteString(`</i>`)
+}
+
+// writeStdAPIVersionForObject writes the Go version introducing the specified
+// std object, if the version is different from the one introducing the owner
+// of the object (the declaring package or type).
+//
+// ds.mutex should be locked before calling this method.
+func (ds *docServer) writeStdAPIVersionForObject(page *htmlPage, obj types.Object) {
+	pkg, id := ds.stdObjectIdentifierPath(obj)
+	if id == "" {
+		return
+	}
+	version := ds.analyzer.StdAPIVersion(pkg.Path, id)
+	if version == "" {
+		return
+	}
+	var owner string
+	if i := strings.LastIndexByte(id, '.'); i >= 0 {
+		owner = id[:i]
+	}
+	if version != ds.analyzer.StdAPIVersion(pkg.Path, owner) {
+		writeStdAPIVersion(page, version)
+	}
+}
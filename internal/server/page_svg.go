@@ -21,6 +21,12 @@ func (ds *docServer) svgFile(w http.ResponseWriter, r *http.Request, svgFile str
 		fmt.Fprint(w, "svg file ", svgFile, " is not ready")
 		return
 	}
+
+	if kind, format, target, ok := parseDependencyGraphResPath(svgFile); ok {
+		ds.dependencyGraphFile(w, r, kind, format, target)
+		return
+	}
+
 	w.Header().Set("Content-Type", "image/svg+xml")
 
 	if genDocsMode {
@@ -32,6 +32,7 @@ type Translation interface {
 
 	// server
 	Text_Server_Started() string
+	Text_NewerDocsAvailable() string // for the watch mode
 
 	// analyzing
 	Text_Analyzing() string
@@ -70,6 +71,10 @@ type Translation interface {
 	Text_SortBy(whatToSort string) string // also used in other pages
 	Text_SortByItem(by string) string     // also used in other pages
 
+	// module page
+	Text_Module(modulePath string) string
+	Text_ModuleInfoTitle(item string) string
+
 	// package details page
 	Text_Package(pkgPath string) string
 	Text_BelongingPackage() string // also used in source code page
@@ -78,6 +83,10 @@ type Translation interface {
 	Text_ImportStat(numImports, numImportedBys int, depPageURL string) string
 	Text_InvolvedFiles(num int) string
 	Text_Examples(num int) string
+	Text_Notes() string
+	Text_NumNotes(num int) string
+	Text_RunExample() string
+	Text_ExampleRunResult(result string) string
 	Text_PackageLevelTypeNames() string
 	Text_TypeParameters() string
 	//Text_AllPackageLevelValues(num int) string
@@ -87,20 +96,32 @@ type Translation interface {
 	Text_PackageLevelResourceSimpleStat(statsAreExact bool, num, numExporteds int, mentionExporteds bool) string
 	Text_UnexportedResourcesHeader(show bool, numUnexporteds int, exact bool) string
 	Text_ListUnexportes() string
+	Text_Deprecated() string                                           // also used in other pages
+	Text_DeprecatedItemsHeader(show bool, num int, kind string) string // also used in overview page
+	Text_AvailableSince(version string) string
 
 	Text_BasicType() string
 	Text_Fields() string // ToDo: merge these into one?
 	Text_Methods() string
 	Text_ImplementedBy() string
+	Text_SatisfiedBy() string
 	Text_Implements() string
 	Text_AsOutputsOf() string
 	Text_AsInputsOf() string
 	Text_AsTypesOf() string
+	Text_AsInstantiation() string
+	Text_Instantiations() string
+	Text_InstantiationsStat(numTypeArgLists, numSites int) string
+	Text_NumInstantiationSites(num int) string
+	Text_SatisfiesConstraint() string
 
 	// package dependencies page
 	Text_DependencyRelations(pkgPath string) string // also used in package details page with a blank argument.
 	Text_Imports() string
 	Text_ImportedBy() string
+	Text_ImportChains() string
+	Text_DependencyGraph() string // also used in module page
+	Text_DependencyGraphOption(option string) string
 
 	// method implementation page
 	Text_MethodImplementations() string
@@ -113,11 +134,25 @@ type Translation interface {
 	Text_ObjectKind(kind string) string
 	Text_ObjectUses(num int) string // also used in other pages
 
+	// call hierarchy page
+	Text_CallHierarchy() string
+	Text_ViewCallHierarchy() string
+	Text_Callers(num int) string
+	Text_Callees(num int) string
+	Text_NumCalls(num int) string
+	Text_DynamicCallVia() string
+	Text_PackageLevelDeclarations() string
+
 	// source code page
 	Text_SourceCode(pkgPath, bareFilename string) string
 	Text_SourceFilePath() string
 	Text_GeneratedFrom() string
 
+	// search page
+	Text_Search() string // also used in overview page
+	Text_SearchResults(query string, num int) string
+	Text_SearchResultsOmitted(num int) string
+
 	// statistics
 	Text_Statistics() string
 	Text_ChartTitle(chartName string) string
@@ -126,6 +161,26 @@ type Translation interface {
 	Text_TypeStatistics(values map[string]interface{}) []string
 	Text_ValueStatistics(values map[string]interface{}) []string
 	Text_Othertatistics(values map[string]interface{}) []string
+	Text_PackageCoupling() string
+	Text_CouplingMetricsLegend() string
+	Text_DependencyStructureMatrix() string // also used in module page
+	Text_DependencyStructureMatrixLegend() string
+
+	// deprecated uses page
+	Text_DeprecatedUses() string // also used in overview page
+	Text_DeprecatedUsesLegend() string
+	Text_DeprecatedUsesSection(kind string, num int) string
+
+	// newer std API uses page
+	Text_NewerStdAPIUses() string // also used in overview page
+	Text_NewerStdAPIUsesLegend() string
+
+	// api diff page
+	Text_APIDiff(oldVersion, newVersion string) string
+	Text_APIDiffSummary(numBreakings, numCompatibles int) string
+	Text_BreakingChanges() string
+	Text_CompatibleChanges() string
+	Text_APIChange(change string) string
 
 	// Footer
 	Text_GeneratedPageFooter(goldsVersion, qrCodeLink, goOS, goArch string) string
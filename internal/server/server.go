@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"go/types"
 	"io"
 	"log"
 	"math/rand"
@@ -62,7 +63,10 @@ type docServer struct {
 	//identifierReferencesPages map[usePageKey][]byte
 	//sourcePages               map[sourcePageKey][]byte
 	//dependencyPages           map[string][]byte
-	cachedPages map[pageCacheKey][]byte
+	cachedPages          map[pageCacheKey][]byte
+	searchItems          []*searchItem                           // built lazily
+	nestedFieldPaths     map[*code.Package]map[*types.Var]string // built lazily
+	callHierarchyBuilder *callHierarchyBuilder                   // built lazily
 	//cachedPagesOptions map[pageCacheKey]interface{} // key.options must be nil in this map
 
 	docRenderer util.MarkdownRenderer
@@ -77,6 +81,7 @@ type docServer struct {
 
 	//
 	updateLogger          *log.Logger
+	watchLogger           *log.Logger // for the watch mode
 	roughBuildTime        func() time.Time
 	updateTip             int
 	cachedUpdateTip       int
@@ -98,6 +103,7 @@ func Run(options PageOutputOptions, args []string, recommendedPort string, silen
 		analyzingLogs:   make([]LoadingLogMessage, 0, 64),
 
 		updateLogger:   log.New(os.Stdout, "[Update] ", 0),
+		watchLogger:    log.New(os.Stdout, "[Watch] ", 0),
 		roughBuildTime: roughBuildTime,
 	}
 
@@ -136,6 +142,10 @@ NextTry:
 		ds.analyzingLogger.SetPrefix("")
 		serverStarted := ds.currentTranslationSafely().Text_Server_Started()
 		ds.analyzingLogger.Printf("%s http://localhost:%v\n", serverStarted, addr.Port)
+
+		if watchSourceChanges {
+			go ds.watchSourceChanges(args, toolchain)
+		}
 	}()
 
 	if !silentMode {
@@ -185,6 +195,14 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
 		case "statistics":
 			ds.statisticsPage(w, r)
+		case "coupling":
+			ds.couplingPage(w, r)
+		case "deprecated":
+			ds.deprecatedUsesPage(w, r)
+		case "newer-std-apis":
+			ds.newerStdAPIUsesPage(w, r)
+		case "search":
+			ds.searchPage(w, r)
 		}
 		return
 	}
@@ -202,6 +220,22 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			ds.updateAPI(w, r)
 		case "load":
 			ds.loadAPI(w, r)
+		case "search":
+			ds.searchAPI(w, r)
+		case "docs-version":
+			ds.docsVersionAPI(w, r)
+		case "run-example":
+			ds.runExampleAPI(w, r)
+		case "package":
+			ds.packageAPI(w, r)
+		case "dependencies":
+			ds.dependenciesAPI(w, r)
+		case "references":
+			ds.referencesAPI(w, r)
+		case "implementations":
+			ds.implementationsAPI(w, r)
+		case "coupling":
+			ds.couplingAPI(w, r)
 		}
 	case ResTypeCSS: // "css"
 		ds.cssFile(w, r, removeVersionFromFilename(resPath, goldsVersion))
@@ -211,8 +245,8 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ds.svgFile(w, r, resPath)
 	case ResTypePNG: // "png"
 		ds.pngFile(w, r, resPath)
-	//case "mod:": // module
-	//	ds.modulePage(w, r, path)
+	case ResTypeModule: // "mod"
+		ds.modulePage(w, r, resPath)
 	case ResTypePackage: // "pkg"
 		ds.packageDetailsPage(w, r, resPath)
 	case ResTypeDependency: // "dep"
@@ -248,6 +282,15 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		} else {
 			ds.identifierReferencePage(w, r, resPath[:index], resPath[index+len(sep):])
 		}
+	case ResTypeCallHierarchy: // "cal"
+		// Two forms: pkg..func or pkg..type.method.
+		const sep = ".."
+		index := strings.LastIndex(resPath, sep)
+		if index < 0 {
+			fmt.Fprint(w, "Function containing package is not specified")
+		} else {
+			ds.callHierarchyPage(w, r, resPath[:index], resPath[index+len(sep):])
+		}
 	}
 }
 
@@ -266,6 +309,7 @@ func (ds *docServer) analyze(args []string, options PageOutputOptions, toolchain
 	//}
 	ds.initialWorkingDirectory = util.WorkingDirectory()
 	ds.analyzer = &code.CodeAnalyzer{}
+	ds.analyzer.EnableCache(cacheDirectory)
 
 	// ...
 	var succeeded = false
@@ -300,7 +344,7 @@ func (ds *docServer) analyze(args []string, options PageOutputOptions, toolchain
 	func() {
 		var repoInfoCache = make(map[string]localRepoInfo, 4)
 		completeModuleInfo := func(m *code.Module) {
-			ds.tryToCompleteModuleInfo(m, repoInfoCache)
+			ds.tryToCompleteModuleInfo(ds.analyzer, m, repoInfoCache)
 		}
 
 		if err := ds.analyzer.ParsePackages(ds.onAnalyzingSubTaskDone, completeModuleInfo, toolchain, args...); err != nil {
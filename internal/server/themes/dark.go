@@ -15,6 +15,7 @@ a {color: #8899ff;}
 .md-text a:hover {color: white;}
 .md-text a:visited {color: #abb;}
 .md-text a:visited:hover {color: white;}
+.md-text .md-heading {font-weight: bold;}
 
 .b {font-weight: bold;}
 
@@ -15,6 +15,7 @@ a {color: #079;}
 .md-text a:hover {color: black;}
 .md-text a:visited {color: #666;}
 .md-text a:visited:hover {color: black;}
+.md-text .md-heading {font-weight: bold;}
 
 .b {font-weight: bold;}
 
@@ -0,0 +1,555 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"html"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/util"
+)
+
+// The API diff mode compares the exported API of the working directory
+// module(s) at two git revisions, or of a module at two versions, and
+// writes the found changes into an api-diff.json file and an api-diff.html
+// page. Each side of the -api-diff=OLD..NEW range is either a git revision
+// or a ModulePath@Version. If NEW is omitted, the working tree is used.
+const (
+	apiDiffJSONFilename = "api-diff.json"
+	apiDiffHTMLFilename = "api-diff.html"
+)
+
+// APIDiffReport is the content of the api-diff.json file.
+type APIDiffReport struct {
+	Old         string      `json:"old"`
+	New         string      `json:"new"`
+	Breakings   int         `json:"breakings"`
+	Compatibles int         `json:"compatibles"`
+	Changes     []APIChange `json:"changes"`
+}
+
+// APIChange is a change of an exported package, type, field, method,
+// function, variable or constant, or of an interface implementation.
+type APIChange struct {
+	Package  string `json:"package"`
+	Name     string `json:"name,omitempty"` // Name or TypeName.Selector
+	Kind     string `json:"kind"`           // package, type, field, method, func, var, const or implements
+	Change   string `json:"change"`         // added, removed or changed
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+	Breaking bool   `json:"breaking"`
+}
+
+// APIDiff compares the exported API between the two sides of diffRange.
+func APIDiff(options PageOutputOptions, args []string, diffRange, outputDir string, silentMode bool, printUsage func(io.Writer)) {
+	toolchain, err := findToolchainInfo()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	oldVersion, newVersion := diffRange, ""
+	if i := strings.Index(diffRange, ".."); i >= 0 {
+		oldVersion, newVersion = diffRange[:i], diffRange[i+len(".."):]
+	}
+	if oldVersion == "" {
+		log.Fatalln("The old version of the API diff range is not specified:", diffRange)
+	}
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+
+	forTesting := outputDir == ""
+	oldAPI, _ := loadAPISnapshot(options, args, oldVersion, toolchain, forTesting, printUsage)
+	newAPI, ds := loadAPISnapshot(options, args, newVersion, toolchain, forTesting, printUsage)
+	if newVersion == "" {
+		newVersion = "worktree"
+	}
+
+	report := &APIDiffReport{
+		Old:     oldVersion,
+		New:     newVersion,
+		Changes: diffAPISnapshots(oldAPI, newAPI),
+	}
+	for _, c := range report.Changes {
+		if c.Breaking {
+			report.Breakings++
+		} else {
+			report.Compatibles++
+		}
+	}
+	if forTesting {
+		return
+	}
+
+	if outputDir == "." {
+		outputDir = ds.initialWorkingDirectory
+	}
+	if err := os.MkdirAll(outputDir, 0700); err != nil {
+		log.Fatalln("Mkdir error:", err)
+	}
+
+	data, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		log.Fatalln("Encode JSON error:", err)
+	}
+	jsonFilePath := filepath.Join(outputDir, apiDiffJSONFilename)
+	if err := os.WriteFile(jsonFilePath, append(data, '\n'), 0600); err != nil {
+		log.Fatalln("Write file error:", err)
+	}
+
+	htmlFilePath := filepath.Join(outputDir, apiDiffHTMLFilename)
+	if err := os.WriteFile(htmlFilePath, ds.buildAPIDiffPage(report), 0600); err != nil {
+		log.Fatalln("Write file error:", err)
+	}
+
+	if !silentMode {
+		log.Println(ds.currentTranslation.Text_APIDiffSummary(report.Breakings, report.Compatibles))
+		log.Printf("API diff results are written to %s and %s", jsonFilePath, htmlFilePath)
+	}
+}
+
+// apiSnapshot records the exported API of some packages.
+// The keys are package import paths.
+type apiSnapshot map[string]*apiPackageSnapshot
+
+type apiPackageSnapshot struct {
+	types  map[string]*apiTypeSnapshot
+	values map[string]apiValueSnapshot
+}
+
+type apiTypeSnapshot struct {
+	decl        string
+	isInterface bool
+	fields      map[string]string // name -> type
+	methods     map[string]string // name -> signature
+	implements  map[string]bool
+}
+
+type apiValueSnapshot struct {
+	kind string // func, var or const
+	decl string
+}
+
+// loadAPISnapshot analyzes the packages at the specified version.
+// A blank version means the current working tree.
+func loadAPISnapshot(options PageOutputOptions, args []string, version string, toolchain code.ToolchainInfo, forTesting bool, printUsage func(io.Writer)) (apiSnapshot, *docServer) {
+	ds := &docServer{}
+
+	var modulePath string
+	switch {
+	case version == "":
+		ds.analyze(args, options, toolchain, forTesting, printUsage)
+	case strings.Contains(version, "@"):
+		// ParsePackages constructs a temp project for such an argument.
+		modulePath = version[:strings.IndexByte(version, '@')]
+		ds.analyze([]string{modulePath + "/...@" + version[len(modulePath)+1:]}, options, toolchain, forTesting, printUsage)
+	default:
+		wd := util.WorkingDirectory()
+		tempDir, err := os.MkdirTemp("", "golds-api-diff-*")
+		if err != nil {
+			log.Fatalln("Create temp dir error:", err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		srcDir, err := extractGitRevision(wd, version, tempDir)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := os.Chdir(srcDir); err != nil {
+			log.Fatalln("Enter temp dir error:", err)
+		}
+		defer os.Chdir(wd)
+		ds.analyze(args, options, toolchain, forTesting, printUsage)
+		ds.initialWorkingDirectory = wd
+	}
+
+	return ds.buildAPISnapshot(modulePath), ds
+}
+
+// buildAPISnapshot records the exported API of the packages in the
+// specified module, or in the working directory modules if modulePath
+// is blank.
+func (ds *docServer) buildAPISnapshot(modulePath string) apiSnapshot {
+	snapshot := make(apiSnapshot)
+	for i, n := 0, ds.analyzer.NumPackages(); i < n; i++ {
+		pkg := ds.analyzer.PackageAt(i)
+		if modulePath != "" {
+			if pkg.ModulePath() != modulePath {
+				continue
+			}
+		} else if !ds.analyzer.IsWorkingDirectoryModule(pkg.Module()) {
+			continue
+		}
+		if pkg.PPkg.Name == "main" || isInformalPackage(pkg.Path) {
+			continue
+		}
+		snapshot[pkg.Path] = buildAPIPackageSnapshot(buildPackageDetailsData(ds.analyzer, pkg.Path, false))
+	}
+	return snapshot
+}
+
+// extractGitRevision extracts the files of the git repository containing
+// the wd directory at the specified revision into the dir directory and
+// returns the directory corresponding to wd in the extracted files.
+func extractGitRevision(wd, revision, dir string) (string, error) {
+	output, err := util.RunShell(time.Minute, wd, nil, "git", "rev-parse", "--show-toplevel", "--show-prefix")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse error: %w", err)
+	}
+	topLevel, prefix := string(bytes.TrimSpace(output)), ""
+	if i := strings.IndexByte(topLevel, '\n'); i >= 0 {
+		topLevel, prefix = topLevel[:i], topLevel[i+1:]
+	}
+
+	output, err = util.RunShell(time.Minute*3, topLevel, nil, "git", "archive", "--format=tar", revision)
+	if err != nil {
+		return "", fmt.Errorf("git archive %s error: %w", revision, err)
+	}
+
+	tr := tar.NewReader(bytes.NewReader(output))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("read git archive error: %w", err)
+		}
+		name := filepath.FromSlash(hdr.Name)
+		if !isLocalPath(name) {
+			return "", errors.New("invalid file path in git archive: " + hdr.Name)
+		}
+		path := filepath.Join(dir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0700); err != nil {
+				return "", err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return "", err
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return "", fmt.Errorf("read git archive error: %w", err)
+			}
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return filepath.Join(dir, filepath.FromSlash(prefix)), nil
+}
+
+// isLocalPath reports whether or not a path is a relative path
+// not escaping the directory it is evaluated in. It is like the
+// filepath.IsLocal function which is only available since Go 1.20.
+func isLocalPath(path string) bool {
+	if path == "" || filepath.IsAbs(path) || filepath.VolumeName(path) != "" {
+		return false
+	}
+	path = filepath.Clean(path)
+	return path != ".." && !strings.HasPrefix(path, ".."+string(filepath.Separator))
+}
+
+func buildAPIPackageSnapshot(details *PackageDetails) *apiPackageSnapshot {
+	ps := &apiPackageSnapshot{
+		types:  make(map[string]*apiTypeSnapshot, len(details.TypeNames)),
+		values: make(map[string]apiValueSnapshot),
+	}
+
+	for _, rwp := range details.TypeNames {
+		td := rwp.Type
+		tn := td.TypeName
+		if !tn.Exported() {
+			continue
+		}
+
+		ts := &apiTypeSnapshot{
+			fields:     make(map[string]string, len(td.Fields)),
+			methods:    make(map[string]string, len(td.Methods)),
+			implements: make(map[string]bool, len(td.Implements)),
+		}
+		ps.types[tn.Name()] = ts
+
+		tt := tn.Denoting.TT
+		switch {
+		case tn.IsAlias():
+			ts.decl = "= " + types.TypeString(tt, nil)
+		default:
+			switch ut := tt.Underlying().(type) {
+			case *types.Struct:
+				ts.decl = "struct"
+			case *types.Interface:
+				ts.decl = "interface"
+				ts.isInterface = true
+			default:
+				ts.decl = types.TypeString(ut, nil)
+			}
+		}
+		if named, ok := tt.(*types.Named); ok && named.TypeParams().Len() > 0 {
+			ts.decl = typeParamsString(named.TypeParams()) + " " + ts.decl
+		}
+
+		for _, fld := range td.Fields {
+			if token.IsExported(fld.Name()) {
+				ts.fields[fld.Name()] = types.TypeString(fld.Type().TT, nil)
+			}
+		}
+		for _, sel := range td.Methods {
+			if !token.IsExported(sel.Name()) {
+				continue
+			}
+			sig := types.TypeString(sel.Type().TT, nil)
+			if sel.PointerReceiverOnly() {
+				sig = "(*) " + sig
+			}
+			ts.methods[sel.Name()] = sig
+		}
+		for _, impl := range td.Implements {
+			itn := impl.BaseType.TypeName
+			if !itn.Exported() || isInformalPackage(itn.Package().Path) {
+				continue
+			}
+			name := itn.Package().Path + "." + impl.NameWithTypeArgs
+			if impl.IsPointer {
+				name = "*" + name
+			}
+			ts.implements[name] = true
+		}
+	}
+
+	var collectValues = func(kind string, values []ResourceWithPosition) {
+		for _, rwp := range values {
+			v := rwp.Value
+			if !v.Exported() {
+				continue
+			}
+			decl := types.TypeString(v.TType(), nil)
+			if c, ok := v.(*code.Constant); ok {
+				decl += " = " + c.Val().ExactString()
+			}
+			ps.values[v.Name()] = apiValueSnapshot{kind: kind, decl: decl}
+		}
+	}
+	collectValues("func", details.Functions)
+	collectValues("var", details.Variables)
+	collectValues("const", details.Constants)
+
+	return ps
+}
+
+func typeParamsString(tparams *types.TypeParamList) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i := 0; i < tparams.Len(); i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		tp := tparams.At(i)
+		b.WriteString(tp.Obj().Name())
+		b.WriteByte(' ')
+		b.WriteString(types.TypeString(tp.Constraint(), nil))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// diffAPISnapshots returns the changes from the old API to the new one.
+// Removals and modifications are breaking. Additions are compatible,
+// except adding methods to interface types, which breaks implementations
+// of the interface types outside of the package.
+func diffAPISnapshots(oldAPI, newAPI apiSnapshot) []APIChange {
+	var changes []APIChange
+	var diffMaps = func(oldMap, newMap map[string]string, onChange func(name, oldDecl, newDecl string)) {
+		for name, oldDecl := range oldMap {
+			if newDecl, ok := newMap[name]; !ok || newDecl != oldDecl {
+				onChange(name, oldDecl, newDecl)
+			}
+		}
+		for name, newDecl := range newMap {
+			if _, ok := oldMap[name]; !ok {
+				onChange(name, "", newDecl)
+			}
+		}
+	}
+	var change = func(pkgPath, name, kind, oldDecl, newDecl string, breakingOnAdded bool) {
+		c := APIChange{Package: pkgPath, Name: name, Kind: kind, Old: oldDecl, New: newDecl}
+		switch {
+		case oldDecl == "":
+			c.Change, c.Breaking = "added", breakingOnAdded
+		case newDecl == "":
+			c.Change, c.Breaking = "removed", true
+		default:
+			c.Change, c.Breaking = "changed", true
+		}
+		changes = append(changes, c)
+	}
+
+	for pkgPath, oldPkg := range oldAPI {
+		newPkg := newAPI[pkgPath]
+		if newPkg == nil {
+			changes = append(changes, APIChange{Package: pkgPath, Kind: "package", Change: "removed", Breaking: true})
+			continue
+		}
+
+		for name, oldType := range oldPkg.types {
+			newType := newPkg.types[name]
+			if newType == nil {
+				change(pkgPath, name, "type", oldType.decl, "", false)
+				continue
+			}
+			if oldType.decl != newType.decl {
+				change(pkgPath, name, "type", oldType.decl, newType.decl, false)
+			}
+			diffMaps(oldType.fields, newType.fields, func(sel, oldDecl, newDecl string) {
+				change(pkgPath, name+"."+sel, "field", oldDecl, newDecl, false)
+			})
+			diffMaps(oldType.methods, newType.methods, func(sel, oldDecl, newDecl string) {
+				change(pkgPath, name+"."+sel, "method", oldDecl, newDecl, newType.isInterface)
+			})
+			for impl := range oldType.implements {
+				if !newType.implements[impl] {
+					change(pkgPath, name, "implements", impl, "", false)
+				}
+			}
+			for impl := range newType.implements {
+				if !oldType.implements[impl] {
+					change(pkgPath, name, "implements", "", impl, false)
+				}
+			}
+		}
+		for name, newType := range newPkg.types {
+			if oldPkg.types[name] == nil {
+				change(pkgPath, name, "type", "", newType.decl, false)
+			}
+		}
+
+		for name, oldValue := range oldPkg.values {
+			newValue, ok := newPkg.values[name]
+			switch {
+			case !ok:
+				change(pkgPath, name, oldValue.kind, oldValue.decl, "", false)
+			case oldValue.kind != newValue.kind:
+				change(pkgPath, name, oldValue.kind, oldValue.decl, newValue.kind+" "+newValue.decl, false)
+			case oldValue.decl != newValue.decl:
+				change(pkgPath, name, oldValue.kind, oldValue.decl, newValue.decl, false)
+			}
+		}
+		for name, newValue := range newPkg.values {
+			if _, ok := oldPkg.values[name]; !ok {
+				change(pkgPath, name, newValue.kind, "", newValue.decl, false)
+			}
+		}
+	}
+	for pkgPath := range newAPI {
+		if oldAPI[pkgPath] == nil {
+			changes = append(changes, APIChange{Package: pkgPath, Kind: "package", Change: "added"})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := &changes[i], &changes[j]
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Old+a.New < b.Old+b.New
+	})
+	return changes
+}
+
+// buildAPIDiffPage builds a self-contained HTML page, so the CSS
+// is embedded in the page instead of being linked.
+func (ds *docServer) buildAPIDiffPage(report *APIDiffReport) []byte {
+	tr := ds.currentTranslation
+	title := tr.Text_APIDiff(report.Old, report.New)
+
+	var css bytes.Buffer
+	t, err := template.New("css").Parse(commonCSS + ds.currentTheme.CSS())
+	if err != nil {
+		panic("parse css template error: " + err.Error())
+	}
+	if err := t.Execute(&css, struct {
+		Colon string
+		Fonts string
+	}{
+		Colon: tr.Text_Colon(true),
+		Fonts: tr.Text_PreferredFontList(),
+	}); err != nil {
+		panic("execute css template error: " + err.Error())
+	}
+
+	var page bytes.Buffer
+	fmt.Fprintf(&page, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<style>%s</style>
+</head>
+<body><div>
+<pre><code><span class="title">%s</span>
+
+%s</code></pre>
+`,
+		html.EscapeString(title), css.Bytes(), html.EscapeString(title),
+		html.EscapeString(tr.Text_APIDiffSummary(report.Breakings, report.Compatibles)),
+	)
+
+	var writeChanges = func(header string, breaking bool) {
+		fmt.Fprintf(&page, `<pre><code><span class="title">%s</span>`, header)
+		lastPkg := ""
+		for _, c := range report.Changes {
+			if c.Breaking != breaking {
+				continue
+			}
+			if c.Package != lastPkg {
+				lastPkg = c.Package
+				fmt.Fprintf(&page, "\n\n\tpackage %s", html.EscapeString(c.Package))
+			}
+			fmt.Fprintf(&page, "\n\t\t[%s] %s", tr.Text_APIChange(c.Change), c.Kind)
+			if c.Name != "" {
+				fmt.Fprintf(&page, " <b>%s</b>", html.EscapeString(c.Name))
+			}
+			switch {
+			case c.Old != "" && c.New != "":
+				fmt.Fprintf(&page, "\n\t\t\t- %s\n\t\t\t+ %s", html.EscapeString(c.Old), html.EscapeString(c.New))
+			case c.Old != "":
+				fmt.Fprintf(&page, ": %s", html.EscapeString(c.Old))
+			case c.New != "":
+				fmt.Fprintf(&page, ": %s", html.EscapeString(c.New))
+			}
+		}
+		page.WriteString("\n</code></pre>\n")
+	}
+	if report.Breakings > 0 {
+		writeChanges(tr.Text_BreakingChanges(), true)
+	}
+	if report.Compatibles > 0 {
+		writeChanges(tr.Text_CompatibleChanges(), false)
+	}
+
+	page.WriteString("</div></body></html>\n")
+	return page.Bytes()
+}
@@ -415,6 +415,9 @@ Generate:
 	if sourceReadingStyle != SourceReadingStyle_rich && linkedPageInfo.resType == ResTypeImplementation {
 		panic("method-implementation page (" + linkedPageInfo.resPath + ") should not be build")
 	}
+	if sourceReadingStyle != SourceReadingStyle_rich && linkedPageInfo.resType == ResTypeCallHierarchy {
+		panic("call-hierarchy page (" + linkedPageInfo.resPath + ") should not be build")
+	}
 
 	var makeHref = func(pathInfo pagePathInfo) (href string) {
 		href = cachedPageHref(pathInfo)
@@ -429,7 +432,11 @@ Generate:
 				href = pathInfo.resPath + resType2ExtTable(pathInfo.resType)
 			}
 		} else {
-			href = string(pathInfo.resType) + "/" + pathInfo.resPath + resType2ExtTable(pathInfo.resType)
+			ext := dependencyGraphFileExt(pathInfo)
+			if ext == "" {
+				ext = resType2ExtTable(pathInfo.resType)
+			}
+			href = string(pathInfo.resType) + "/" + pathInfo.resPath + ext
 		}
 		cachePageHref(pathInfo, href)
 		return
@@ -0,0 +1,363 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"go/token"
+	"go/types"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go101.org/golds/code"
+)
+
+// The file generated by the json gen intent is a JSON object
+// in the following format (Packages and types are written one by one):
+//
+//	{
+//		"goldsVersion": "...",
+//		"time": "...",
+//		"stats": {...},
+//		"modules": [JSONModule, ...],
+//		"packages": [JSONPackage, ...],
+//		"typeInfos": [JSONTypeInfo, ...]
+//	}
+//
+// The following results are not included:
+//   - the references to local objects (the ones declared in function
+//     bodies, function parameters and results, labels and import names),
+//     and to the methods of unnamed interface types.
+//   - the source code, examples and notes of packages.
+const jsonSnapshotFilename = "golds-analysis.json"
+
+// JSONModule is a module in the json gen intent output.
+type JSONModule struct {
+	Path      string   `json:"path"`
+	Version   string   `json:"version,omitempty"`
+	GoVersion string   `json:"goVersion,omitempty"`
+	Dir       string   `json:"dir,omitempty"`
+	Requires  []string `json:"requires,omitempty"`
+}
+
+// JSONPackage is a package in the json gen intent output.
+// Besides the info returned by api:package, the dependencies
+// and the references of the package-level resources are included.
+type JSONPackage struct {
+	*APIPackage
+	Deps       []string        `json:"deps"`
+	References []JSONReference `json:"references,omitempty"`
+}
+
+// JSONReference lists the uses of a package-level resource, a field
+// or method declared for a package-level type, or a field of an unnamed
+// struct type used in a package-level declaration. The declaring
+// identifiers are not counted as uses.
+type JSONReference struct {
+	Identifier string        `json:"identifier"` // Name, TypeName.Selector or Name.field.nestedField
+	Kind       string        `json:"kind"`
+	Uses       []APIPosition `json:"uses"`
+}
+
+// JSONTypeInfo is a type (either named or unnamed) found in analysis.
+// Types reference each other by their indexes in the "typeInfos" list.
+type JSONTypeInfo struct {
+	Index      int    `json:"index"`
+	Type       string `json:"type"`
+	Kind       string `json:"kind"`
+	Package    string `json:"package,omitempty"` // for named types only
+	Name       string `json:"name,omitempty"`    // for named types only
+	Underlying int    `json:"underlying"`        // -1 for unknown
+
+	// All fields and methods, including the promoted ones.
+	Fields  []JSONSelector `json:"fields,omitempty"`
+	Methods []JSONSelector `json:"methods,omitempty"`
+
+	// For non-interface types, the interfaces implemented by them or
+	// by their pointer types. For interface types, the (non-pointer or
+	// pointer) types implementing them.
+	Implements     []JSONImplementation `json:"implements,omitempty"`
+	ImplementedBys []int                `json:"implementedBys,omitempty"`
+}
+
+// JSONSelector is a field or method of a type.
+type JSONSelector struct {
+	Name    string `json:"name"`
+	Package string `json:"package,omitempty"` // for unexported selectors only
+	Type    int    `json:"type"`              // -1 for unknown
+
+	// For promoted selectors, the embedded fields (from the outermost
+	// one) through which the selectors are promoted.
+	EmbeddingChain []string `json:"embeddingChain,omitempty"`
+	Indirect       bool     `json:"indirect,omitempty"` // whether the chain contains pointers
+}
+
+// JSONImplementation records an interface implemented by a type
+// (Impler is either the type itself or the pointer type of it).
+type JSONImplementation struct {
+	Interface int `json:"interface"`
+	Impler    int `json:"impler"`
+}
+
+// GenJSON writes all the analysis results into one JSON file.
+func GenJSON(options PageOutputOptions, args []string, outputDir string, silentMode bool, printUsage func(io.Writer)) {
+	toolchain, err := findToolchainInfo()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	forTesting := outputDir == ""
+	ds := &docServer{}
+	ds.analyze(args, options, toolchain, forTesting, printUsage)
+	if forTesting {
+		return
+	}
+
+	if outputDir == "." {
+		outputDir = ds.initialWorkingDirectory
+	}
+	if err := os.MkdirAll(outputDir, 0700); err != nil {
+		log.Fatalln("Mkdir error:", err)
+	}
+	dataFilePath := filepath.Join(outputDir, jsonSnapshotFilename)
+
+	f, err := os.Create(dataFilePath)
+	if err != nil {
+		log.Fatalln("Create file error:", err)
+	}
+	w := bufio.NewWriter(f)
+	if err := writeJSONSnapshot(w, ds.analyzer); err != nil {
+		log.Fatalln("Write file error:", err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalln("Write file error:", err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalln("Write file error:", err)
+	}
+
+	if !silentMode {
+		log.Printf("Analysis results are written to %s", dataFilePath)
+	}
+}
+
+func writeJSONSnapshot(w io.Writer, analyzer *code.CodeAnalyzer) error {
+	var modules []JSONModule
+	analyzer.IterateModule(func(m *code.Module) {
+		jm := JSONModule{
+			Path:      m.Path,
+			Version:   m.Version,
+			GoVersion: m.GoVersion,
+			Dir:       m.Dir,
+		}
+		for _, r := range m.Requires {
+			jm.Requires = append(jm.Requires, r.Path)
+		}
+		modules = append(modules, jm)
+	})
+
+	header, err := json.Marshal(struct {
+		GoldsVersion string       `json:"goldsVersion"`
+		Time         time.Time    `json:"time"`
+		Stats        code.Stats   `json:"stats"`
+		Modules      []JSONModule `json:"modules"`
+	}{goldsVersion, time.Now(), analyzer.Statistics(), modules})
+	if err != nil {
+		return err
+	}
+
+	// Replace the ending "}" with the packages list.
+	if _, err := w.Write(header[:len(header)-1]); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, `,"packages":[`); err != nil {
+		return err
+	}
+	for i, n := 0, analyzer.NumPackages(); i < n; i++ {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		data, err := json.Marshal(buildJSONPackage(analyzer, analyzer.PackageAt(i)))
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, `],"typeInfos":[`); err != nil {
+		return err
+	}
+	for i, n := 0, analyzer.NumTypeInfos(); i < n; i++ {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		data, err := json.Marshal(buildJSONTypeInfo(analyzer.TypeInfoAt(i)))
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, "]}\n")
+	return err
+}
+
+func buildJSONTypeInfo(t *code.TypeInfo) *JSONTypeInfo {
+	var index = func(t *code.TypeInfo) int {
+		if t == nil {
+			return -1
+		}
+		return t.Index()
+	}
+	var selectors = func(sels []*code.Selector) []JSONSelector {
+		if len(sels) == 0 {
+			return nil
+		}
+		list := make([]JSONSelector, len(sels))
+		for i, sel := range sels {
+			js := &list[i]
+			js.Name = sel.Name()
+			if !token.IsExported(js.Name) {
+				if pkg := sel.Package(); pkg != nil {
+					js.Package = pkg.Path
+				}
+			}
+			js.Type = index(sel.Type())
+			if sel.EmbeddingChain != nil {
+				js.EmbeddingChain = make([]string, sel.Depth)
+				k := len(js.EmbeddingChain)
+				for ef := sel.EmbeddingChain; ef != nil && k > 0; ef = ef.Prev {
+					k--
+					js.EmbeddingChain[k] = ef.Name
+				}
+				js.EmbeddingChain = js.EmbeddingChain[k:]
+				js.Indirect = sel.Indirect
+			}
+		}
+		return list
+	}
+
+	jt := &JSONTypeInfo{
+		Index:      t.Index(),
+		Type:       types.TypeString(t.TT, nil),
+		Kind:       t.Kind().String(),
+		Underlying: index(t.Underlying),
+		Fields:     selectors(t.AllFields),
+		Methods:    selectors(t.AllMethods),
+	}
+	if t.TypeName != nil {
+		jt.Name = t.TypeName.Name()
+		if pkg := t.TypeName.Package(); pkg != nil {
+			jt.Package = pkg.Path
+		}
+	}
+	for _, impl := range t.Implements {
+		jt.Implements = append(jt.Implements, JSONImplementation{
+			Interface: index(impl.Interface),
+			Impler:    index(impl.Impler),
+		})
+	}
+	for _, impBy := range t.ImplementedBys {
+		jt.ImplementedBys = append(jt.ImplementedBys, impBy.Index())
+	}
+	return jt
+}
+
+func buildJSONPackage(analyzer *code.CodeAnalyzer, pkg *code.Package) *JSONPackage {
+	details := buildPackageDetailsData(analyzer, pkg.Path, collectUnexporteds)
+	jp := &JSONPackage{
+		APIPackage: buildAPIPackage(details),
+		Deps:       make([]string, len(pkg.Deps)),
+	}
+	for i, dep := range pkg.Deps {
+		jp.Deps[i] = dep.Path
+	}
+
+	var collectReferences = func(identifier, kind string, obj types.Object) {
+		if obj == nil {
+			return
+		}
+		var uses []APIPosition
+		for _, id := range analyzer.ObjectReferences(obj) {
+			idPkg := id.FileInfo.Pkg
+			if idPkg.PPkg.TypesInfo.Defs[id.AstIdent] == obj {
+				continue // the declaring identifier
+			}
+			uses = append(uses, buildAPIPosition(idPkg, idPkg.PPkg.Fset.PositionFor(id.AstIdent.NamePos, false)))
+		}
+		if len(uses) == 0 {
+			return
+		}
+		jp.References = append(jp.References, JSONReference{
+			Identifier: identifier,
+			Kind:       kind,
+			Uses:       uses,
+		})
+	}
+
+	for _, rwp := range details.TypeNames {
+		tn := rwp.Type.TypeName
+		collectReferences(tn.Name(), "type", tn.TypeName)
+		if tn.IsAlias() {
+			continue
+		}
+		for _, sel := range tn.Denoting.AllFields {
+			if sel.EmbeddingChain == nil && sel.Package() == pkg {
+				collectReferences(tn.Name()+"."+sel.Name(), "field", sel.Object())
+			}
+		}
+		for _, sel := range tn.Denoting.AllMethods {
+			if sel.EmbeddingChain == nil && sel.Package() == pkg {
+				collectReferences(tn.Name()+"."+sel.Name(), "method", sel.Object())
+			}
+		}
+	}
+	for _, rwp := range details.Functions {
+		if f, ok := rwp.Value.(*code.Function); ok && f.Func != nil {
+			collectReferences(f.Name(), "func", f.Func)
+		}
+	}
+	for _, rwp := range details.Variables {
+		if v, ok := rwp.Value.(*code.Variable); ok {
+			collectReferences(v.Name(), "var", v.Var)
+		}
+	}
+	for _, rwp := range details.Constants {
+		if c, ok := rwp.Value.(*code.Constant); ok {
+			collectReferences(c.Name(), "const", c.Const)
+		}
+	}
+
+	nestedFieldPaths := make(map[*types.Var]string)
+	collectNestedFieldPaths(pkg.PPkg.Syntax, pkg.PPkg.TypesInfo, nestedFieldPaths)
+	nestedFields := make([]*types.Var, 0, len(nestedFieldPaths))
+NextField:
+	for field, path := range nestedFieldPaths {
+		if !collectUnexporteds {
+			for _, t := range strings.Split(path, ".") {
+				if !token.IsExported(t) {
+					continue NextField
+				}
+			}
+		}
+		nestedFields = append(nestedFields, field)
+	}
+	sort.Slice(nestedFields, func(i, j int) bool {
+		return nestedFieldPaths[nestedFields[i]] < nestedFieldPaths[nestedFields[j]]
+	})
+	for _, field := range nestedFields {
+		collectReferences(nestedFieldPaths[field], "field", field)
+	}
+
+	return jp
+}
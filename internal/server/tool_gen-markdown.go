@@ -0,0 +1,306 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+// The markdown gen intent writes an index.md file listing all packages
+// and a pkg/PkgPath.md file for each package. The content of the
+// package files matches the package details HTML pages.
+
+const markdownIndexFilename = "index.md"
+
+func markdownFilePath(pkgPath string) string {
+	return "pkg/" + pkgPath + ".md"
+}
+
+// GenMarkdown generates Markdown docs files.
+func GenMarkdown(options PageOutputOptions, args []string, outputDir string, silentMode bool, printUsage func(io.Writer)) {
+	toolchain, err := findToolchainInfo()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	forTesting := outputDir == ""
+	silent := silentMode || forTesting
+	ds := &docServer{}
+	ds.analyze(args, options, toolchain, forTesting, printUsage)
+
+	if outputDir == "." {
+		outputDir = ds.initialWorkingDirectory
+	}
+
+	writeFile := func(filePath string, write func(w *bufio.Writer)) {
+		if forTesting {
+			write(bufio.NewWriter(io.Discard))
+			return
+		}
+
+		filePath = filepath.Join(outputDir, filepath.FromSlash(filePath))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+			log.Fatalln("Mkdir error:", err)
+		}
+		f, err := os.Create(filePath)
+		if err != nil {
+			log.Fatalln("Create file error:", err)
+		}
+		w := bufio.NewWriter(f)
+		write(w)
+		if err := w.Flush(); err != nil {
+			log.Fatalln("Write file error:", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalln("Write file error:", err)
+		}
+		if !silent {
+			log.Println("Generated", filePath)
+		}
+	}
+
+	numPkgs := ds.analyzer.NumPackages()
+	pkgs := make([]*code.Package, numPkgs)
+	for i := range pkgs {
+		pkgs[i] = ds.analyzer.PackageAt(i)
+	}
+	sort.Slice(pkgs, func(a, b int) bool {
+		return pkgs[a].Path < pkgs[b].Path
+	})
+
+	writeFile(markdownIndexFilename, func(w *bufio.Writer) {
+		fmt.Fprintf(w, "# %s\n\n", ds.currentTranslation.Text_PackageList())
+		for _, pkg := range pkgs {
+			fmt.Fprintf(w, "- [%s](%s)", pkg.Path, markdownFilePath(pkg.Path))
+			if pkg.OneLineDoc != "" {
+				fmt.Fprintf(w, " - %s", pkg.OneLineDoc)
+			}
+			w.WriteString("\n")
+		}
+	})
+
+	for _, pkg := range pkgs {
+		details := buildPackageDetailsData(ds.analyzer, pkg.Path, collectUnexporteds)
+		writeFile(markdownFilePath(pkg.Path), func(w *bufio.Writer) {
+			ds.writePackageMarkdown(w, details)
+		})
+	}
+
+	if !silent {
+		log.Printf("Markdown docs are generated in %s", outputDir)
+	}
+}
+
+type markdownWriter struct {
+	*bufio.Writer
+	ds  *docServer
+	pkg *code.Package
+}
+
+func (ds *docServer) writePackageMarkdown(w *bufio.Writer, details *PackageDetails) {
+	mw := &markdownWriter{Writer: w, ds: ds, pkg: details.Package}
+	tr := ds.currentTranslation
+
+	fmt.Fprintf(mw, "# package %s\n\n", details.Name)
+	fmt.Fprintf(mw, "**%s**: `%s`\n", tr.Text_ImportPath(), details.ImportPath)
+	if m := details.Package.Module(); m != nil {
+		fmt.Fprintf(mw, "\n**%s**: `%s`", tr.Text_BelongingModule(), m.Path)
+		if v := m.ActualVersion(); v != "" {
+			fmt.Fprintf(mw, " %s", v)
+		}
+		mw.WriteString("\n")
+	}
+
+	for _, f := range details.Files {
+		if f.DocText != "" {
+			mw.WriteString("\n")
+			mw.writeDoc(f.DocText)
+		}
+	}
+
+	if deps := details.Package.Deps; len(deps) > 0 {
+		fmt.Fprintf(mw, "\n## %s\n\n", tr.Text_Imports())
+		depPaths := make([]string, len(deps))
+		for i, dep := range deps {
+			depPaths[i] = dep.Path
+		}
+		sort.Strings(depPaths)
+		for _, depPath := range depPaths {
+			fmt.Fprintf(mw, "- [%s](%s)\n", depPath, mw.packageLink(depPath, ""))
+		}
+	}
+
+	if len(details.TypeNames) > 0 {
+		fmt.Fprintf(mw, "\n## %s\n", tr.Text_PackageLevelTypeNames())
+		for _, rwp := range details.TypeNames {
+			mw.writeType(rwp.Type)
+		}
+	}
+
+	var writeValues = func(title string, values []ResourceWithPosition) {
+		if len(values) == 0 {
+			return
+		}
+		fmt.Fprintf(mw, "\n## %s\n", title)
+		for _, rwp := range values {
+			v := rwp.Value
+			fmt.Fprintf(mw, "\n### <a id=\"name-%s\"></a>%s\n\n", v.Name(), v.Name())
+			mw.writeCode(mw.valueDeclaration(v))
+			if doc := v.Documentation(); doc != "" {
+				mw.WriteString("\n")
+				mw.writeDoc(doc)
+			}
+		}
+	}
+	writeValues(tr.Text_PackageLevelFunctions(), details.Functions)
+	writeValues(tr.Text_PackageLevelVariables(), details.Variables)
+	writeValues(tr.Text_PackageLevelConstants(), details.Constants)
+}
+
+func (mw *markdownWriter) writeType(td *TypeDetails) {
+	tr := mw.ds.currentTranslation
+	tn := td.TypeName
+
+	fmt.Fprintf(mw, "\n### <a id=\"name-%s\"></a>%s\n\n", tn.Name(), tn.Name())
+
+	var decl = "type " + tn.Name()
+	if tn.AstSpec != nil {
+		var buf bytes.Buffer
+		if err := format.Node(&buf, tn.Pkg.PPkg.Fset, tn.AstSpec); err == nil {
+			decl = "type " + buf.String()
+		}
+	}
+	mw.writeCode(decl)
+	if doc := tn.Documentation(); doc != "" {
+		mw.WriteString("\n")
+		mw.writeDoc(doc)
+	}
+
+	var writeList = func(title string, n int, writeItem func(i int)) {
+		if n == 0 {
+			return
+		}
+		fmt.Fprintf(mw, "\n**%s**\n\n", title)
+		for i := 0; i < n; i++ {
+			mw.WriteString("- ")
+			writeItem(i)
+			mw.WriteString("\n")
+		}
+	}
+	writeList(tr.Text_Fields(), len(td.Fields), func(i int) {
+		sel := td.Fields[i]
+		fmt.Fprintf(mw, "`%s %s`", selectorPath(sel.Selector), mw.typeString(sel.Type()))
+	})
+	writeList(tr.Text_Methods(), len(td.Methods), func(i int) {
+		sel := td.Methods[i]
+		sig := mw.typeString(sel.Type())
+		fmt.Fprintf(mw, "`%s%s`", selectorPath(sel), strings.TrimPrefix(sig, "func"))
+	})
+	writeList(tr.Text_Implements(), len(td.Implements), func(i int) {
+		mw.writeTypeLink(td.Implements[i])
+	})
+	writeList(tr.Text_ImplementedBy(), len(td.ImplementedBys), func(i int) {
+		mw.writeTypeLink(td.ImplementedBys[i])
+	})
+	writeList(tr.Text_SatisfiedBy(), len(td.SatisfiedBys), func(i int) {
+		mw.writeTypeLink(td.SatisfiedBys[i])
+	})
+	writeList(tr.Text_AsOutputsOf(), len(td.AsOutputsOf), func(i int) {
+		mw.writeValueLink(td.AsOutputsOf[i].ValueResource)
+	})
+	writeList(tr.Text_AsInputsOf(), len(td.AsInputsOf), func(i int) {
+		mw.writeValueLink(td.AsInputsOf[i].ValueResource)
+	})
+	writeList(tr.Text_AsTypesOf(), len(td.Values), func(i int) {
+		mw.writeValueLink(td.Values[i].ValueResource)
+	})
+}
+
+// packageLink returns the link from the current package file
+// to the file of the specified package.
+func (mw *markdownWriter) packageLink(pkgPath, anchor string) string {
+	if pkgPath == mw.pkg.Path && anchor != "" {
+		return "#name-" + anchor
+	}
+
+	from := path.Dir(markdownFilePath(mw.pkg.Path))
+	rel, err := filepath.Rel(filepath.FromSlash(from), filepath.FromSlash(markdownFilePath(pkgPath)))
+	if err != nil {
+		return ""
+	}
+	link := filepath.ToSlash(rel)
+	if anchor != "" {
+		link += "#name-" + anchor
+	}
+	return link
+}
+
+func (mw *markdownWriter) writeTypeLink(t *TypeForListing) {
+	tn := t.BaseType.TypeName
+	var text = t.NameWithTypeArgs
+	if !t.InCurrentPkg && tn.Package().Path != "builtin" {
+		text = tn.Package().PPkg.Name + "." + text
+	}
+	if t.IsPointer {
+		text = "*" + text
+	}
+	fmt.Fprintf(mw, "[%s](%s)", text, mw.packageLink(tn.Package().Path, tn.Name()))
+}
+
+func (mw *markdownWriter) writeValueLink(v code.ValueResource) {
+	var text, anchor = v.Name(), v.Name()
+	if f, ok := v.(code.FunctionResource); ok && f.IsMethod() {
+		if _, tn, _ := f.ReceiverTypeName(); tn != nil {
+			text = tn.Name() + "." + text
+			anchor = tn.Name()
+		}
+	}
+	if v.Package() != mw.pkg {
+		text = v.Package().PPkg.Name + "." + text
+	}
+	fmt.Fprintf(mw, "[%s](%s)", text, mw.packageLink(v.Package().Path, anchor))
+}
+
+func (mw *markdownWriter) typeString(t *code.TypeInfo) string {
+	if t == nil {
+		return ""
+	}
+	return types.TypeString(t.TT, func(p *types.Package) string {
+		if p.Path() == mw.pkg.Path {
+			return ""
+		}
+		return p.Name()
+	})
+}
+
+func (mw *markdownWriter) valueDeclaration(v code.ValueResource) string {
+	tt := types.TypeString(v.TType(), func(p *types.Package) string {
+		if p.Path() == mw.pkg.Path {
+			return ""
+		}
+		return p.Name()
+	})
+	switch v.(type) {
+	case *code.Function:
+		return "func " + v.Name() + strings.TrimPrefix(tt, "func")
+	case *code.Constant:
+		return "const " + v.Name() + " " + tt
+	default:
+		return "var " + v.Name() + " " + tt
+	}
+}
+
+func (mw *markdownWriter) writeCode(decl string) {
+	fmt.Fprintf(mw, "```go\n%s\n```\n", decl)
+}
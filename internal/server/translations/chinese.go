@@ -49,6 +49,10 @@ func (*Chinese) Text_Server_Started() string {
 	return "服务已启动："
 }
 
+func (*Chinese) Text_NewerDocsAvailable() string {
+	return `源代码已更改，新的文档已可用。<b><a href="">刷新</a></b>以查看。`
+}
+
 ///////////////////////////////////////////////////////////////////
 // analyzing
 ///////////////////////////////////////////////////////////////////
@@ -216,11 +220,44 @@ func (*Chinese) Text_SortByItem(by string) string {
 		return "按依赖距离排序"
 	case "codelines":
 		return "按代码行数排序"
+	case "uses":
+		return "按使用次数排序"
 	default:
 		panic("unknown sort-by: " + by)
 	}
 }
 
+///////////////////////////////////////////////////////////////////
+// module page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_Module(modulePath string) string {
+	return fmt.Sprintf("模块：%s", modulePath)
+}
+
+func (*Chinese) Text_ModuleInfoTitle(item string) string {
+	switch item {
+	case "version":
+		return "版本"
+	case "replacement":
+		return "替换为"
+	case "goversion":
+		return "Go版本"
+	case "repository":
+		return "代码仓库"
+	case "commit":
+		return "提交"
+	case "packages":
+		return "库包列表"
+	case "requires":
+		return "需要的模块"
+	case "requiredbys":
+		return "被以下模块需要"
+	default:
+		panic("unknown module info title: " + item)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // package details page: type details
 ///////////////////////////////////////////////////////////////////
@@ -259,6 +296,31 @@ func (*Chinese) Text_InvolvedFiles(num int) string { return "相关源文件" }
 
 func (*Chinese) Text_Examples(num int) string { return "代码示例" }
 
+func (*Chinese) Text_Notes() string { return "注释标记" }
+
+func (*Chinese) Text_NumNotes(num int) string {
+	return fmt.Sprintf("%d条", num)
+}
+
+func (*Chinese) Text_RunExample() string { return "运行" }
+
+func (*Chinese) Text_ExampleRunResult(result string) string {
+	switch result {
+	case "pass":
+		return "通过：输出和输出注释一致："
+	case "fail":
+		return "失败：示例运行失败或者输出和输出注释不一致。"
+	case "unverified":
+		return "此示例没有输出注释，所以只被编译而未被运行。"
+	case "timeout":
+		return "此示例因运行时间过长而被终止。"
+	case "busy":
+		return "正在运行的示例过多，请稍后再试。"
+	default:
+		panic("unknown example run result: " + result)
+	}
+}
+
 func (*Chinese) Text_PackageLevelTypeNames() string {
 	return "包级类型名"
 }
@@ -359,6 +421,10 @@ func (*Chinese) Text_ImplementedBy() string {
 	return "被实现列表"
 }
 
+func (*Chinese) Text_SatisfiedBy() string {
+	return "满足者列表"
+}
+
 func (*Chinese) Text_Implements() string {
 	return "接口实现列表"
 }
@@ -375,6 +441,20 @@ func (*Chinese) Text_AsTypesOf() string {
 	return "和此类型相关的包级值"
 }
 
+func (*Chinese) Text_AsInstantiation() string { return "作为" }
+
+func (*Chinese) Text_SatisfiesConstraint() string { return "满足" }
+
+func (*Chinese) Text_Instantiations() string { return "实例化" }
+
+func (*Chinese) Text_InstantiationsStat(numTypeArgLists, numSites int) string {
+	return fmt.Sprintf("%d个类型实参列表，%d处", numTypeArgLists, numSites)
+}
+
+func (*Chinese) Text_NumInstantiationSites(num int) string {
+	return fmt.Sprintf("%d处", num)
+}
+
 ///////////////////////////////////////////////////////////////////
 // package dependencies page
 ///////////////////////////////////////////////////////////////////
@@ -391,6 +471,25 @@ func (*Chinese) Text_Imports() string { return "引入了这些代码包" }
 
 func (*Chinese) Text_ImportedBy() string { return "被这些代码包引入" }
 
+func (*Chinese) Text_ImportChains() string { return "为何被引入" }
+
+func (*Chinese) Text_DependencyGraph() string { return "依赖关系图" }
+
+func (*Chinese) Text_DependencyGraphOption(option string) string {
+	switch option {
+	case "depth":
+		return "深度"
+	case "module":
+		return "模块"
+	case "all":
+		return "全部"
+	case "download":
+		return "下载"
+	default:
+		panic("unknown dependency graph option: " + option)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // method implementation page
 ///////////////////////////////////////////////////////////////////
@@ -433,10 +532,56 @@ func (*Chinese) Text_ObjectKind(kind string) string {
 	}
 }
 
+func (*Chinese) Text_Deprecated() string {
+	return "已弃用"
+}
+
+func (*Chinese) Text_DeprecatedItemsHeader(show bool, num int, kind string) string {
+	action := "隐藏"
+	if show {
+		action = "显示"
+	}
+	switch kind {
+	case "packages":
+		kind = "库包"
+	case "identifiers":
+		kind = "标识符"
+	}
+	return fmt.Sprintf("/* %s%d个已弃用的%s */", action, num, kind)
+}
+
+func (*Chinese) Text_AvailableSince(version string) string {
+	return "始于" + version
+}
+
 func (*Chinese) Text_ObjectUses(num int) string {
 	return fmt.Sprintf("%d处使用", num)
 }
 
+///////////////////////////////////////////////////////////////////
+// call hierarchy page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_CallHierarchy() string { return "调用层级" }
+
+func (*Chinese) Text_ViewCallHierarchy() string { return "查看调用层级" }
+
+func (*Chinese) Text_Callers(num int) string {
+	return fmt.Sprintf("%d个调用者", num)
+}
+
+func (*Chinese) Text_Callees(num int) string {
+	return fmt.Sprintf("%d个被调用者", num)
+}
+
+func (*Chinese) Text_NumCalls(num int) string {
+	return fmt.Sprintf("%d处调用", num)
+}
+
+func (*Chinese) Text_DynamicCallVia() string { return "动态调用，通过" }
+
+func (*Chinese) Text_PackageLevelDeclarations() string { return "包级声明" }
+
 ///////////////////////////////////////////////////////////////////
 // source code page
 ///////////////////////////////////////////////////////////////////
@@ -449,6 +594,23 @@ func (*Chinese) Text_SourceFilePath() string { return "源文件：" }
 
 func (*Chinese) Text_GeneratedFrom() string { return "从此文件生成" }
 
+///////////////////////////////////////////////////////////////////
+// search page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_Search() string { return "搜索" }
+
+func (*Chinese) Text_SearchResults(query string, num int) string {
+	if num == 0 {
+		return fmt.Sprintf("没有匹配%q的标识符", query)
+	}
+	return fmt.Sprintf("%d个匹配%q的标识符", num, query)
+}
+
+func (*Chinese) Text_SearchResultsOmitted(num int) string {
+	return fmt.Sprintf("（其余%d个已省略）", num)
+}
+
 ///////////////////////////////////////////////////////////////////
 // statistics
 ///////////////////////////////////////////////////////////////////
@@ -457,6 +619,63 @@ func (*Chinese) Text_Statistics() string {
 	return "统计信息"
 }
 
+func (*Chinese) Text_PackageCoupling() string {
+	return "库包耦合度量"
+}
+
+func (*Chinese) Text_CouplingMetricsLegend() string {
+	return `
+	Ca：    传入耦合，引入一个库包的库包数目。
+	Ce：    传出耦合，一个库包引入的库包数目。
+	I：     不稳定度，Ce / (Ca + Ce)。
+	Height：一个库包在依赖关系图中的高度。
+	Depth： 一个库包在依赖关系图中的深度（与各主包的接近程度）。
+`
+}
+
+func (*Chinese) Text_DependencyStructureMatrix() string {
+	return "依赖结构矩阵"
+}
+
+func (*Chinese) Text_DependencyStructureMatrixLegend() string {
+	return `
+	每一行引入此行中标记的列。库包按照高度（最长引入链的长度）分层，因此各引入均位于对角线上方。粗线为层间分界线。
+	子目录中的库包位于其父目录库包的上层。橙色标记为分层违例（引入了子目录中的非internal库包），红色标记为循环引入。
+`
+}
+
+func (*Chinese) Text_DeprecatedUses() string {
+	return "仍被使用的已弃用标识符"
+}
+
+func (*Chinese) Text_DeprecatedUsesLegend() string {
+	return `
+	工作目录中的库包所引入的已弃用库包和所使用的已弃用标识符。
+	已弃用标识符在声明它们的库包中的使用未被列出。
+`
+}
+
+func (*Chinese) Text_DeprecatedUsesSection(kind string, num int) string {
+	switch kind {
+	case "packages":
+		return fmt.Sprintf("已弃用的库包（%d）", num)
+	case "identifiers":
+		return fmt.Sprintf("已弃用的标识符（%d）", num)
+	}
+	panic("unknown kind: " + kind)
+}
+
+func (*Chinese) Text_NewerStdAPIUses() string {
+	return "被使用的较新的标准库API"
+}
+
+func (*Chinese) Text_NewerStdAPIUsesLegend() string {
+	return `
+	工作目录中的模块所使用的、但引入版本比这些模块的go.mod文件中的go指令
+	所指定的版本更新的标准库包和标识符。
+`
+}
+
 func (*Chinese) Text_ChartTitle(chartName string) string {
 	switch chartName {
 	case "gosourcefiles-by-imports":
@@ -520,6 +739,7 @@ func (*Chinese) Text_PackageStatistics(values map[string]interface{}) []string {
 	平均说来：
 	- 每个Go源文件引入了%.2f个库包，包含%.0f行代码（含空行）；
 	- 每个库包依赖于%.2f个其它库包，含有%.2f个源文件。
+	各个库包的耦合度量列在<a href="%s">这里</a>。
 
 `,
 
@@ -536,6 +756,7 @@ func (*Chinese) Text_PackageStatistics(values map[string]interface{}) []string {
 			values["averageCodeLineCountPerFile"],
 			values["averageDependencyCountPerPackage"],
 			values["averageSourceFileCountPerPackage"],
+			values["couplingPageURL"],
 
 		//values["gosourcefilesByImportsChartURL"],
 		//values["packagesByDependenciesChartURL"],
@@ -684,6 +905,35 @@ func (*Chinese) Text_Othertatistics(values map[string]interface{}) []string {
 	}
 }
 
+///////////////////////////////////////////////////////////////////
+// api diff page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_APIDiff(oldVersion, newVersion string) string {
+	return fmt.Sprintf("API变化：%s → %s", oldVersion, newVersion)
+}
+
+func (*Chinese) Text_APIDiffSummary(numBreakings, numCompatibles int) string {
+	return fmt.Sprintf("共发现%d处不兼容变化和%d处兼容变化。", numBreakings, numCompatibles)
+}
+
+func (*Chinese) Text_BreakingChanges() string { return "不兼容变化" }
+
+func (*Chinese) Text_CompatibleChanges() string { return "兼容变化" }
+
+func (*Chinese) Text_APIChange(change string) string {
+	switch change {
+	case "added":
+		return "新增"
+	case "removed":
+		return "删除"
+	case "changed":
+		return "修改"
+	default:
+		panic("unknown api change: " + change)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // footer
 ///////////////////////////////////////////////////////////////////
@@ -59,6 +59,10 @@ func (*English) Text_Server_Started() string {
 	return "Server started:"
 }
 
+func (*English) Text_NewerDocsAvailable() string {
+	return `Newer docs are available for the source changes. <b><a href="">Reload</a></b> to view them.`
+}
+
 ///////////////////////////////////////////////////////////////////
 // analyzing
 ///////////////////////////////////////////////////////////////////
@@ -238,11 +242,44 @@ func (*English) Text_SortByItem(by string) string {
 		return "dependency distance"
 	case "codelines":
 		return "lines of code"
+	case "uses":
+		return "use count"
 	default:
 		panic("unknown sort-by: " + by)
 	}
 }
 
+///////////////////////////////////////////////////////////////////
+// module page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_Module(modulePath string) string {
+	return fmt.Sprintf("Module: %s", modulePath)
+}
+
+func (*English) Text_ModuleInfoTitle(item string) string {
+	switch item {
+	case "version":
+		return "Version"
+	case "replacement":
+		return "Replaced By"
+	case "goversion":
+		return "Go Version"
+	case "repository":
+		return "Repository"
+	case "commit":
+		return "Commit"
+	case "packages":
+		return "Packages"
+	case "requires":
+		return "Requires"
+	case "requiredbys":
+		return "Required By"
+	default:
+		panic("unknown module info title: " + item)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // package details page: type details
 ///////////////////////////////////////////////////////////////////
@@ -292,6 +329,34 @@ func (*English) Text_InvolvedFiles(num int) string { return "Involved Source Fil
 
 func (*English) Text_Examples(num int) string { return "Code Examples" }
 
+func (*English) Text_Notes() string { return "Notes" }
+
+func (*English) Text_NumNotes(num int) string {
+	if num == 1 {
+		return "one note"
+	}
+	return fmt.Sprintf("%d notes", num)
+}
+
+func (*English) Text_RunExample() string { return "Run" }
+
+func (*English) Text_ExampleRunResult(result string) string {
+	switch result {
+	case "pass":
+		return "PASS: the output matches the output comment:"
+	case "fail":
+		return "FAIL: the example failed or its output doesn't match the output comment."
+	case "unverified":
+		return "The example has no output comment, so it is compiled but not run."
+	case "timeout":
+		return "The example is killed for running too long."
+	case "busy":
+		return "Too many examples are running. Please try again later."
+	default:
+		panic("unknown example run result: " + result)
+	}
+}
+
 func (*English) Text_PackageLevelTypeNames() string {
 	return "Package-Level Type Names"
 }
@@ -420,6 +485,10 @@ func (*English) Text_ImplementedBy() string {
 	return "Implemented By"
 }
 
+func (*English) Text_SatisfiedBy() string {
+	return "Satisfied By"
+}
+
 func (*English) Text_Implements() string {
 	return "Implements"
 }
@@ -436,6 +505,34 @@ func (*English) Text_AsTypesOf() string {
 	return "As Types Of"
 }
 
+func (*English) Text_AsInstantiation() string { return "as " }
+
+func (*English) Text_SatisfiesConstraint() string { return " satisfies " }
+
+func (*English) Text_Instantiations() string { return "Instantiations" }
+
+func (*English) Text_InstantiationsStat(numTypeArgLists, numSites int) string {
+	var lists, sites string
+	if numTypeArgLists == 1 {
+		lists = "one type argument list"
+	} else {
+		lists = fmt.Sprintf("%d type argument lists", numTypeArgLists)
+	}
+	if numSites == 1 {
+		sites = "one site"
+	} else {
+		sites = fmt.Sprintf("%d sites", numSites)
+	}
+	return lists + ", " + sites
+}
+
+func (*English) Text_NumInstantiationSites(num int) string {
+	if num == 1 {
+		return "one site"
+	}
+	return fmt.Sprintf("%d sites", num)
+}
+
 ///////////////////////////////////////////////////////////////////
 // package dependencies page
 ///////////////////////////////////////////////////////////////////
@@ -452,6 +549,25 @@ func (*English) Text_Imports() string { return "Imports" }
 
 func (*English) Text_ImportedBy() string { return "Imported By" }
 
+func (*English) Text_ImportChains() string { return "Why Imported" }
+
+func (*English) Text_DependencyGraph() string { return "Dependency Graph" }
+
+func (*English) Text_DependencyGraphOption(option string) string {
+	switch option {
+	case "depth":
+		return "depth"
+	case "module":
+		return "module"
+	case "all":
+		return "all"
+	case "download":
+		return "download"
+	default:
+		panic("unknown dependency graph option: " + option)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // method implementation page
 ///////////////////////////////////////////////////////////////////
@@ -498,6 +614,25 @@ func (*English) Text_ObjectKind(kind string) string {
 	}
 }
 
+func (*English) Text_Deprecated() string {
+	return "deprecated"
+}
+
+func (*English) Text_DeprecatedItemsHeader(show bool, num int, kind string) string {
+	action := "hide"
+	if show {
+		action = "show"
+	}
+	if num == 1 {
+		return fmt.Sprintf("/* %s one deprecated %s */", action, kind[:len(kind)-1])
+	}
+	return fmt.Sprintf("/* %s %d deprecated %s */", action, num, kind)
+}
+
+func (*English) Text_AvailableSince(version string) string {
+	return "since " + version
+}
+
 func (*English) Text_ObjectUses(num int) string {
 	if num == 1 {
 		return "one use"
@@ -505,6 +640,45 @@ func (*English) Text_ObjectUses(num int) string {
 	return fmt.Sprintf("%d uses", num)
 }
 
+///////////////////////////////////////////////////////////////////
+// call hierarchy page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_CallHierarchy() string { return "Call Hierarchy" }
+
+func (*English) Text_ViewCallHierarchy() string { return "view call hierarchy" }
+
+func (*English) Text_Callers(num int) string {
+	switch num {
+	case 0:
+		return "No callers"
+	case 1:
+		return "One caller"
+	}
+	return fmt.Sprintf("%d callers", num)
+}
+
+func (*English) Text_Callees(num int) string {
+	switch num {
+	case 0:
+		return "No callees"
+	case 1:
+		return "One callee"
+	}
+	return fmt.Sprintf("%d callees", num)
+}
+
+func (*English) Text_NumCalls(num int) string {
+	if num == 1 {
+		return "one call"
+	}
+	return fmt.Sprintf("%d calls", num)
+}
+
+func (*English) Text_DynamicCallVia() string { return "dynamic call via" }
+
+func (*English) Text_PackageLevelDeclarations() string { return "package-level declarations" }
+
 ///////////////////////////////////////////////////////////////////
 // source code page
 ///////////////////////////////////////////////////////////////////
@@ -517,6 +691,29 @@ func (*English) Text_SourceFilePath() string { return "Source File" }
 
 func (*English) Text_GeneratedFrom() string { return "Generated From" }
 
+///////////////////////////////////////////////////////////////////
+// search page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_Search() string { return "Search" }
+
+func (*English) Text_SearchResults(query string, num int) string {
+	switch num {
+	case 0:
+		return fmt.Sprintf("No identifiers matching %q", query)
+	case 1:
+		return fmt.Sprintf("One identifier matching %q", query)
+	}
+	return fmt.Sprintf("%d identifiers matching %q", num, query)
+}
+
+func (*English) Text_SearchResultsOmitted(num int) string {
+	if num == 1 {
+		return "(one more is omitted)"
+	}
+	return fmt.Sprintf("(%d more are omitted)", num)
+}
+
 ///////////////////////////////////////////////////////////////////
 // statistics
 ///////////////////////////////////////////////////////////////////
@@ -525,6 +722,69 @@ func (*English) Text_Statistics() string {
 	return "Statistics"
 }
 
+func (*English) Text_PackageCoupling() string {
+	return "Package Coupling"
+}
+
+func (*English) Text_CouplingMetricsLegend() string {
+	return `
+	Ca:     afferent couplings, the number of packages importing a package.
+	Ce:     efferent couplings, the number of packages imported by a package.
+	I:      instability, Ce / (Ca + Ce).
+	Height: the height of a package in the dependency graph.
+	Depth:  the depth of a package in the dependency graph (how close it is to main packages).
+`
+}
+
+func (*English) Text_DependencyStructureMatrix() string {
+	return "Dependency Structure Matrix"
+}
+
+func (*English) Text_DependencyStructureMatrixLegend() string {
+	return `
+	A row imports the marked columns. The packages are layered by their
+	heights (the lengths of their longest import chains), so imports are
+	above the diagonal. Thick lines separate the layers.
+	A package is built on the package in its parent directory, so
+	orange marks are layering violations (importing non-internal
+	packages in descendant directories). Red marks are import cycles.
+`
+}
+
+func (*English) Text_DeprecatedUses() string {
+	return "Deprecated Identifiers In Use"
+}
+
+func (*English) Text_DeprecatedUsesLegend() string {
+	return `
+	The deprecated packages imported by and the deprecated identifiers
+	used in the working directory packages. The uses in the packages
+	declaring the deprecated identifiers are not listed.
+`
+}
+
+func (*English) Text_DeprecatedUsesSection(kind string, num int) string {
+	switch kind {
+	case "packages":
+		return fmt.Sprintf("Deprecated Packages (%d)", num)
+	case "identifiers":
+		return fmt.Sprintf("Deprecated Identifiers (%d)", num)
+	}
+	panic("unknown kind: " + kind)
+}
+
+func (*English) Text_NewerStdAPIUses() string {
+	return "Newer Standard APIs In Use"
+}
+
+func (*English) Text_NewerStdAPIUsesLegend() string {
+	return `
+	The standard packages and identifiers used in the working directory
+	modules but introduced in Go versions newer than the go directives
+	in the go.mod files of the modules.
+`
+}
+
 func (*English) Text_ChartTitle(chartName string) string {
 	switch chartName {
 	case "gosourcefiles-by-imports":
@@ -588,6 +848,7 @@ func (*English) Text_PackageStatistics(values map[string]interface{}) []string {
 	Averagely,
 	- each Go source file imports %.2f packages and contains %.0f lines of code.
 	- each package depends %.2f other packages and contains %.2f source files.
+	The coupling metrics of packages are listed <a href="%s">here</a>.
 
 `,
 
@@ -604,6 +865,7 @@ func (*English) Text_PackageStatistics(values map[string]interface{}) []string {
 			values["averageCodeLineCountPerFile"],
 			values["averageDependencyCountPerPackage"],
 			values["averageSourceFileCountPerPackage"],
+			values["couplingPageURL"],
 
 			//values["gosourcefilesByImportsChartURL"],
 			//values["packagesByDependenciesChartURL"],
@@ -755,6 +1017,52 @@ func (*English) Text_Othertatistics(values map[string]interface{}) []string {
 	}
 }
 
+///////////////////////////////////////////////////////////////////
+// api diff page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_APIDiff(oldVersion, newVersion string) string {
+	return fmt.Sprintf("API Changes: %s → %s", oldVersion, newVersion)
+}
+
+func (*English) Text_APIDiffSummary(numBreakings, numCompatibles int) string {
+	var breakings, compatibles string
+	switch numBreakings {
+	case 0:
+		breakings = "no breaking changes"
+	case 1:
+		breakings = "one breaking change"
+	default:
+		breakings = fmt.Sprintf("%d breaking changes", numBreakings)
+	}
+	switch numCompatibles {
+	case 0:
+		compatibles = "no compatible changes"
+	case 1:
+		compatibles = "one compatible change"
+	default:
+		compatibles = fmt.Sprintf("%d compatible changes", numCompatibles)
+	}
+	return fmt.Sprintf("Found %s and %s.", breakings, compatibles)
+}
+
+func (*English) Text_BreakingChanges() string { return "Breaking Changes" }
+
+func (*English) Text_CompatibleChanges() string { return "Compatible Changes" }
+
+func (*English) Text_APIChange(change string) string {
+	switch change {
+	case "added":
+		return "added"
+	case "removed":
+		return "removed"
+	case "changed":
+		return "changed"
+	default:
+		panic("unknown api change: " + change)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////
 // footer
 ///////////////////////////////////////////////////////////////////
@@ -2,3 +2,38 @@
 // +build go1.18
 
 package generics
+
+import "fmt"
+
+// Implementation relations between generic types.
+
+type Getter[T any] interface{ Get() T }
+
+type StringerGetter[T fmt.Stringer] interface{ Get() T }
+
+type List[T any] struct{ items []T }
+
+func (l *List[T]) Get() T { return l.items[0] } // implements Getter[T], but not StringerGetter[T]
+
+type Box[T fmt.Stringer] struct{ v T }
+
+func (b Box[T]) Get() T { return b.v } // implements both
+
+type Counter struct{ n int }
+
+func (c Counter) Get() int { return c.n } // implements Getter[int]
+
+var IntList List[int]
+
+// Constraint satisfactions.
+
+type Integer interface{ ~int | ~int64 } // satisfied by int, int64 and Level
+
+type Level int
+
+type StringerInt interface { // satisfied by Level only, and implemented by nothing
+	~int
+	String() string
+}
+
+func (l Level) String() string { return fmt.Sprint(int(l)) }
@@ -23,9 +23,7 @@ func RunShellCommand(timeout time.Duration, wd string, envs []string, cmd string
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
-	command := exec.CommandContext(ctx, cmd, args...)
-	command.Dir = wd
-	command.Env = removeGODEBUG(append(os.Environ(), envs...))
+	command := NewCommand(ctx, wd, envs, cmd, args...)
 	var erroutput bytes.Buffer
 	command.Stderr = &erroutput
 	output, err := command.Output()
@@ -52,6 +50,15 @@ func RunShell(timeout time.Duration, wd string, envs []string, cmdAndArgs ...str
 	return RunShellCommand(timeout, wd, envs, cmdAndArgs[0], cmdAndArgs[1:]...)
 }
 
+// NewCommand creates a command which runs in the wd directory
+// with the current environment variables plus envs.
+func NewCommand(ctx context.Context, wd string, envs []string, cmd string, args ...string) *exec.Cmd {
+	command := exec.CommandContext(ctx, cmd, args...)
+	command.Dir = wd
+	command.Env = removeGODEBUG(append(os.Environ(), envs...))
+	return command
+}
+
 func removeGODEBUG(envs []string) []string {
 	r := envs[:0]
 	for _, e := range envs {